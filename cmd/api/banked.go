@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/evloader"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// bankedResponse is a /solve response for the banked-rerolls variant.
+type bankedResponse struct {
+	solver.RecommendationJSON
+	BankCap          int  `json:"bank_cap"`
+	RerollsAvailable int  `json:"rerolls_available"`            // the round's own plus the bank
+	BankAfterScoring *int `json:"bank_after_scoring,omitempty"` // carried over if the round is scored now; unset before the first roll
+}
+
+// solveBanked answers a /solve request that sets bank_cap. Explanations
+// and outcome profiles are for the standard game and are left out.
+func solveBanked(w http.ResponseWriter, req solveRequest, dice game.Dice, cs game.CategorySet, card *game.Scorecard, table *ev.Table) {
+	bankCap := *req.BankCap
+	if bankCap < 0 || bankCap > ev.MaxBankCap {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("bank_cap must be 0-%d", ev.MaxBankCap))
+		return
+	}
+	if req.Bank < 0 || req.Bank > bankCap {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("bank must be 0-%d", bankCap))
+		return
+	}
+	banked, err := bankedTables.Get(table.Model(), bankCap)
+	if errors.Is(err, evloader.ErrBusy) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, err.Error()+"; retry shortly")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rec := solver.NewBankedSolver(cs, banked).Solve(dice, req.RollsLeft, req.Bank)
+	rec.Scorecard = card
+	if req.RollsLeft < game.RollsPerRound {
+		roll := rollOrder(req.Dice, dice)
+		rec.Roll = &roll
+	}
+	res := bankedResponse{
+		RecommendationJSON: solver.RecommendationToJSON(rec),
+		BankCap:            bankCap,
+		RerollsAvailable:   solver.BankedRerolls(req.RollsLeft, req.Bank),
+	}
+	if req.RollsLeft < game.RollsPerRound {
+		bank := banked.BankAfter(res.RerollsAvailable)
+		res.BankAfterScoring = &bank
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
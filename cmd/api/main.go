@@ -1,6 +1,11 @@
 // Package main provides an HTTP API server for the Jumbleberry Fields solver.
 // POST /solve accepts JSON requests with dice, rolls_left, and categories,
-// returning optimal action recommendations.
+// returning optimal action recommendations. POST /plan returns the optimal
+// policy tree for the rest of the round as JSON, text or Graphviz DOT.
+// Either request may set face_probs to solve for a biased or house-made
+// die set; tables for such dice are computed on first use and cached.
+// A /solve request may set bank_cap to solve the banked-rerolls variant,
+// with bank the rerolls saved from earlier rounds.
 package main
 
 import (
@@ -13,15 +18,33 @@ import (
 
 	"github.com/iadams749/JBFieldsSolver/internal/ev"
 	"github.com/iadams749/JBFieldsSolver/internal/evloader"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
 	"github.com/iadams749/JBFieldsSolver/internal/solver"
 )
 
-var table *ev.Table
+// tables holds the EV table of every dice model requested so far.
+var tables *evloader.Cache
+
+// bankedTables holds the banked-rerolls EV tables requested so far.
+var bankedTables = evloader.NewBankedCache()
+
+// defaultModel is the dice model of requests without face_probs.
+var defaultModel *game.DiceModel
 
 type solveRequest struct {
-	Dice       string `json:"dice"`
-	RollsLeft  int    `json:"rolls_left"`
-	Categories string `json:"categories"`
+	Dice       string          `json:"dice"`
+	RollsLeft  int             `json:"rolls_left"`
+	Categories string          `json:"categories"`
+	Scorecard  json.RawMessage `json:"scorecard"`  // notation string or object; replaces categories
+	FaceProbs  json.RawMessage `json:"face_probs"` // weights as notation, array or object; default the server's dice
+	BankCap    *int            `json:"bank_cap"`   // set to play the banked-rerolls variant
+	Bank       int             `json:"bank"`       // rerolls saved from earlier rounds, with bank_cap
+}
+
+type planRequest struct {
+	solveRequest
+	Threshold *float64 `json:"threshold"` // collapse branches below this reach probability; 0 keeps all
+	Format    string   `json:"format"`    // "json" (default), "text" or "dot"
 }
 
 type errorResponse struct {
@@ -31,16 +54,33 @@ type errorResponse struct {
 func main() {
 	addr := flag.String("addr", ":8080", "listen address")
 	evPath := flag.String("ev", "ev_table.json", "path to EV table JSON")
+	faces := flag.String("faces", "", "face weights of the dice for requests without face_probs, or a .json file (default standard dice)")
 	flag.Parse()
 
 	var err error
-	table, err = evloader.Load(*evPath)
+	defaultModel, err = evloader.ParseModel(*faces)
+	if err != nil {
+		fmt.Printf("Fatal: -faces: %v\n", err)
+		os.Exit(1)
+	}
+	standard, err := evloader.Load(*evPath)
 	if err != nil {
 		fmt.Printf("Fatal: %v\n", err)
 		os.Exit(1)
 	}
+	tables = evloader.NewCache(standard)
+	if !defaultModel.IsDefault() {
+		// Load or compute the server's own dice up front.
+		table, err := evloader.LoadModel(*evPath, defaultModel)
+		if err != nil {
+			fmt.Printf("Fatal: %v\n", err)
+			os.Exit(1)
+		}
+		tables.Put(table)
+	}
 
 	http.HandleFunc("POST /solve", handleSolve)
+	http.HandleFunc("POST /plan", handlePlan)
 
 	log.Printf("Listening on %s", *addr)
 	log.Fatal(http.ListenAndServe(*addr, nil))
@@ -53,30 +93,166 @@ func handleSolve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dice, err := solver.ParseDice(req.Dice)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid dice: "+err.Error())
+	dice, cs, card, ok := parseState(w, req)
+	if !ok {
 		return
 	}
-
-	if req.RollsLeft < 0 || req.RollsLeft > 2 {
-		writeError(w, http.StatusBadRequest, "rolls_left must be 0, 1, or 2")
+	table, ok := tableFor(w, req)
+	if !ok {
 		return
 	}
-
-	cs, err := solver.ParseCategories(req.Categories)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid categories: "+err.Error())
+	if req.BankCap != nil {
+		solveBanked(w, req, dice, cs, card, table)
+		return
+	}
+	if req.Bank != 0 {
+		writeError(w, http.StatusBadRequest, "bank needs bank_cap")
 		return
 	}
 
 	rec := solver.Solve(dice, req.RollsLeft, cs, table)
+	rec.Scorecard = card
+	solver.Explain(&rec, dice, req.RollsLeft, cs, table)
+	solver.AddOutcomes(&rec, req.RollsLeft, cs, table)
+	if req.RollsLeft < game.RollsPerRound {
+		roll := rollOrder(req.Dice, dice)
+		rec.Roll = &roll
+	}
 	result := solver.RecommendationToJSON(rec)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
 
+func handlePlan(w http.ResponseWriter, r *http.Request) {
+	var req planRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.BankCap != nil || req.Bank != 0 {
+		writeError(w, http.StatusBadRequest, "plans are not available for the banked-rerolls variant")
+		return
+	}
+	dice, cs, _, ok := parseState(w, req.solveRequest)
+	if !ok {
+		return
+	}
+	table, ok := tableFor(w, req.solveRequest)
+	if !ok {
+		return
+	}
+
+	threshold := solver.DefaultPlanThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+	if threshold < 0 || threshold >= 1 {
+		writeError(w, http.StatusBadRequest, "threshold must be in [0, 1)")
+		return
+	}
+
+	plan := solver.PlanRound(dice, req.RollsLeft, cs, table, threshold)
+
+	switch req.Format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(solver.PlanToJSON(plan))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		solver.FormatPlan(w, plan)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		solver.WritePlanDOT(w, plan)
+	default:
+		writeError(w, http.StatusBadRequest, `format must be "json", "text", or "dot"`)
+	}
+}
+
+// parseState validates the game state in req, writing an error response
+// and returning false if it is invalid. The scorecard is nil unless the
+// request gives one.
+func parseState(w http.ResponseWriter, req solveRequest) (game.Dice, game.CategorySet, *game.Scorecard, bool) {
+	if req.RollsLeft < 0 || req.RollsLeft > game.RollsPerRound {
+		writeError(w, http.StatusBadRequest, "rolls_left must be 0, 1, 2, or 3")
+		return game.Dice{}, 0, nil, false
+	}
+
+	// With 3 rolls left the dice have not been rolled yet and are ignored.
+	var dice game.Dice
+	if req.RollsLeft < game.RollsPerRound {
+		var err error
+		dice, err = solver.ParseDice(req.Dice)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid dice: "+err.Error())
+			return game.Dice{}, 0, nil, false
+		}
+	}
+
+	if len(req.Scorecard) > 0 && string(req.Scorecard) != "null" {
+		if req.Categories != "" {
+			writeError(w, http.StatusBadRequest, "give either categories or scorecard, not both")
+			return game.Dice{}, 0, nil, false
+		}
+		card, err := solver.ParseScorecardJSON(req.Scorecard)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid scorecard: "+err.Error())
+			return game.Dice{}, 0, nil, false
+		}
+		if card.CategoriesLeft() == 0 {
+			writeError(w, http.StatusBadRequest, "scorecard is full: the game is over")
+			return game.Dice{}, 0, nil, false
+		}
+		return dice, card.CategoriesLeft(), &card, true
+	}
+
+	cs, err := solver.ParseCategories(req.Categories)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid categories: "+err.Error())
+		return game.Dice{}, 0, nil, false
+	}
+
+	if cs == 0 {
+		writeError(w, http.StatusBadRequest, "at least one category must be selected")
+		return game.Dice{}, 0, nil, false
+	}
+
+	return dice, cs, nil, true
+}
+
+// tableFor returns the EV table for the dice of req, writing an error
+// response and returning false if face_probs is invalid or the server is
+// busy computing the table for other dice.
+func tableFor(w http.ResponseWriter, req solveRequest) (*ev.Table, bool) {
+	model := defaultModel
+	if len(req.FaceProbs) > 0 && string(req.FaceProbs) != "null" {
+		var err error
+		model, err = game.ParseFaceProbsJSON(req.FaceProbs)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid face_probs: "+err.Error())
+			return nil, false
+		}
+	}
+	table, err := tables.Get(model)
+	if err != nil {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, err.Error()+"; retry shortly")
+		return nil, false
+	}
+	return table, true
+}
+
+// rollOrder returns the dice in the order they were given so hold masks
+// line up with the caller's dice. Count-format input has no order, so the
+// dice are laid out canonically (J, S, P, M, X).
+func rollOrder(input string, dice game.Dice) game.Roll {
+	if roll, err := solver.ParseRoll(input); err == nil {
+		return roll
+	}
+	return game.RollFromDice(dice)
+}
+
 func writeError(w http.ResponseWriter, status int, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/iadams749/JBFieldsSolver/internal/adaptive"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// learner feeds the rolls entered at the prompt to an adaptive.Advisor.
+// Only freshly rolled dice may be observed, so it remembers the last
+// state to tell the rerolled dice from the kept ones.
+type learner struct {
+	advisor *adaptive.Advisor
+
+	prevRollsLeft int
+	prevKeep      *game.Dice // recommended keep; nil unless the advice was to reroll
+}
+
+// observe adds the dice just rolled to the posterior: all of them on the
+// first roll of a round, otherwise those beyond the keep recommended for
+// the previous state. Rolls it cannot attribute are skipped with a note.
+func (l *learner) observe(w io.Writer, dice game.Dice, rollsLeft int) {
+	var rolled game.Dice
+	switch {
+	case rollsLeft == game.RollsPerRound:
+		return // nothing rolled yet
+	case rollsLeft == game.RollsPerRound-1:
+		rolled = dice
+	case l.prevKeep != nil && rollsLeft == l.prevRollsLeft-1 && contains(dice, *l.prevKeep):
+		for b := range dice {
+			rolled[b] = dice[b] - l.prevKeep[b]
+		}
+	default:
+		fmt.Fprintln(w, "  (Not learning from this roll: the rerolled dice are unknown. Enter every roll, keeping the recommended dice.)")
+		return
+	}
+
+	if l.advisor.Observe(rolled) {
+		p := l.advisor.Posterior()
+		fmt.Fprintf(w, "  Dice estimate after %d dice: %s\n", p.Dice(), formatEstimate(p))
+		fmt.Fprintf(w, "  Re-solved for the estimated dice (update %d).\n", l.advisor.Resolves())
+	}
+}
+
+// remember records the state just solved and its advice.
+func (l *learner) remember(rollsLeft int, rec solver.Recommendation) {
+	l.prevRollsLeft = rollsLeft
+	l.prevKeep = nil
+	if rec.BestAction.Type == solver.RerollAction {
+		keep := rec.BestAction.Keep
+		l.prevKeep = &keep
+	}
+}
+
+// report notes advice that differs from the advice for standard dice.
+func (l *learner) report(w io.Writer, adv adaptive.Advice) {
+	if !adv.Differs {
+		return
+	}
+	fmt.Fprintf(w, "Standard dice would %s instead; the advice for your dice is worth %+.2f more.\n",
+		solver.FormatAction(adv.Standard), adv.Gain)
+}
+
+// contains reports whether dice holds every die of keep.
+func contains(dice, keep game.Dice) bool {
+	for b := range dice {
+		if dice[b] < keep[b] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatEstimate returns the posterior mean of each face with its
+// standard deviation, in percent.
+func formatEstimate(p *adaptive.Posterior) string {
+	mean := p.Mean()
+	s := ""
+	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+		if b > 0 {
+			s += "  "
+		}
+		s += fmt.Sprintf("%c %.1f±%.1f%%", b.Letter(), mean[b]*100, p.StdDev(b)*100)
+	}
+	return s
+}
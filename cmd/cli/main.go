@@ -5,26 +5,114 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/iadams749/JBFieldsSolver/internal/adaptive"
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
 	"github.com/iadams749/JBFieldsSolver/internal/evloader"
 	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/robust"
 	"github.com/iadams749/JBFieldsSolver/internal/solver"
 )
 
 const evTablePath = "ev_table.json"
 
 func main() {
-	table, err := evloader.Load(evTablePath)
+	showPlan := flag.Bool("plan", false, "print the round plan tree after each reroll recommendation")
+	planThreshold := flag.Float64("plan-threshold", solver.DefaultPlanThreshold, "collapse plan branches reached with lower probability (0 keeps all)")
+	faces := flag.String("faces", "", "face weights of your dice, e.g. \"3,3,2,1,1\" or \"M=0.15 X=0.05 ...\", or a .json file (default standard dice)")
+	learn := flag.Bool("adaptive", false, "learn the face probabilities of your dice from the rolls you enter and adapt the advice")
+	priorStrength := flag.Float64("prior-strength", adaptive.DefaultStrength, "with -adaptive, how many dice the starting face probabilities are worth")
+	drift := flag.Float64("drift", adaptive.DefaultTolerance, "with -adaptive, re-solve once the estimate moves this far (L1 distance)")
+	goldenFaces := flag.String("golden", "", "face weights of a golden die with its own odds, e.g. \"1,1,2,3,1\" (default no golden die)")
+	goldenDie := flag.Int("golden-die", game.NumDice, "with -golden, the position of the golden die in the rolls you enter (1-5)")
+	uncertainty := flag.String("robust", "", "also show the play that is best in the worst case over face probabilities within \"l1:RADIUS\" or \"interval:WIDTH\" of your dice")
+	flag.Parse()
+
+	model, err := evloader.ParseModel(*faces)
+	if err != nil {
+		fmt.Printf("Fatal: -faces: %v\n", err)
+		os.Exit(1)
+	}
+	table, err := evloader.LoadModel(evTablePath, model)
 	if err != nil {
 		fmt.Printf("Fatal: %v\n", err)
 		os.Exit(1)
 	}
+	if !model.IsDefault() {
+		fmt.Printf("Custom dice: %s\n", model)
+	}
 	fmt.Printf("EV with all categories: %.2f\n\n", table.EV(game.AllCategories))
 
+	var mixed *ev.MixedTable
+	if *goldenFaces != "" {
+		if *learn || *uncertainty != "" || *showPlan {
+			fmt.Println("Fatal: -golden cannot be combined with -adaptive, -robust or -plan")
+			os.Exit(1)
+		}
+		golden, err := evloader.ParseModel(*goldenFaces)
+		if err != nil {
+			fmt.Printf("Fatal: -golden: %v\n", err)
+			os.Exit(1)
+		}
+		dice, err := game.GoldenDieSet(model, golden, *goldenDie-1)
+		if err != nil {
+			fmt.Printf("Fatal: -golden-die: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Mixed dice: %s\n", dice)
+		fmt.Println("Computing EV table for the mixed dice...")
+		mixed = ev.ComputeMixed(dice, nil)
+		fmt.Printf("EV with all categories: %.2f\n", mixed.EV(game.AllCategories))
+		fmt.Printf("Enter rolls in table order (e.g. JJSPM), with the golden die at position %d.\n\n", *goldenDie)
+	}
+
+	var robustTable *robust.Table
+	if *uncertainty != "" {
+		if *learn {
+			fmt.Println("Fatal: -robust and -adaptive cannot be combined")
+			os.Exit(1)
+		}
+		set, err := robust.ParseSet(*uncertainty, model)
+		if err != nil {
+			fmt.Printf("Fatal: -robust: %v\n", err)
+			os.Exit(1)
+		}
+		if robustTable, err = robust.Compute(set, model); err != nil {
+			fmt.Printf("Fatal: -robust: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Robust mode: dice anywhere in the %s.\n", set)
+		fmt.Printf("Robust play: worst-case EV %.2f; EV %.2f with your dice as given, %.2f below optimal play.\n\n",
+			robustTable.EV(game.AllCategories), robustTable.NominalEV(game.AllCategories),
+			table.EV(game.AllCategories)-robustTable.NominalEV(game.AllCategories))
+	}
+
+	var l *learner
+	if *learn {
+		posterior, err := adaptive.NewPosterior(model, *priorStrength)
+		if err != nil {
+			fmt.Printf("Fatal: -prior-strength: %v\n", err)
+			os.Exit(1)
+		}
+		standard := table
+		if !model.IsDefault() {
+			if standard, err = evloader.Load(evTablePath); err != nil {
+				fmt.Printf("Fatal: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		l = &learner{advisor: adaptive.NewAdvisor(standard, posterior, table)}
+		l.advisor.Tolerance = *drift
+		fmt.Println("Adaptive mode: enter every roll, first roll with 2 rolls left, keeping the")
+		fmt.Println("recommended dice, and the solver learns how your dice really roll.")
+		fmt.Println()
+	}
+
 	// REPL loop
 	scanner := bufio.NewScanner(os.Stdin)
 	fmt.Println("=== Jumbleberry Fields Solver ===")
@@ -35,6 +123,7 @@ func main() {
 	fmt.Println("  Letters: J=Jumbleberry  S=Sugarberry  P=Pickleberry  M=Moonberry  X=Pest")
 	fmt.Println("  Sequence format:  JJSPM       (one letter per die, 5 total)")
 	fmt.Println("  Count format:     2J 1S 1P 1M (space-separated, omitted types = 0)")
+	fmt.Println("  Not rolled yet:   -           (start of a round; skips Rolls Left)")
 	fmt.Println()
 	fmt.Println("--- Rolls Left ---")
 	fmt.Println("  0 = no rerolls (must score)    1 = one reroll left    2 = two rerolls left")
@@ -44,6 +133,8 @@ func main() {
 	fmt.Println("  Examples:  'all'          all 9 categories")
 	fmt.Println("             'all-j-s'      all except Jumbleberry and Sugarberry")
 	fmt.Println("             'j,m,3k,fr'    only those 4 categories")
+	fmt.Println("  Or a scorecard of used boxes ('-' = scratch), 'new' for a fresh one:")
+	fmt.Println("             'j=6 s=4 3k=23 mix=-'")
 	fmt.Println()
 
 	for {
@@ -57,31 +148,35 @@ func main() {
 			break
 		}
 
-		dice, err := solver.ParseDice(diceInput)
-		if err != nil {
-			fmt.Printf("  Error: %v\n\n", err)
-			continue
-		}
+		var dice game.Dice
+		rollsLeft := game.RollsPerRound
+		if diceInput != "-" {
+			dice, err = solver.ParseDice(diceInput)
+			if err != nil {
+				fmt.Printf("  Error: %v\n\n", err)
+				continue
+			}
 
-		// Prompt for rolls left
-		fmt.Print("Rolls left (0-2): ")
-		if !scanner.Scan() {
-			break
-		}
-		rollsInput := strings.TrimSpace(scanner.Text())
-		if rollsInput == "quit" || rollsInput == "exit" {
-			break
-		}
+			// Prompt for rolls left
+			fmt.Print("Rolls left (0-2): ")
+			if !scanner.Scan() {
+				break
+			}
+			rollsInput := strings.TrimSpace(scanner.Text())
+			if rollsInput == "quit" || rollsInput == "exit" {
+				break
+			}
 
-		rollsLeft, err := strconv.Atoi(rollsInput)
-		if err != nil || rollsLeft < 0 || rollsLeft > 2 {
-			fmt.Println("  Error: rolls left must be 0, 1, or 2")
-			fmt.Println()
-			continue
+			rollsLeft, err = strconv.Atoi(rollsInput)
+			if err != nil || rollsLeft < 0 || rollsLeft > 2 {
+				fmt.Println("  Error: rolls left must be 0, 1, or 2")
+				fmt.Println()
+				continue
+			}
 		}
 
 		// Prompt for categories
-		fmt.Print("Categories remaining: ")
+		fmt.Print("Categories remaining (or scorecard): ")
 		if !scanner.Scan() {
 			break
 		}
@@ -90,15 +185,74 @@ func main() {
 			break
 		}
 
-		cs, err := solver.ParseCategories(catInput)
-		if err != nil {
-			fmt.Printf("  Error: %v\n\n", err)
+		// Scorecard notation ("j=6 s=4 mix=-", or "new") replaces the
+		// category list and also gives the score so far.
+		var card *game.Scorecard
+		var cs game.CategorySet
+		if catInput == "new" || strings.Contains(catInput, "=") {
+			sc, err := solver.ParseScorecard(catInput)
+			if err != nil {
+				fmt.Printf("  Error: %v\n\n", err)
+				continue
+			}
+			if sc.CategoriesLeft() == 0 {
+				fmt.Println("  Error: scorecard is full: the game is over")
+				fmt.Println()
+				continue
+			}
+			card, cs = &sc, sc.CategoriesLeft()
+		} else {
+			cs, err = solver.ParseCategories(catInput)
+			if err != nil {
+				fmt.Printf("  Error: %v\n\n", err)
+				continue
+			}
+		}
+
+		if mixed != nil {
+			var roll game.Roll
+			if rollsLeft < game.RollsPerRound {
+				if roll, err = solver.ParseRoll(diceInput); err != nil {
+					fmt.Printf("  Error: %v\n\n", err)
+					continue
+				}
+			}
+			rec := solver.NewMixedSolver(cs, mixed).Solve(roll, rollsLeft)
+			solver.FormatMixedRecommendation(os.Stdout, rec, rollsLeft, cs)
+			fmt.Println()
 			continue
 		}
 
 		// Solve and display
-		rec := solver.Solve(dice, rollsLeft, cs, table)
+		var rec solver.Recommendation
+		var adv adaptive.Advice
+		if l != nil {
+			l.observe(os.Stdout, dice, rollsLeft)
+			table = l.advisor.Table()
+			adv = l.advisor.Advise(dice, rollsLeft, cs)
+			rec = adv.Recommendation
+			l.remember(rollsLeft, rec)
+		} else {
+			rec = solver.Solve(dice, rollsLeft, cs, table)
+		}
+		rec.Scorecard = card
+		solver.Explain(&rec, dice, rollsLeft, cs, table)
+		solver.AddOutcomes(&rec, rollsLeft, cs, table)
+		if roll, err := solver.ParseRoll(diceInput); err == nil {
+			rec.Roll = &roll
+		}
 		solver.FormatRecommendation(os.Stdout, rec, dice, rollsLeft, cs)
+		if l != nil {
+			l.report(os.Stdout, adv)
+		}
+		if robustTable != nil {
+			reportRobust(os.Stdout, robust.Advise(dice, rollsLeft, cs, robustTable, table))
+		}
+		if *showPlan && rec.BestAction.Type != solver.ScoreAction {
+			fmt.Println()
+			fmt.Println("Round plan:")
+			solver.FormatPlan(os.Stdout, solver.PlanRound(dice, rollsLeft, cs, table, *planThreshold))
+		}
 		fmt.Println()
 	}
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/iadams749/JBFieldsSolver/internal/robust"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// reportRobust prints the robust advice beside the regular advice: the
+// move that is best in the worst case, what it protects, and what it
+// costs if the dice are as believed.
+func reportRobust(w io.Writer, adv robust.Advice) {
+	if !adv.Differs {
+		fmt.Fprintf(w, "Robust play agrees: worst-case EV %.2f. Playing robustly from here gives up %.2f if the dice roll as expected.\n",
+			adv.Robust.EV, adv.Cost())
+		return
+	}
+	fmt.Fprintf(w, "Robust play would %s instead: worst-case EV %.2f against %.2f (%+.2f).\n",
+		solver.FormatAction(adv.Robust), adv.Robust.EV, adv.NominalWorst, adv.Protection())
+	fmt.Fprintf(w, "It gives up %.2f of expected score if the dice roll as expected.\n", adv.Cost())
+}
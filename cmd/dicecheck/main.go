@@ -0,0 +1,223 @@
+// Package main checks whether a set of physical dice matches the standard
+// face probabilities (game.FaceProb). It reads logs of rolls, estimates
+// the probability of each face with confidence intervals, runs a
+// chi-square test against FaceProb, and recomputes the EV table with the
+// estimated probabilities to show what the bias is worth.
+//
+// A log has one roll of five dice per line, in ordered ("JJSPM") or count
+// ("2J 1S 1P 1M") form; blank lines are skipped and # starts a comment.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
+)
+
+// faceEstimate is the estimated probability of one face.
+type faceEstimate struct {
+	Face     string  `json:"face"`
+	Count    int     `json:"count"`
+	Estimate float64 `json:"estimate"`
+	CILow    float64 `json:"ci_low"`
+	CIHigh   float64 `json:"ci_high"`
+	Expected float64 `json:"expected"` // game.FaceProb
+}
+
+// report is the result of a check, printed as text or JSON.
+type report struct {
+	Rolls        int            `json:"rolls"`
+	Dice         int            `json:"dice"`
+	Level        float64        `json:"level"`
+	Faces        []faceEstimate `json:"faces"`
+	ChiSquare    float64        `json:"chi_square"`
+	DF           int            `json:"df"`
+	PValue       float64        `json:"p_value"`
+	StandardEV   float64        `json:"standard_ev"`  // EV of a new game with FaceProb dice
+	EstimatedEV  float64        `json:"estimated_ev"` // EV with the estimated probabilities
+	EVDifference float64        `json:"ev_difference"`
+}
+
+func main() {
+	evPath := flag.String("ev", "ev_table.json", "path to EV table JSON for the standard dice")
+	level := flag.Float64("level", 0.95, "confidence level of the face probability intervals")
+	alpha := flag.Float64("alpha", 0.05, "significance level for reporting bias")
+	format := flag.String("format", "text", "output format: text or json")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: dicecheck [flags] [roll-log ...]")
+		fmt.Fprintln(os.Stderr, "Reads standard input if no logs are given.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *level <= 0 || *level >= 1 || *alpha <= 0 || *alpha >= 1 {
+		fmt.Fprintln(os.Stderr, "Error: -level and -alpha must be between 0 and 1")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, `Error: -format must be "text" or "json"`)
+		os.Exit(1)
+	}
+
+	var rolls []game.Dice
+	if flag.NArg() == 0 {
+		r, err := readRolls(os.Stdin, "stdin")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rolls = r
+	}
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		r, err := readRolls(f, path)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rolls = append(rolls, r...)
+	}
+	if len(rolls) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no rolls found")
+		os.Exit(1)
+	}
+
+	table, err := ev.LoadJSON(*evPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading EV table: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run the CLI or API first to generate ev_table.json")
+		os.Exit(1)
+	}
+
+	rep, err := check(rolls, table, *level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printReport(os.Stdout, rep, *alpha)
+}
+
+// readRolls reads a roll log; name labels errors.
+func readRolls(r io.Reader, name string) ([]game.Dice, error) {
+	var rolls []game.Dice
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		d, err := solver.ParseDice(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		rolls = append(rolls, d)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rolls, nil
+}
+
+// check estimates the face probabilities of rolls and compares them with
+// game.FaceProb; table is the EV table of the standard dice.
+func check(rolls []game.Dice, table *ev.Table, level float64) (report, error) {
+	// One observation per die, the face as an int, so the chi-square
+	// test can pool rare faces in small samples.
+	faces := stats.NewIntSample()
+	for _, d := range rolls {
+		for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+			for range d[b] {
+				faces.Add(int(b))
+			}
+		}
+	}
+	n := faces.N()
+
+	rep := report{Rolls: len(rolls), Dice: n, Level: level}
+	var estimated [game.NumBerryTypes]float64
+	expected := make(map[int]float64)
+	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+		k := faces.Count(int(b))
+		lo, hi := stats.ProportionCI(k, n, level)
+		estimated[b] = float64(k) / float64(n)
+		expected[int(b)] = game.FaceProb[b]
+		rep.Faces = append(rep.Faces, faceEstimate{
+			Face:     b.String(),
+			Count:    k,
+			Estimate: estimated[b],
+			CILow:    lo,
+			CIHigh:   hi,
+			Expected: game.FaceProb[b],
+		})
+	}
+	chi := faces.ChiSquare(expected)
+	rep.ChiSquare, rep.DF, rep.PValue = chi.Statistic, chi.DF, chi.P
+
+	model, err := game.NewDiceModel(estimated)
+	if err != nil {
+		return report{}, err
+	}
+	rep.StandardEV = table.EV(game.AllCategories)
+	rep.EstimatedEV = ev.ComputeWith(model, nil).EV(game.AllCategories)
+	rep.EVDifference = rep.EstimatedEV - rep.StandardEV
+	return rep, nil
+}
+
+// printReport writes the human-readable report.
+func printReport(w io.Writer, rep report, alpha float64) {
+	fmt.Fprintf(w, "Rolls: %d (%d dice)\n\n", rep.Rolls, rep.Dice)
+
+	fmt.Fprintf(w, "  %-12s  %6s  %8s  %-18s  %8s\n", "Face", "Count", "Observed", fmt.Sprintf("%.0f%% CI", rep.Level*100), "Expected")
+	for _, f := range rep.Faces {
+		ci := fmt.Sprintf("[%.2f%%, %.2f%%]", f.CILow*100, f.CIHigh*100)
+		mark := ""
+		if f.Expected < f.CILow || f.Expected > f.CIHigh {
+			mark = "  *"
+		}
+		fmt.Fprintf(w, "  %-12s  %6d  %7.2f%%  %-18s  %7.2f%%%s\n", f.Face, f.Count, f.Estimate*100, ci, f.Expected*100, mark)
+	}
+	fmt.Fprintln(w, "  * expected probability outside the interval")
+	fmt.Fprintln(w)
+
+	if rep.DF == 0 {
+		// Every face was pooled into one bin, so there is nothing to test.
+		fmt.Fprintln(w, "Too few dice for a chi-square test against FaceProb; log more rolls.")
+	} else {
+		fmt.Fprintf(w, "Chi-square vs FaceProb: %.2f on %d df, p = %.4g\n", rep.ChiSquare, rep.DF, rep.PValue)
+		if rep.PValue < alpha {
+			fmt.Fprintf(w, "The dice look biased (p < %g).\n", alpha)
+		} else {
+			fmt.Fprintf(w, "No evidence of bias (p >= %g).\n", alpha)
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Expected score of a new game under optimal play:")
+	fmt.Fprintf(w, "  Standard dice:   %.4f\n", rep.StandardEV)
+	fmt.Fprintf(w, "  Estimated dice:  %.4f  (%+.4f)\n", rep.EstimatedEV, rep.EVDifference)
+}
@@ -0,0 +1,81 @@
+// Package main generates counter-intuitive Jumbleberry Fields positions,
+// where the optimal play differs from the points-now choice, and exports
+// them as JSON with solutions and explanations.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/puzzle"
+)
+
+func main() {
+	defaults := puzzle.DefaultOptions()
+	evPath := flag.String("ev", "ev_table.json", "path to EV table JSON")
+	outPath := flag.String("out", "", "write puzzles to this JSON file (default stdout)")
+	limit := flag.Int("limit", 100, "maximum number of puzzles to export (0 = all)")
+	minGap := flag.Float64("min-gap", defaults.MinGap, "minimum EV gap between optimal and intuitive play")
+	minCats := flag.Int("min-categories", defaults.MinCategories, "only search states with at least this many categories left")
+	daily := flag.Bool("daily", false, "export only the puzzle of the day")
+	dateStr := flag.String("date", "", "date for -daily as YYYY-MM-DD (default today)")
+	flag.Parse()
+
+	table, err := ev.LoadJSON(*evPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading EV table: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run the CLI or API first to generate ev_table.json")
+		os.Exit(1)
+	}
+
+	date := time.Now()
+	if *dateStr != "" {
+		date, err = time.Parse(time.DateOnly, *dateStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -date: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := puzzle.Options{
+		MinGap:        *minGap,
+		MinCategories: *minCats,
+		Limit:         *limit,
+	}
+	if *daily {
+		// The daily pick must not depend on -limit, so search everything.
+		opts.Limit = 0
+	}
+	puzzles := puzzle.Generate(table, opts)
+
+	if *daily {
+		p, ok := puzzle.OfTheDay(puzzles, date)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: no puzzles found")
+			os.Exit(1)
+		}
+		puzzles = []puzzle.Puzzle{p}
+	}
+
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := puzzle.WriteJSON(out, puzzles); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing puzzles: %v\n", err)
+		os.Exit(1)
+	}
+	if *outPath != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %d puzzles to %s\n", len(puzzles), *outPath)
+	}
+}
@@ -0,0 +1,225 @@
+// Package main reports how sensitive the expected score and the optimal
+// decisions are to the rules: the probability of each face (the others
+// rescaled to compensate) and the points of each berry. It prints the
+// derivative of the EV with respect to each parameter and, for a given
+// position, the values at which the best action changes, e.g. "if
+// P(Moonberry) > 0.1342, keep 1M 1P beats keep 1M". It answers design
+// questions before a house rule is tried at the table.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/evloader"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/sensitivity"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// derivative is the sensitivity of the EV to one parameter.
+type derivative struct {
+	param      sensitivity.Param
+	Param      string  `json:"param"`
+	Value      float64 `json:"value"`
+	Derivative float64 `json:"derivative"` // d EV / d param
+}
+
+// flipJSON is a decision flip.
+type flipJSON struct {
+	Param     string             `json:"param"`
+	Direction string             `json:"direction"` // "up" or "down"
+	Found     bool               `json:"found"`
+	Threshold float64            `json:"threshold,omitempty"`
+	Limit     float64            `json:"limit,omitempty"` // end of the range searched, if not found
+	To        *solver.ActionJSON `json:"to,omitempty"`
+}
+
+// decision is the flip analysis of one position.
+type decision struct {
+	Dice      string            `json:"dice"`
+	RollsLeft int               `json:"rolls_left"`
+	Best      solver.ActionJSON `json:"best"`
+	Flips     []flipJSON        `json:"flips"`
+}
+
+// report is the result, printed as text or JSON.
+type report struct {
+	FaceProbs   [game.NumBerryTypes]float64 `json:"face_probs"`
+	Points      [game.NumBerryTypes]float64 `json:"points"`
+	Categories  []string                    `json:"categories"`
+	EV          float64                     `json:"ev"`
+	Derivatives []derivative                `json:"derivatives"`
+	Decision    *decision                   `json:"decision,omitempty"`
+}
+
+func main() {
+	categories := flag.String("categories", "all", "categories left, e.g. \"all\", \"all-j-s\" or \"m,3k,mix\"")
+	diceInput := flag.String("dice", "", "also find where the best action for these dice changes, e.g. JJSPM")
+	rollsLeft := flag.Int("rolls-left", 2, "rerolls left with -dice (0-2)")
+	paramsInput := flag.String("params", "", "comma-separated parameters, e.g. \"p:M,pts:M\" (default all ten)")
+	faces := flag.String("faces", "", "face weights to start from, e.g. \"3,3,2,1,1\", or a .json file (default standard dice)")
+	pointsInput := flag.String("points", "", "berry points to start from, e.g. \"2,2,4,7,0\" or \"M=8\" (default standard points)")
+	format := flag.String("format", "text", "output format: text or json")
+	flag.Parse()
+
+	if *format != "text" && *format != "json" {
+		fatalf(`-format must be "text" or "json"`)
+	}
+	cs, err := solver.ParseCategories(*categories)
+	if err != nil {
+		fatalf("-categories: %v", err)
+	}
+	if cs == 0 {
+		fatalf("-categories: no categories left")
+	}
+	model, err := evloader.ParseModel(*faces)
+	if err != nil {
+		fatalf("-faces: %v", err)
+	}
+	points, err := sensitivity.ParsePoints(*pointsInput)
+	if err != nil {
+		fatalf("-points: %v", err)
+	}
+	params := sensitivity.AllParams()
+	if *paramsInput != "" {
+		params = nil
+		for _, s := range strings.Split(*paramsInput, ",") {
+			p, err := sensitivity.ParseParam(s)
+			if err != nil {
+				fatalf("-params: %v", err)
+			}
+			params = append(params, p)
+		}
+	}
+	var dice game.Dice
+	if *diceInput != "" {
+		if *rollsLeft < 0 || *rollsLeft >= game.RollsPerRound {
+			fatalf("-rolls-left must be 0, 1, or 2")
+		}
+		if dice, err = solver.ParseDice(*diceInput); err != nil {
+			fatalf("-dice: %v", err)
+		}
+	}
+
+	rules := sensitivity.Rules{FaceProb: model.FaceProb(), Points: points}
+	table, err := sensitivity.Compute(rules, cs)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	rep := report{FaceProbs: rules.FaceProb, Points: rules.Points, EV: table.EV(cs)}
+	cs.ForEach(func(c game.Category) {
+		rep.Categories = append(rep.Categories, c.String())
+	})
+	grad := table.Gradient(cs)
+	for _, p := range params {
+		rep.Derivatives = append(rep.Derivatives, derivative{param: p, Param: p.String(), Value: rules.Value(p), Derivative: grad[p]})
+	}
+	if *format == "text" {
+		printHeader(os.Stdout, rep)
+	}
+
+	if *diceInput != "" {
+		best := table.Actions(dice, *rollsLeft, cs)[0].Action
+		dec := &decision{Dice: *diceInput, RollsLeft: *rollsLeft, Best: solver.ActionToJSON(best)}
+		if *format == "text" {
+			fmt.Printf("Dice %s, rolls left %d: %s  (EV %.4f)\n", *diceInput, *rollsLeft, solver.FormatAction(best), best.EV)
+			fmt.Println("The best action changes:")
+		}
+		for _, p := range params {
+			for _, up := range []bool{true, false} {
+				f, err := sensitivity.FindFlip(table, dice, *rollsLeft, cs, p, up)
+				if err != nil {
+					fatalf("%v: %v", p, err)
+				}
+				dec.Flips = append(dec.Flips, toFlipJSON(f))
+				if *format == "text" {
+					printFlip(os.Stdout, f)
+				}
+			}
+		}
+		rep.Decision = dec
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rep); err != nil {
+			fatalf("writing JSON: %v", err)
+		}
+	}
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func toFlipJSON(f sensitivity.Flip) flipJSON {
+	fj := flipJSON{Param: f.Param.String(), Direction: "down", Found: f.Found}
+	if f.Up {
+		fj.Direction = "up"
+	}
+	if f.Found {
+		fj.Threshold = f.Threshold
+		to := solver.ActionToJSON(f.To)
+		fj.To = &to
+	} else {
+		fj.Limit = f.Limit
+	}
+	return fj
+}
+
+// formatValue formats a parameter value: probabilities to four places,
+// points as short as possible.
+func formatValue(p sensitivity.Param, v float64) string {
+	if p.IsProb() {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// printHeader writes the rules, the EV and its derivatives.
+func printHeader(w io.Writer, rep report) {
+	fmt.Fprint(w, "Faces:")
+	for b, p := range rep.FaceProbs {
+		fmt.Fprintf(w, " %c %.2f%%", game.Berry(b).Letter(), p*100)
+	}
+	fmt.Fprint(w, "\nPoints:")
+	for b, p := range rep.Points {
+		fmt.Fprintf(w, " %c %g", game.Berry(b).Letter(), p)
+	}
+	fmt.Fprintf(w, "\nCategories left: %s\n", strings.Join(rep.Categories, ", "))
+	fmt.Fprintf(w, "EV: %.4f\n\n", rep.EV)
+
+	// Probabilities move in steps of a percentage point, points by one.
+	fmt.Fprintf(w, "  %-20s  %8s  %11s  %s\n", "Parameter", "Value", "dEV/dparam", "EV change per step")
+	for _, d := range rep.Derivatives {
+		p := d.param
+		step, label := 1.0, "+1 point"
+		if p.IsProb() {
+			step, label = 0.01, "+1 pp"
+		}
+		fmt.Fprintf(w, "  %-20s  %8s  %+11.4f  %+.4f per %s\n", d.Param, formatValue(p, d.Value), d.Derivative, d.Derivative*step, label)
+	}
+	fmt.Fprintln(w)
+}
+
+// printFlip writes one decision flip.
+func printFlip(w io.Writer, f sensitivity.Flip) {
+	cmp, dir := "<", "down"
+	if f.Up {
+		cmp, dir = ">", "up"
+	}
+	if !f.Found {
+		fmt.Fprintf(w, "  %s: never, going %s to %s\n", f.Param, dir, formatValue(f.Param, f.Limit))
+		return
+	}
+	fmt.Fprintf(w, "  if %s %s %s, %s beats %s\n", f.Param, cmp, formatValue(f.Param, f.Threshold), solver.FormatAction(f.To), solver.FormatAction(f.From))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
+)
+
+// bankedResult is the result of a -bank-cap run, printed as text or JSON.
+type bankedResult struct {
+	Games          int     `json:"games"`
+	Seed           uint64  `json:"seed"`
+	Workers        int     `json:"workers"`
+	BankCap        int     `json:"bank_cap"`
+	Dice           string  `json:"dice,omitempty"` // face probabilities; empty for standard dice
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	TheoreticalEV  float64 `json:"theoretical_ev"`
+	StandardEV     float64 `json:"standard_ev"` // EV of the game without banking
+	Mean           float64 `json:"mean"`
+	Difference     float64 `json:"difference"` // mean - theoretical EV
+	CILow          float64 `json:"ci_low"`
+	CIHigh         float64 `json:"ci_high"`
+	StdError       float64 `json:"std_error"`
+	StdDev         float64 `json:"std_dev"`
+	Min            int     `json:"min"`
+	Max            int     `json:"max"`
+	EarlyScores    float64 `json:"early_scores"`   // rounds per game scored with rerolls left
+	BankedRerolls  float64 `json:"banked_rerolls"` // rerolls per game spent from the bank
+}
+
+// bankedStats accumulates the results of the games one worker played.
+type bankedStats struct {
+	scores *stats.IntSample // final scores
+	early  *stats.IntSample // rounds scored with rerolls left, per game
+	spent  *stats.IntSample // rerolls spent from the bank, per game
+}
+
+func newBankedStats() *bankedStats {
+	return &bankedStats{scores: stats.NewIntSample(), early: stats.NewIntSample(), spent: stats.NewIntSample()}
+}
+
+// merge adds the results in o to s.
+func (s *bankedStats) merge(o *bankedStats) {
+	s.scores.Merge(o.scores)
+	s.early.Merge(o.early)
+	s.spent.Merge(o.spent)
+}
+
+// bankedSolvers builds one solver per category set on first use and
+// shares it between workers.
+type bankedSolvers struct {
+	table   *ev.BankedTable
+	once    [512]sync.Once
+	solvers [512]*solver.BankedSolver
+}
+
+func (s *bankedSolvers) get(cs game.CategorySet) *solver.BankedSolver {
+	s.once[cs].Do(func() {
+		s.solvers[cs] = solver.NewBankedSolver(cs, s.table)
+	})
+	return s.solvers[cs]
+}
+
+// playBanked plays one game of the banked-rerolls variant optimally,
+// spending the round's own rerolls before the bank's, and records it.
+func playBanked(src game.DiceSource, solvers *bankedSolvers, st *bankedStats) error {
+	roll := func(keep game.Dice) (game.Dice, error) {
+		faces, err := src.Roll(game.NumDice - keep.Total())
+		for _, b := range faces {
+			keep[b]++
+		}
+		return keep, err
+	}
+
+	score, early, spent, bank := 0, 0, 0, 0
+	for cs := game.AllCategories; cs != 0; {
+		bs := solvers.get(cs)
+		dice, err := roll(game.Dice{})
+		if err != nil {
+			return err
+		}
+		rollsLeft := game.RollsPerRound - 1
+		for {
+			rec := bs.Solve(dice, rollsLeft, bank)
+			if rec.BestAction.Type == solver.ScoreAction {
+				cat := rec.BestAction.Category
+				score += game.Score(dice, cat)
+				if rollsLeft > 0 {
+					early++
+				}
+				bank = solvers.table.BankAfter(rollsLeft + bank)
+				cs = cs.Remove(cat)
+				break
+			}
+			if rollsLeft > 0 {
+				rollsLeft--
+			} else {
+				bank--
+				spent++
+			}
+			if dice, err = roll(rec.BestAction.Keep); err != nil {
+				return err
+			}
+		}
+	}
+	st.scores.Add(score)
+	st.early.Add(early)
+	st.spent.Add(spent)
+	return nil
+}
+
+// runBanked plays games 0..n-1 of the banked-rerolls variant from a new
+// game, game i rolling from the PCG stream (seed, i) as in a normal run.
+func runBanked(table *ev.BankedTable, standardEV float64, n, workers int, seed uint64, progress io.Writer) (bankedResult, error) {
+	began := time.Now()
+	solvers := &bankedSolvers{table: table}
+
+	perWorker := make([]*bankedStats, workers)
+	var next, done atomic.Int64
+	errs := make(chan error, workers)
+	progressStep := int64(max(n/10, 1))
+	var wg sync.WaitGroup
+	for w := range perWorker {
+		st := newBankedStats()
+		perWorker[w] = st
+		wg.Go(func() {
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				src := game.NewModelSource(rand.New(rand.NewPCG(seed, uint64(i))), table.Model())
+				if err := playBanked(src, solvers, st); err != nil {
+					errs <- fmt.Errorf("simulating game %d: %w", i+1, err)
+					next.Store(int64(n)) // stop the other workers
+					return
+				}
+				if d := done.Add(1); d%progressStep == 0 {
+					fmt.Fprintf(progress, "  %d/%d games  (%v elapsed)\n", d, n, time.Since(began).Round(time.Millisecond))
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return bankedResult{}, err
+	}
+
+	merged := newBankedStats()
+	for _, st := range perWorker {
+		merged.merge(st)
+	}
+	theoretical := table.EV(game.AllCategories, 0)
+	lo, hi := merged.scores.MeanCI(ciLevel)
+	return bankedResult{
+		Games:          n,
+		Seed:           seed,
+		Workers:        workers,
+		BankCap:        table.Cap(),
+		Dice:           diceLabel(table.Model()),
+		ElapsedSeconds: time.Since(began).Seconds(),
+		TheoreticalEV:  theoretical,
+		StandardEV:     standardEV,
+		Mean:           merged.scores.Mean(),
+		Difference:     merged.scores.Mean() - theoretical,
+		CILow:          lo,
+		CIHigh:         hi,
+		StdError:       merged.scores.StdErr(),
+		StdDev:         merged.scores.StdDev(),
+		Min:            merged.scores.Min(),
+		Max:            merged.scores.Max(),
+		EarlyScores:    merged.early.Mean(),
+		BankedRerolls:  merged.spent.Mean(),
+	}, nil
+}
+
+// printBanked writes the human-readable report of a -bank-cap run.
+func printBanked(w io.Writer, res bankedResult) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "=== Results (banked rerolls, cap %d) ===\n", res.BankCap)
+	if res.Dice != "" {
+		fmt.Fprintf(w, "Dice:             %s\n", res.Dice)
+	}
+	fmt.Fprintf(w, "Games simulated:  %d\n", res.Games)
+	fmt.Fprintf(w, "Time elapsed:     %v\n", time.Duration(res.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Theoretical EV:   %.4f  (%+.4f over the standard game)\n", res.TheoreticalEV, res.TheoreticalEV-res.StandardEV)
+	fmt.Fprintf(w, "Simulated mean:   %.4f  [%.4f, %.4f] (%.0f%% CI)\n", res.Mean, res.CILow, res.CIHigh, ciLevel*100)
+	fmt.Fprintf(w, "Difference:       %+.4f\n", res.Difference)
+	fmt.Fprintf(w, "Std error:        %.4f\n", res.StdError)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Standard dev:     %.4f\n", res.StdDev)
+	fmt.Fprintf(w, "Min score:        %d\n", res.Min)
+	fmt.Fprintf(w, "Max score:        %d\n", res.Max)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Per game: %.2f rounds scored early, %.2f rerolls spent from the bank\n", res.EarlyScores, res.BankedRerolls)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/policy"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
+)
+
+// decisionStream marks the PCG streams that randomized policies draw
+// from, keeping them apart from the dice streams.
+const decisionStream = 1 << 63
+
+// newGameSources returns the dice source and decision RNG of game i, with
+// dice rolling by model. Every policy gets fresh copies, so all of them
+// see the same dice.
+func newGameSources(seed uint64, i int, model *game.DiceModel) (game.DiceSource, *rand.Rand) {
+	dice := game.NewModelSource(rand.New(rand.NewPCG(seed, uint64(i))), model)
+	return dice, rand.New(rand.NewPCG(seed, uint64(i)|decisionStream))
+}
+
+// comparison is the result of a -compare run, printed as text or JSON.
+type comparison struct {
+	Games          int            `json:"games"`
+	Seed           uint64         `json:"seed"`
+	Workers        int            `json:"workers"`
+	Start          *startJSON     `json:"start,omitempty"`
+	Dice           string         `json:"dice,omitempty"` // face probabilities; empty for standard dice
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+	Policies       []policyResult `json:"policies"`
+	Pairs          []pairResult   `json:"pairs"`
+}
+
+// policyResult is the final score distribution of one policy.
+type policyResult struct {
+	Name     string  `json:"name"`
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"std_dev"`
+	StdError float64 `json:"std_error"`
+
+	scores *stats.IntSample // final scores, for charts
+}
+
+// pairResult compares policies A and B game by game.
+type pairResult struct {
+	A              string  `json:"a"`
+	B              string  `json:"b"`
+	MeanDifference float64 `json:"mean_difference"` // A - B
+	CILow          float64 `json:"ci_low"`
+	CIHigh         float64 `json:"ci_high"`
+	StdError       float64 `json:"std_error"`
+	Z              float64 `json:"z"`
+	PValue         float64 `json:"p_value"` // two-sided paired test
+	AWins          float64 `json:"a_wins"`  // fraction of games
+	Ties           float64 `json:"ties"`
+	BWins          float64 `json:"b_wins"`
+}
+
+// compareStats accumulates the results of the games one worker played.
+type compareStats struct {
+	scores []*stats.IntSample   // per policy
+	diffs  [][]*stats.IntSample // diffs[a][b]: score of a minus b, for a < b
+}
+
+func newCompareStats(numPolicies int) *compareStats {
+	s := &compareStats{
+		scores: make([]*stats.IntSample, numPolicies),
+		diffs:  make([][]*stats.IntSample, numPolicies),
+	}
+	for a := range numPolicies {
+		s.scores[a] = stats.NewIntSample()
+		s.diffs[a] = make([]*stats.IntSample, numPolicies)
+		for b := a + 1; b < numPolicies; b++ {
+			s.diffs[a][b] = stats.NewIntSample()
+		}
+	}
+	return s
+}
+
+// add records the final scores of one game, one per policy.
+func (s *compareStats) add(scores []int) {
+	for a, sa := range scores {
+		s.scores[a].Add(sa)
+		for b := a + 1; b < len(scores); b++ {
+			s.diffs[a][b].Add(sa - scores[b])
+		}
+	}
+}
+
+// merge adds the results in o to s.
+func (s *compareStats) merge(o *compareStats) {
+	for a := range s.scores {
+		s.scores[a].Merge(o.scores[a])
+		for b := a + 1; b < len(s.scores); b++ {
+			s.diffs[a][b].Merge(o.diffs[a][b])
+		}
+	}
+}
+
+// runCompare plays games 0..n-1 with every policy from start, each policy
+// replaying the same dice stream for a game (common random numbers), and
+// compares the final scores game by game.
+func runCompare(policies []policy.Policy, model *game.DiceModel, n, workers int, seed uint64, start game.GameState, progress io.Writer) (comparison, error) {
+	began := time.Now()
+
+	perWorker := make([]*compareStats, workers)
+	var next, done atomic.Int64
+	errs := make(chan error, workers)
+	progressStep := int64(max(n/10, 1))
+	var wg sync.WaitGroup
+	for w := range perWorker {
+		st := newCompareStats(len(policies))
+		perWorker[w] = st
+		wg.Go(func() {
+			scores := make([]int, len(policies))
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				for k, p := range policies {
+					dice, rng := newGameSources(seed, i, model)
+					g, err := policy.Play(dice, p.NewPlayer(rng), start)
+					if err != nil {
+						errs <- fmt.Errorf("game %d, policy %s: %w", i+1, p.Name(), err)
+						next.Store(int64(n)) // stop the other workers
+						return
+					}
+					scores[k] = int(start.Score) + g.Total()
+				}
+				st.add(scores)
+				if d := done.Add(1); d%progressStep == 0 {
+					fmt.Fprintf(progress, "  %d/%d games  (%v elapsed)\n", d, n, time.Since(began).Round(time.Millisecond))
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return comparison{}, err
+	}
+
+	merged := newCompareStats(len(policies))
+	for _, st := range perWorker {
+		merged.merge(st)
+	}
+
+	cmp := comparison{
+		Games:          n,
+		Seed:           seed,
+		Workers:        workers,
+		Start:          newStartJSON(start),
+		Dice:           diceLabel(model),
+		ElapsedSeconds: time.Since(began).Seconds(),
+	}
+	for a, p := range policies {
+		s := merged.scores[a]
+		cmp.Policies = append(cmp.Policies, policyResult{
+			Name:     p.Name(),
+			Mean:     s.Mean(),
+			StdDev:   s.StdDev(),
+			StdError: s.StdErr(),
+			scores:   s,
+		})
+	}
+	for a := range policies {
+		for b := a + 1; b < len(policies); b++ {
+			d := merged.diffs[a][b]
+			lo, hi := d.MeanCI(ciLevel)
+			z, p := d.ZTest(0)
+			ties := d.Count(0)
+			bWins := 0
+			for _, x := range d.Values() {
+				if x < 0 {
+					bWins += d.Count(x)
+				}
+			}
+			cmp.Pairs = append(cmp.Pairs, pairResult{
+				A:              policies[a].Name(),
+				B:              policies[b].Name(),
+				MeanDifference: d.Mean(),
+				CILow:          lo,
+				CIHigh:         hi,
+				StdError:       d.StdErr(),
+				Z:              z,
+				PValue:         p,
+				AWins:          float64(n-ties-bWins) / float64(n),
+				Ties:           float64(ties) / float64(n),
+				BWins:          float64(bWins) / float64(n),
+			})
+		}
+	}
+	return cmp, nil
+}
+
+// printComparison writes the human-readable comparison report.
+func printComparison(w io.Writer, cmp comparison, start game.GameState) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "=== Policy comparison (common random numbers) ===")
+	if cmp.Start != nil {
+		fmt.Fprintf(w, "Starting from:    %s\n", describeStart(start))
+	}
+	if cmp.Dice != "" {
+		fmt.Fprintf(w, "Dice:             %s\n", cmp.Dice)
+	}
+	fmt.Fprintf(w, "Games per policy: %d\n", cmp.Games)
+	fmt.Fprintf(w, "Time elapsed:     %v\n", time.Duration(cmp.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "  %-16s  %9s  %8s  %9s\n", "Policy", "Mean", "Std dev", "Std error")
+	for _, p := range cmp.Policies {
+		fmt.Fprintf(w, "  %-16s  %9.4f  %8.4f  %9.4f\n", p.Name, p.Mean, p.StdDev, p.StdError)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Paired differences (%.0f%% CI):\n", ciLevel*100)
+	for _, p := range cmp.Pairs {
+		fmt.Fprintf(w, "  %s - %s:  %+.4f  [%+.4f, %+.4f]  z = %.2f  p = %.3g\n",
+			p.A, p.B, p.MeanDifference, p.CILow, p.CIHigh, p.Z, p.PValue)
+		fmt.Fprintf(w, "    head to head: %s wins %.1f%%, ties %.1f%%, %s wins %.1f%%\n",
+			p.A, p.AWins*100, p.Ties*100, p.B, p.BWins*100)
+	}
+}
@@ -1,234 +1,552 @@
 // Package main simulates many games of Jumbleberry Fields using optimal play
 // to validate the theoretical expected value and compute standard deviation.
+// Games start from a new game, or from a mid-game position given by a
+// scorecard or the remaining categories and score, optionally with the
+// dice of a round in progress.
+//
+// With -replay, one game of an earlier run is played again and every
+// decision is printed with the values of its alternatives.
+//
+// With -compare, several policies play the same games: each replays the
+// game's dice stream, and the report compares their scores game by game.
+//
+// With -bank-cap, games follow the banked-rerolls variant, in which
+// rerolls not used in a round are saved for later rounds.
+//
+// Games run in parallel. Game i (0-based) rolls from the PCG stream
+// (seed, i), so results are identical for any number of workers.
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math"
 	"math/rand/v2"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/evloader"
 	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/policy"
+	"github.com/iadams749/JBFieldsSolver/internal/record"
 	"github.com/iadams749/JBFieldsSolver/internal/solver"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
 )
 
 func main() {
 	numGames := flag.Int("n", 100000, "number of games to simulate")
 	evPath := flag.String("ev", "ev_table.json", "path to EV table JSON")
 	seed := flag.Uint64("seed", 0, "random seed (0 = use current time)")
+	recordPath := flag.String("record", "", "write every game to this file in record notation")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "number of games to play in parallel")
+	format := flag.String("format", "text", "summary format: text or json")
+	gamesOut := flag.String("games-out", "", "stream per-game results to this .ndjson or .csv file")
+	scorecard := flag.String("scorecard", "", "start from this scorecard, e.g. \"j=6 s=4 3k=23 mix=-\"")
+	categories := flag.String("categories", "", "start with only these categories left, e.g. \"all-j-s\" or \"3k,4k,fr\"")
+	startScore := flag.Int("score", 0, "starting score, with -categories")
+	startDice := flag.String("dice", "", "start mid-round with these dice showing, e.g. JJSPM")
+	rollsLeft := flag.Int("rolls-left", 2, "rerolls left with -dice (0-2)")
+	thresholds := flag.String("threshold", "", "comma-separated scores; report how often games finish with at least each")
+	compare := flag.String("compare", "", "compare comma-separated policies on the same dice, e.g. \"optimal,greedy,softmax:2\"")
+	replaySeed := flag.Uint64("replay", 0, "replay one game of the run with this seed, printing every decision")
+	replayGameNum := flag.Int("game", 1, "game number to replay with -replay")
+	svgPath := flag.String("svg", "", "write charts of the results to this SVG file")
+	validate := flag.Bool("validate", false, "compute the exact score distribution and test the simulated scores against it")
+	faces := flag.String("faces", "", "face weights of the dice, e.g. \"3,3,2,1,1\", or a .json file (default standard dice)")
+	bankCap := flag.Int("bank-cap", -1, "play the banked-rerolls variant, saving up to this many unused rerolls (-1 = standard game)")
+	targetStdErr := flag.Float64("target-stderr", 0, "keep simulating until the std error of the mean is below this (0 = play exactly -n games; otherwise -n is the maximum)")
 	flag.Parse()
 
-	table, err := ev.LoadJSON(*evPath)
+	if *numGames < 1 || *workers < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -n and -workers must be at least 1")
+		os.Exit(1)
+	}
+	if *targetStdErr < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -target-stderr must not be negative")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, `Error: -format must be "text" or "json"`)
+		os.Exit(1)
+	}
+
+	from, err := parseStart(*scorecard, *categories, *startScore, *startDice, *rollsLeft)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading EV table: %v\n", err)
-		fmt.Fprintln(os.Stderr, "Run the CLI or API first to generate ev_table.json")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	thresholdScores, err := parseThresholds(*thresholds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	var rng *rand.Rand
-	if *seed != 0 {
-		rng = rand.New(rand.NewPCG(*seed, 0))
-	} else {
-		rng = rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+	model, err := evloader.ParseModel(*faces)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -faces: %v\n", err)
+		os.Exit(1)
+	}
+	var table *ev.Table
+	if model.IsDefault() {
+		table, err = ev.LoadJSON(*evPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading EV table: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Run the CLI or API first to generate ev_table.json")
+			os.Exit(1)
+		}
+	} else if table, err = ev.LoadJSONWith(evloader.ModelPath(*evPath, model), model); err != nil {
+		// A table for these dice is saved only by the CLI and API; the
+		// simulator computes one in memory, keeping stdout for results.
+		table = ev.ComputeWith(model, nil)
+	}
+
+	if *bankCap >= 0 {
+		if from != game.NewGame() || *replaySeed != 0 || *compare != "" || *recordPath != "" || *gamesOut != "" ||
+			*targetStdErr > 0 || *validate || *svgPath != "" || *thresholds != "" {
+			fmt.Fprintln(os.Stderr, "Error: -bank-cap cannot be combined with a starting position, -replay, -compare, -record, -games-out, -target-stderr, -validate, -svg or -threshold")
+			os.Exit(1)
+		}
+		progress := os.Stdout
+		if *format == "json" {
+			progress = os.Stderr
+		}
+		t := time.Now()
+		banked, err := ev.ComputeBanked(model, *bankCap, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -bank-cap: %v\n", err)
+			os.Exit(1)
+		}
+		if *seed == 0 {
+			*seed = uint64(time.Now().UnixNano())
+		}
+		fmt.Fprintf(progress, "Banked EV table (cap %d): %v\n", *bankCap, time.Since(t).Round(time.Millisecond))
+		fmt.Fprintf(progress, "Theoretical EV (all categories): %.4f\n", banked.EV(game.AllCategories, 0))
+		fmt.Fprintf(progress, "Simulating %d games on %d workers...\n\n", *numGames, *workers)
+		res, err := runBanked(banked, table.EV(game.AllCategories), *numGames, *workers, *seed, progress)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
+		}
+		if *format == "json" {
+			writeJSON(res)
+			return
+		}
+		printBanked(os.Stdout, res)
+		return
+	}
+
+	if *replaySeed != 0 {
+		if *compare != "" || *gamesOut != "" || *targetStdErr > 0 || *validate || *svgPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: -replay cannot be combined with -compare, -games-out, -target-stderr, -validate or -svg")
+			os.Exit(1)
+		}
+		if *replayGameNum < 1 {
+			fmt.Fprintln(os.Stderr, "Error: -game must be at least 1")
+			os.Exit(1)
+		}
+		i := *replayGameNum - 1
+		g, steps, err := replayGame(table, *replaySeed, i, from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error replaying game %d: %v\n", *replayGameNum, err)
+			os.Exit(1)
+		}
+		if *recordPath != "" {
+			f, err := os.Create(*recordPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating record file: %v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w := record.NewWriter(f)
+			if err := w.Write(g); err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing record: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		printReplay(os.Stdout, g, steps, *replaySeed, i, from)
+		return
 	}
 
-	theoreticalEV := table.EV(game.AllCategories)
-	fmt.Printf("Theoretical EV (all categories): %.4f\n", theoreticalEV)
-	fmt.Printf("Simulating %d games...\n\n", *numGames)
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+
+	if *compare != "" {
+		if *recordPath != "" || *gamesOut != "" || *targetStdErr > 0 || *validate {
+			fmt.Fprintln(os.Stderr, "Error: -compare cannot be combined with -record, -games-out, -target-stderr or -validate")
+			os.Exit(1)
+		}
+		policies, err := policy.ParseList(*compare, table)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -compare: %v\n", err)
+			os.Exit(1)
+		}
+		if len(policies) < 2 {
+			fmt.Fprintln(os.Stderr, "Error: -compare needs at least two policies")
+			os.Exit(1)
+		}
+		progress := os.Stdout
+		if *format == "json" {
+			progress = os.Stderr
+		}
+		fmt.Fprintf(progress, "Comparing %d policies over %d games on %d workers...\n\n", len(policies), *numGames, *workers)
+		cmp, err := runCompare(policies, model, *numGames, *workers, *seed, from, progress)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
+		}
+		if *svgPath != "" {
+			if err := writeSVG(*svgPath, comparisonTitle(from), comparisonCharts(cmp)...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing SVG: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *format == "json" {
+			writeJSON(cmp)
+			return
+		}
+		printComparison(os.Stdout, cmp, from)
+		return
+	}
+
+	var sinks []gameSink
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating record file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		sinks = append(sinks, newRecordSink(f, *seed, from))
+	}
+	if *gamesOut != "" {
+		f, err := os.Create(*gamesOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating games file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		sink, err := newGamesSink(f, *gamesOut, *seed, from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	var games chan indexedGame
+	gamesDone := make(chan error, 1)
+	if len(sinks) > 0 {
+		games = make(chan indexedGame, *workers)
+		go func() {
+			gamesDone <- writeOrdered(games, sinks)
+		}()
+	}
+
+	// Progress goes to stderr when stdout carries JSON.
+	progress := os.Stdout
+	if *format == "json" {
+		progress = os.Stderr
+	}
+
+	theoreticalEV := startEV(from, table)
+	if from == game.NewGame() {
+		fmt.Fprintf(progress, "Theoretical EV (all categories): %.4f\n", theoreticalEV)
+	} else {
+		fmt.Fprintf(progress, "Starting from %s\n", describeStart(from))
+		fmt.Fprintf(progress, "Theoretical expected final score: %.4f\n", theoreticalEV)
+	}
+	// The exact distribution is the baseline of -validate and the overlay
+	// of the -svg histogram.
+	var exact []solver.ScoreProb
+	if *validate || *svgPath != "" {
+		t := time.Now()
+		exact = solver.FinalScoreDist(from, table)
+		fmt.Fprintf(progress, "Exact score distribution: %d scores  (%v)\n", len(exact), time.Since(t).Round(time.Millisecond))
+	}
+	if *targetStdErr > 0 {
+		fmt.Fprintf(progress, "Simulating until std error < %g (at most %d games) on %d workers...\n\n",
+			*targetStdErr, *numGames, *workers)
+	} else {
+		fmt.Fprintf(progress, "Simulating %d games on %d workers...\n\n", *numGames, *workers)
+	}
 
-	scores := make([]int, *numGames)
 	start := time.Now()
 
-	// Track best game
-	bestScore := 0
-	var bestBreakdown []categoryResult
+	optimal := policy.Optimal(table)
 
-	for i := range *numGames {
-		score, breakdown := simulateGame(rng, table)
-		scores[i] = score
-		if score > bestScore {
-			bestScore = score
-			bestBreakdown = breakdown
+	// Each game draws from its own PCG stream, so every game (and the
+	// merged stats) is the same whatever the number of workers.
+	perWorker := make([]*simStats, *workers)
+	for w := range perWorker {
+		perWorker[w] = newSimStats(from)
+	}
+	var done atomic.Int64
+	progressStep := int64(max(*numGames/10, 1))
+	runBatch := func(lo, hi int) error {
+		next := atomic.Int64{}
+		next.Store(int64(lo))
+		errs := make(chan error, *workers)
+		var wg sync.WaitGroup
+		for _, st := range perWorker {
+			wg.Go(func() {
+				for {
+					i := int(next.Add(1) - 1)
+					if i >= hi {
+						return
+					}
+					src := game.NewModelSource(rand.New(rand.NewPCG(*seed, uint64(i))), model)
+					g, err := policy.Play(src, optimal.NewPlayer(nil), from)
+					if err != nil {
+						errs <- fmt.Errorf("simulating game %d: %w", i+1, err)
+						next.Store(int64(hi)) // stop the other workers
+						return
+					}
+					st.add(i, g)
+					if games != nil {
+						games <- indexedGame{index: i, game: g}
+					}
+					if d := done.Add(1); *targetStdErr == 0 && d%progressStep == 0 {
+						fmt.Fprintf(progress, "  %d/%d games  (%v elapsed)\n", d, *numGames, time.Since(start).Round(time.Millisecond))
+					}
+				}
+			})
 		}
-		if (i+1)%(*numGames/10) == 0 {
-			elapsed := time.Since(start)
-			fmt.Printf("  %d/%d games  (%v elapsed)\n", i+1, *numGames, elapsed.Round(time.Millisecond))
+		wg.Wait()
+		close(errs)
+		return <-errs
+	}
+
+	// Merge the per-worker stats
+	merged := func() *simStats {
+		st := newSimStats(from)
+		for _, w := range perWorker {
+			st.merge(w)
 		}
+		return st
+	}
+
+	var stats *simStats
+	reached := false
+	if *targetStdErr > 0 {
+		// Adaptive: play whole batches so the stopping point does not
+		// depend on how games are spread across workers.
+		for lo := 0; lo < *numGames; lo += adaptiveBatch {
+			hi := min(lo+adaptiveBatch, *numGames)
+			if err := runBatch(lo, hi); err != nil {
+				fmt.Fprintf(os.Stderr, "Error %v\n", err)
+				os.Exit(1)
+			}
+			stats = merged()
+			stdErr := stats.scores.StdErr()
+			if reached = stdErr < *targetStdErr; reached || (hi/adaptiveBatch)%10 == 0 {
+				fmt.Fprintf(progress, "  %d games  std error %.4f  (%v elapsed)\n",
+					hi, stdErr, time.Since(start).Round(time.Millisecond))
+			}
+			if reached {
+				break
+			}
+		}
+	} else {
+		if err := runBatch(0, *numGames); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
+		}
+		stats = merged()
 	}
 
 	elapsed := time.Since(start)
 
-	// Compute statistics
-	sum := 0
-	for _, s := range scores {
-		sum += s
-	}
-	mean := float64(sum) / float64(*numGames)
-
-	varSum := 0.0
-	for _, s := range scores {
-		diff := float64(s) - mean
-		varSum += diff * diff
-	}
-	stddev := math.Sqrt(varSum / float64(*numGames))
-	stderr := stddev / math.Sqrt(float64(*numGames))
-
-	minScore, maxScore := scores[0], scores[0]
-	for _, s := range scores[1:] {
-		if s < minScore {
-			minScore = s
-		}
-		if s > maxScore {
-			maxScore = s
-		}
-	}
-
-	// Histogram buckets
-	bucketSize := 10
-	buckets := make(map[int]int)
-	for _, s := range scores {
-		bucket := (s / bucketSize) * bucketSize
-		buckets[bucket]++
-	}
-
-	fmt.Println()
-	fmt.Println("=== Results ===")
-	fmt.Printf("Games simulated:  %d\n", *numGames)
-	fmt.Printf("Time elapsed:     %v\n", elapsed.Round(time.Millisecond))
-	fmt.Println()
-	fmt.Printf("Theoretical EV:   %.4f\n", theoreticalEV)
-	fmt.Printf("Simulated mean:   %.4f\n", mean)
-	fmt.Printf("Difference:       %+.4f\n", mean-theoreticalEV)
-	fmt.Printf("Std error:        %.4f\n", stderr)
-	fmt.Println()
-	fmt.Printf("Standard dev:     %.4f\n", stddev)
-	fmt.Printf("Min score:        %d\n", minScore)
-	fmt.Printf("Max score:        %d\n", maxScore)
-	fmt.Println()
-
-	// Print best game breakdown
-	fmt.Println("Best game breakdown:")
-	for i, cr := range bestBreakdown {
-		fmt.Printf("  Round %d:  %-18s  scored %3d  with %s\n",
-			i+1, cr.category, cr.score, formatDice(cr.dice))
-	}
-	fmt.Printf("  %-26s  = %d\n", "TOTAL", bestScore)
-	fmt.Println()
-
-	// Print histogram
-	fmt.Println("Score distribution:")
-	minBucket := (minScore / bucketSize) * bucketSize
-	maxBucket := (maxScore / bucketSize) * bucketSize
-	maxCount := 0
-	for _, c := range buckets {
-		if c > maxCount {
-			maxCount = c
-		}
-	}
-	barWidth := 50
-	for b := minBucket; b <= maxBucket; b += bucketSize {
-		count := buckets[b]
-		barLen := count * barWidth / maxCount
-		bar := ""
-		for range barLen {
-			bar += "█"
-		}
-		pct := float64(count) / float64(*numGames) * 100
-		fmt.Printf("  %3d-%3d: %s %d (%.1f%%)\n", b, b+bucketSize-1, bar, count, pct)
+	if games != nil {
+		close(games)
+		if err := <-gamesDone; err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing games: %v\n", err)
+			os.Exit(1)
+		}
 	}
+
+	sum := newSummary(stats, theoreticalEV, *seed, *workers, elapsed, thresholdScores)
+	sum.Dice = diceLabel(model)
+	if *targetStdErr > 0 {
+		sum.TargetStdError = *targetStdErr
+		sum.TargetReached = reached
+	}
+	if *validate {
+		sum.Fit = newFit(stats.scores, exact)
+	}
+	if *svgPath != "" {
+		if err := writeSVG(*svgPath, summaryTitle(from), summaryCharts(sum, stats, exact)...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing SVG: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *format == "json" {
+		writeJSON(sum)
+		return
+	}
+	printSummary(os.Stdout, sum, from, stats.best)
 }
 
-// categoryResult records what dice were scored in a category for one game.
-type categoryResult struct {
-	category game.Category
-	dice     game.Dice
-	score    int
+// adaptiveBatch is the number of games played between stopping checks
+// with -target-stderr.
+const adaptiveBatch = 1000
+
+// simStats accumulates the results of the games one worker played.
+// Samples are integer frequency tables, so merging is exact in any order.
+type simStats struct {
+	start     game.GameState                              // position every game starts from
+	scores    *stats.IntSample                            // final scores, including start.Score
+	cats      [game.NumCategories]*stats.IntSample        // points scored per category
+	catRound  [game.NumCategories][game.NumCategories]int // games using category c in round r+1
+	best      *record.Game
+	bestIndex int // index of best; ties go to the lowest index
 }
 
-// simulateGame plays one full game using optimal strategy,
-// returning the total score and the per-category breakdown.
-func simulateGame(rng *rand.Rand, table *ev.Table) (int, []categoryResult) {
-	cs := game.AllCategories
-	totalScore := 0
-	var breakdown []categoryResult
-
-	for round := 0; round < int(game.NumCategories); round++ {
-		dice := rollAllDice(rng)
-
-		// Up to 2 rerolls
-		for rollsLeft := 2; rollsLeft > 0; rollsLeft-- {
-			rec := solver.Solve(dice, rollsLeft, cs, table)
-			if rec.BestAction.Type == solver.ScoreAction {
-				break // solver says score now
-			}
-			// Reroll: keep the recommended dice, reroll the rest
-			keep := rec.BestAction.Keep
-			numReroll := game.NumDice - keep.Total()
-			rerolled := rollNDice(rng, numReroll)
-			dice = game.AddDice(keep, rerolled)
-		}
-
-		// Must score now (rollsLeft == 0)
-		rec := solver.Solve(dice, 0, cs, table)
-		cat := rec.BestAction.Category
-		score := game.Score(dice, cat)
-		totalScore += score
-		breakdown = append(breakdown, categoryResult{
-			category: cat,
-			dice:     dice,
-			score:    score,
-		})
-		cs = cs.Remove(cat)
-	}
-
-	return totalScore, breakdown
+func newSimStats(start game.GameState) *simStats {
+	s := &simStats{start: start, scores: stats.NewIntSample()}
+	for c := range s.cats {
+		s.cats[c] = stats.NewIntSample()
+	}
+	return s
 }
 
-// rollAllDice rolls 5 dice and returns the result.
-func rollAllDice(rng *rand.Rand) game.Dice {
-	return rollNDice(rng, game.NumDice)
+// add records game i.
+func (s *simStats) add(i int, g *record.Game) {
+	s.scores.Add(int(s.start.Score) + g.Total())
+	first := s.start.Round() - 1
+	for r, round := range g.Rounds {
+		s.cats[round.Category].Add(round.Points)
+		s.catRound[round.Category][first+r]++
+	}
+	s.offerBest(i, g)
 }
 
-// rollNDice rolls n dice and returns the result as a Dice count array.
-func rollNDice(rng *rand.Rand, n int) game.Dice {
-	var d game.Dice
-	for range n {
-		face := rollOneDie(rng)
-		d[face]++
+// merge adds the results in o to s.
+func (s *simStats) merge(o *simStats) {
+	s.scores.Merge(o.scores)
+	for c := range s.cats {
+		s.cats[c].Merge(o.cats[c])
+		for r := range s.catRound[c] {
+			s.catRound[c][r] += o.catRound[c][r]
+		}
+	}
+	if o.best != nil {
+		s.offerBest(o.bestIndex, o.best)
 	}
-	return d
 }
 
-// rollOneDie returns a random berry face based on FaceProb distribution.
-func rollOneDie(rng *rand.Rand) game.Berry {
-	r := rng.Float64()
-	cumulative := 0.0
-	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
-		cumulative += game.FaceProb[b]
-		if r < cumulative {
-			return b
+// offerBest makes game i the best game if it scores more than the current
+// best, or the same with a lower index.
+func (s *simStats) offerBest(i int, g *record.Game) {
+	if s.best == nil || g.Total() > s.best.Total() || g.Total() == s.best.Total() && i < s.bestIndex {
+		s.best, s.bestIndex = g, i
+	}
+}
+
+// writeJSON writes v to stdout as indented JSON.
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseStart returns the position games start from. The categories left
+// and score come from scorecard or from categories and score; dice, if
+// set, puts the game mid-round with rollsLeft rerolls to go.
+func parseStart(scorecard, categories string, score int, dice string, rollsLeft int) (game.GameState, error) {
+	start := game.NewGame()
+	switch {
+	case scorecard != "" && (categories != "" || score != 0):
+		return game.GameState{}, fmt.Errorf("-scorecard cannot be combined with -categories or -score")
+	case scorecard != "":
+		sc, err := solver.ParseScorecard(scorecard)
+		if err != nil {
+			return game.GameState{}, fmt.Errorf("-scorecard: %w", err)
+		}
+		start = sc.GameState()
+	case categories != "":
+		cs, err := solver.ParseCategories(categories)
+		if err != nil {
+			return game.GameState{}, fmt.Errorf("-categories: %w", err)
+		}
+		start.CategoriesLeft = cs
+	}
+	if score < 0 || score > math.MaxUint16 {
+		return game.GameState{}, fmt.Errorf("-score %d out of range", score)
+	}
+	start.Score += uint16(score)
+	if start.GameOver() {
+		return game.GameState{}, fmt.Errorf("no categories left to play")
+	}
+
+	if dice != "" {
+		d, err := solver.ParseDice(dice)
+		if err != nil {
+			return game.GameState{}, fmt.Errorf("-dice: %w", err)
+		}
+		if rollsLeft < 0 || rollsLeft >= game.RollsPerRound {
+			return game.GameState{}, fmt.Errorf("-rolls-left must be 0-%d", game.RollsPerRound-1)
 		}
+		start.CurrentDice, start.RollsLeft = d, uint8(rollsLeft)
 	}
-	return game.Pest // rounding safety
+
+	if _, err := game.NewEngineAt(nil, start); err != nil {
+		return game.GameState{}, err
+	}
+	return start, nil
 }
 
-// formatDice returns a human-readable string for a dice outcome, e.g. "3M 2P".
-func formatDice(d game.Dice) string {
-	letters := [game.NumBerryTypes]string{"J", "S", "P", "M", "X"}
-	result := ""
-	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
-		if d[b] > 0 {
-			if result != "" {
-				result += " "
-			}
-			result += fmt.Sprintf("%d%s", d[b], letters[b])
+// parseThresholds parses a comma-separated list of scores.
+func parseThresholds(input string) ([]int, error) {
+	var scores []int
+	for _, field := range strings.Split(input, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
 		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("-threshold: invalid score %q", field)
+		}
+		scores = append(scores, n)
+	}
+	return scores, nil
+}
+
+// startEV returns the expected final score under optimal play from start.
+func startEV(start game.GameState, table *ev.Table) float64 {
+	if start.RollsLeft == game.RollsPerRound {
+		return float64(start.Score) + table.EV(start.CategoriesLeft)
 	}
-	if result == "" {
-		return "(empty)"
+	rs := solver.NewRoundSolver(start.CategoriesLeft, table)
+	rec := rs.Solve(start.CurrentDice, int(start.RollsLeft))
+	return float64(start.Score) + rec.BestAction.EV
+}
+
+// describeStart returns a one-line description of a starting position,
+// e.g. "round 7, score 98, left 3k fr mix, dice JJSPM with 2 rerolls left".
+func describeStart(start game.GameState) string {
+	var codes []string
+	start.CategoriesLeft.ForEach(func(c game.Category) {
+		codes = append(codes, c.Code())
+	})
+	desc := fmt.Sprintf("round %d, score %d, left %s", start.Round(), start.Score, strings.Join(codes, " "))
+	if start.RollsLeft < game.RollsPerRound {
+		desc += fmt.Sprintf(", dice %s with %d rerolls left", game.RollFromDice(start.CurrentDice), start.RollsLeft)
 	}
-	return result
+	return desc
 }
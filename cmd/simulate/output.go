@@ -0,0 +1,528 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/record"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
+)
+
+// histBucket is the width of the score histogram buckets.
+const histBucket = 10
+
+// summary is the result of a run, printed as text or JSON.
+type summary struct {
+	Games          int            `json:"games"`
+	Seed           uint64         `json:"seed"`
+	Workers        int            `json:"workers"`
+	Start          *startJSON     `json:"start,omitempty"` // nil for a new game
+	Dice           string         `json:"dice,omitempty"`  // face probabilities; empty for standard dice
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+	TheoreticalEV  float64        `json:"theoretical_ev"` // expected final score from the start
+	Mean           float64        `json:"mean"`
+	Difference     float64        `json:"difference"` // mean - theoretical EV
+	StdError       float64        `json:"std_error"`
+	StdDev         float64        `json:"std_dev"`
+	Min            int            `json:"min"`
+	Max            int            `json:"max"`
+	Percentiles    []percentile   `json:"percentiles"`
+	Thresholds     []threshold    `json:"thresholds,omitempty"`
+	Categories     []categoryStat `json:"categories"`
+	Histogram      []histBin      `json:"histogram"`
+	BestGame       gameJSON       `json:"best_game"`
+	TargetStdError float64        `json:"target_std_error,omitempty"` // set with -target-stderr
+	TargetReached  bool           `json:"target_reached,omitempty"`
+	Fit            *fit           `json:"fit,omitempty"` // set with -validate
+}
+
+// fitAlpha is the significance level at which -validate reports a misfit.
+const fitAlpha = 0.01
+
+// fit compares the simulated scores with the exact distribution under
+// optimal play.
+type fit struct {
+	ExactMean   float64 `json:"exact_mean"`
+	ExactStdDev float64 `json:"exact_std_dev"`
+	ChiSquare   float64 `json:"chi_square"`
+	DF          int     `json:"df"`
+	ChiSquareP  float64 `json:"chi_square_p"`
+	KSD         float64 `json:"ks_d"`
+	KSP         float64 `json:"ks_p"`
+	Rejected    bool    `json:"rejected"` // either p-value below fitAlpha
+}
+
+// newFit tests scores against the exact distribution.
+func newFit(scores *stats.IntSample, exact []solver.ScoreProb) *fit {
+	probs := make(map[int]float64, len(exact))
+	var mean, sq float64
+	for _, sp := range exact {
+		probs[sp.Score] = sp.Prob
+		mean += float64(sp.Score) * sp.Prob
+		sq += float64(sp.Score) * float64(sp.Score) * sp.Prob
+	}
+	chi := scores.ChiSquare(probs)
+	ks := scores.KS(probs)
+	return &fit{
+		ExactMean:   mean,
+		ExactStdDev: math.Sqrt(max(sq-mean*mean, 0)),
+		ChiSquare:   chi.Statistic,
+		DF:          chi.DF,
+		ChiSquareP:  chi.P,
+		KSD:         ks.Statistic,
+		KSP:         ks.P,
+		Rejected:    chi.P < fitAlpha || ks.P < fitAlpha,
+	}
+}
+
+// percentileLevels are the score percentiles reported, as fractions.
+var percentileLevels = []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99}
+
+// ciLevel is the confidence level of the percentile intervals.
+const ciLevel = 0.95
+
+// percentile is one score percentile with its confidence interval.
+type percentile struct {
+	P      float64 `json:"p"`
+	Value  int     `json:"value"`
+	CILow  int     `json:"ci_low"`
+	CIHigh int     `json:"ci_high"`
+}
+
+// threshold is the fraction of games finishing with at least Score.
+type threshold struct {
+	Score    int     `json:"score"`
+	Fraction float64 `json:"fraction"`
+	StdError float64 `json:"std_error"`
+}
+
+// startJSON is a mid-game starting position.
+type startJSON struct {
+	Round      int      `json:"round"`
+	Score      int      `json:"score"`
+	Categories []string `json:"categories_left"`
+	Dice       string   `json:"dice,omitempty"` // set if mid-round
+	RollsLeft  int      `json:"rolls_left"`     // rerolls left, if mid-round
+}
+
+// newStartJSON returns the JSON form of start, or nil for a new game.
+func newStartJSON(start game.GameState) *startJSON {
+	if start == game.NewGame() {
+		return nil
+	}
+	sj := &startJSON{
+		Round: start.Round(),
+		Score: int(start.Score),
+	}
+	start.CategoriesLeft.ForEach(func(c game.Category) {
+		sj.Categories = append(sj.Categories, c.String())
+	})
+	if start.RollsLeft < game.RollsPerRound {
+		sj.Dice = game.RollFromDice(start.CurrentDice).String()
+		sj.RollsLeft = int(start.RollsLeft)
+	}
+	return sj
+}
+
+// categoryStat summarizes the points scored in one category.
+type categoryStat struct {
+	Category    string  `json:"category"`
+	Mean        float64 `json:"mean"`
+	StdDev      float64 `json:"std_dev"`
+	ScratchRate float64 `json:"scratch_rate"` // fraction of games scoring 0
+	MeanRound   float64 `json:"mean_round"`   // 1-based
+	// RoundDistribution[r] is the fraction of games using the category
+	// in round r+1.
+	RoundDistribution []float64 `json:"round_distribution"`
+}
+
+// histBin is one bucket of the score histogram: scores From..To inclusive.
+type histBin struct {
+	From     int     `json:"from"`
+	To       int     `json:"to"`
+	Count    int     `json:"count"`
+	Fraction float64 `json:"fraction"`
+}
+
+// gameJSON is one game as written to NDJSON output.
+type gameJSON struct {
+	Game   int         `json:"game"`   // 1-based
+	Seed   uint64      `json:"seed"`   // run seed
+	Stream uint64      `json:"stream"` // PCG stream of this game: Game - 1
+	Total  int         `json:"total"`  // final score, including any starting score
+	Rounds []roundJSON `json:"rounds"`
+}
+
+// roundJSON is one round of a gameJSON.
+type roundJSON struct {
+	Category string `json:"category"`
+	Dice     string `json:"dice"` // final dice, e.g. "JJSPM"
+	Score    int    `json:"score"`
+	Rerolls  int    `json:"rerolls"`
+}
+
+// newSummary computes the summary of a finished run.
+func newSummary(st *simStats, theoreticalEV float64, seed uint64, workers int, elapsed time.Duration, thresholdScores []int) summary {
+	scores := st.scores
+	n := scores.N()
+
+	var hist []histBin
+	for b := (scores.Min() / histBucket) * histBucket; b <= scores.Max(); b += histBucket {
+		count := 0
+		for x := b; x < b+histBucket; x++ {
+			count += scores.Count(x)
+		}
+		hist = append(hist, histBin{
+			From:     b,
+			To:       b + histBucket - 1,
+			Count:    count,
+			Fraction: float64(count) / float64(n),
+		})
+	}
+
+	var pcts []percentile
+	for _, p := range percentileLevels {
+		lo, hi := scores.QuantileCI(p, ciLevel)
+		pcts = append(pcts, percentile{P: p, Value: scores.Quantile(p), CILow: lo, CIHigh: hi})
+	}
+
+	var thresholds []threshold
+	for _, t := range thresholdScores {
+		frac := float64(scores.CountAtLeast(t)) / float64(n)
+		thresholds = append(thresholds, threshold{
+			Score:    t,
+			Fraction: frac,
+			StdError: math.Sqrt(frac * (1 - frac) / float64(n)),
+		})
+	}
+
+	var cats []categoryStat
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		cs := st.cats[c]
+		if cs.N() == 0 {
+			continue // used before the start
+		}
+		stat := categoryStat{
+			Category:          c.String(),
+			Mean:              cs.Mean(),
+			StdDev:            cs.StdDev(),
+			ScratchRate:       float64(cs.Count(0)) / float64(cs.N()),
+			RoundDistribution: make([]float64, game.NumCategories),
+		}
+		for r, count := range st.catRound[c] {
+			frac := float64(count) / float64(cs.N())
+			stat.RoundDistribution[r] = frac
+			stat.MeanRound += float64(r+1) * frac
+		}
+		cats = append(cats, stat)
+	}
+
+	mean := scores.Mean()
+	return summary{
+		Games:          n,
+		Seed:           seed,
+		Workers:        workers,
+		Start:          newStartJSON(st.start),
+		ElapsedSeconds: elapsed.Seconds(),
+		TheoreticalEV:  theoreticalEV,
+		Mean:           mean,
+		Difference:     mean - theoreticalEV,
+		StdError:       scores.StdErr(),
+		StdDev:         scores.StdDev(),
+		Min:            scores.Min(),
+		Max:            scores.Max(),
+		Percentiles:    pcts,
+		Thresholds:     thresholds,
+		Categories:     cats,
+		Histogram:      hist,
+		BestGame:       toGameJSON(st.bestIndex, st.best, seed, st.start),
+	}
+}
+
+// printSummary writes the human-readable report.
+func printSummary(w io.Writer, sum summary, start game.GameState, best *record.Game) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "=== Results ===")
+	if sum.Start != nil {
+		fmt.Fprintf(w, "Starting from:    %s\n", describeStart(start))
+	}
+	if sum.Dice != "" {
+		fmt.Fprintf(w, "Dice:             %s\n", sum.Dice)
+	}
+	fmt.Fprintf(w, "Games simulated:  %d\n", sum.Games)
+	fmt.Fprintf(w, "Seed:             %d\n", sum.Seed)
+	fmt.Fprintf(w, "Time elapsed:     %v\n", time.Duration(sum.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Theoretical EV:   %.4f\n", sum.TheoreticalEV)
+	fmt.Fprintf(w, "Simulated mean:   %.4f\n", sum.Mean)
+	fmt.Fprintf(w, "Difference:       %+.4f\n", sum.Difference)
+	fmt.Fprintf(w, "Std error:        %.4f\n", sum.StdError)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Standard dev:     %.4f\n", sum.StdDev)
+	fmt.Fprintf(w, "Min score:        %d\n", sum.Min)
+	fmt.Fprintf(w, "Max score:        %d\n", sum.Max)
+	if sum.TargetStdError > 0 && !sum.TargetReached {
+		fmt.Fprintf(w, "Target std error %.4f not reached within %d games\n", sum.TargetStdError, sum.Games)
+	}
+	fmt.Fprintln(w)
+
+	if len(sum.Thresholds) > 0 {
+		fmt.Fprintln(w, "Finishing with at least:")
+		for _, t := range sum.Thresholds {
+			fmt.Fprintf(w, "  %4d  %6.2f%%  ± %.2f%%\n", t.Score, t.Fraction*100, t.StdError*100)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Percentiles (%.0f%% CI):\n", ciLevel*100)
+	for _, p := range sum.Percentiles {
+		fmt.Fprintf(w, "  p%-3s %4d  [%d, %d]\n", strconv.FormatFloat(p.P*100, 'f', -1, 64), p.Value, p.CILow, p.CIHigh)
+	}
+	fmt.Fprintln(w)
+
+	if f := sum.Fit; f != nil {
+		fmt.Fprintln(w, "Goodness of fit (exact distribution under optimal play):")
+		fmt.Fprintf(w, "  Exact mean:   %.4f  (sd %.4f)\n", f.ExactMean, f.ExactStdDev)
+		fmt.Fprintf(w, "  Chi-square:   %.2f on %d df, p = %.4f\n", f.ChiSquare, f.DF, f.ChiSquareP)
+		fmt.Fprintf(w, "  KS distance:  %.5f, p = %.4f\n", f.KSD, f.KSP)
+		if f.Rejected {
+			fmt.Fprintf(w, "  MISMATCH: the simulated scores do not follow the exact distribution (p < %g)\n", fitAlpha)
+		} else {
+			fmt.Fprintf(w, "  Consistent with the exact distribution (p >= %g)\n", fitAlpha)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "Categories:")
+	fmt.Fprintf(w, "  %-18s  %6s  %6s  %7s  %6s  rounds 1-%d\n", "", "mean", "sd", "scratch", "round", game.NumCategories)
+	for _, c := range sum.Categories {
+		var dist []string
+		for _, f := range c.RoundDistribution {
+			dist = append(dist, fmt.Sprintf("%3.0f", f*100))
+		}
+		fmt.Fprintf(w, "  %-18s  %6.2f  %6.2f  %6.1f%%  %6.2f  %s\n",
+			c.Category, c.Mean, c.StdDev, c.ScratchRate*100, c.MeanRound, strings.Join(dist, " "))
+	}
+	fmt.Fprintln(w)
+
+	// Print best game breakdown
+	fmt.Fprintf(w, "Best game breakdown (game %d; replay with -replay %d -game %d):\n",
+		sum.BestGame.Game, sum.Seed, sum.BestGame.Game)
+	if start.Score > 0 {
+		fmt.Fprintf(w, "  %-26s    %d\n", "Starting score", start.Score)
+	}
+	for i, r := range best.Rounds {
+		fmt.Fprintf(w, "  Round %d:  %-18s  scored %3d  with %s\n",
+			start.Round()+i, r.Category, r.Points, formatDice(r.Final().Dice()))
+	}
+	fmt.Fprintf(w, "  %-26s  = %d\n", "TOTAL", sum.Max)
+	fmt.Fprintln(w)
+
+	// Print histogram
+	fmt.Fprintln(w, "Score distribution:")
+	maxCount := 0
+	for _, bin := range sum.Histogram {
+		maxCount = max(maxCount, bin.Count)
+	}
+	barWidth := 50
+	for _, bin := range sum.Histogram {
+		bar := strings.Repeat("█", bin.Count*barWidth/maxCount)
+		fmt.Fprintf(w, "  %3d-%3d: %s %d (%.1f%%)\n", bin.From, bin.To, bar, bin.Count, bin.Fraction*100)
+	}
+}
+
+// diceLabel describes model for the summaries, or returns "" for the
+// standard dice.
+func diceLabel(model *game.DiceModel) string {
+	if model.IsDefault() {
+		return ""
+	}
+	return model.String()
+}
+
+// toGameJSON converts game index i of a run from start to its JSON form.
+func toGameJSON(i int, g *record.Game, seed uint64, start game.GameState) gameJSON {
+	gj := gameJSON{
+		Game:   i + 1,
+		Seed:   seed,
+		Stream: uint64(i),
+		Total:  int(start.Score) + g.Total(),
+		Rounds: []roundJSON{},
+	}
+	for _, r := range g.Rounds {
+		gj.Rounds = append(gj.Rounds, roundJSON{
+			Category: r.Category.String(),
+			Dice:     r.Final().String(),
+			Score:    r.Points,
+			Rerolls:  len(r.Keeps),
+		})
+	}
+	return gj
+}
+
+// indexedGame is a finished game and its 0-based index in the run.
+type indexedGame struct {
+	index int
+	game  *record.Game
+}
+
+// gameSink receives every game of a run in index order.
+type gameSink interface {
+	write(i int, g *record.Game) error
+	flush() error
+}
+
+// writeOrdered passes games to every sink in index order as they arrive
+// from workers in any order. After an error it keeps draining games so
+// workers never block.
+func writeOrdered(games <-chan indexedGame, sinks []gameSink) error {
+	pending := make(map[int]*record.Game)
+	next := 0
+	var err error
+	for ig := range games {
+		pending[ig.index] = ig.game
+		for g, ok := pending[next]; ok; g, ok = pending[next] {
+			delete(pending, next)
+			for _, s := range sinks {
+				if err == nil {
+					err = s.write(next, g)
+				}
+			}
+			next++
+		}
+	}
+	for _, s := range sinks {
+		if ferr := s.flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// recordSink writes games in record notation.
+type recordSink struct {
+	w     *record.Writer
+	seed  uint64
+	date  string
+	start game.GameState
+}
+
+func newRecordSink(w io.Writer, seed uint64, start game.GameState) *recordSink {
+	return &recordSink{w: record.NewWriter(w), seed: seed, date: time.Now().Format(time.DateOnly), start: start}
+}
+
+func (s *recordSink) write(i int, g *record.Game) error {
+	tagged := *g
+	tagged.Tags = append([]record.Tag{
+		{Name: record.TagEvent, Value: fmt.Sprintf("Simulation game %d", i+1)},
+		{Name: record.TagDate, Value: s.date},
+		{Name: record.TagPlayer, Value: "optimal"},
+		{Name: record.TagSeed, Value: strconv.FormatUint(s.seed, 10)},
+	}, g.Tags...)
+	if s.start != game.NewGame() {
+		tagged.SetTag("Start", describeStart(s.start))
+	}
+	return s.w.Write(&tagged)
+}
+
+func (s *recordSink) flush() error {
+	return s.w.Flush()
+}
+
+// newGamesSink returns a per-game sink for path, choosing NDJSON or CSV
+// by its extension.
+func newGamesSink(w io.Writer, path string, seed uint64, start game.GameState) (gameSink, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		bw := bufio.NewWriter(w)
+		return &ndjsonSink{bw: bw, enc: json.NewEncoder(bw), seed: seed, start: start}, nil
+	case ".csv":
+		return newCSVSink(w, seed, start)
+	}
+	return nil, fmt.Errorf("-games-out %s: extension must be .ndjson, .jsonl or .csv", path)
+}
+
+// ndjsonSink writes one gameJSON object per line.
+type ndjsonSink struct {
+	bw    *bufio.Writer
+	enc   *json.Encoder
+	seed  uint64
+	start game.GameState
+}
+
+func (s *ndjsonSink) write(i int, g *record.Game) error {
+	return s.enc.Encode(toGameJSON(i, g, s.seed, s.start))
+}
+
+func (s *ndjsonSink) flush() error {
+	return s.bw.Flush()
+}
+
+// csvSink writes one row per game: game, seed, stream, total, then the
+// category, final dice and score of each round played.
+type csvSink struct {
+	w     *csv.Writer
+	seed  uint64
+	start game.GameState
+}
+
+func newCSVSink(w io.Writer, seed uint64, start game.GameState) (*csvSink, error) {
+	header := []string{"game", "seed", "stream", "total"}
+	for r := start.Round(); r <= int(game.NumCategories); r++ {
+		header = append(header,
+			fmt.Sprintf("r%d_category", r),
+			fmt.Sprintf("r%d_dice", r),
+			fmt.Sprintf("r%d_score", r))
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvSink{w: cw, seed: seed, start: start}, nil
+}
+
+func (s *csvSink) write(i int, g *record.Game) error {
+	row := []string{
+		strconv.Itoa(i + 1),
+		strconv.FormatUint(s.seed, 10),
+		strconv.Itoa(i),
+		strconv.Itoa(int(s.start.Score) + g.Total()),
+	}
+	for _, r := range g.Rounds {
+		row = append(row, r.Category.String(), r.Final().String(), strconv.Itoa(r.Points))
+	}
+	return s.w.Write(row)
+}
+
+func (s *csvSink) flush() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// formatDice returns a human-readable string for a dice outcome, e.g. "3M 2P".
+func formatDice(d game.Dice) string {
+	letters := [game.NumBerryTypes]string{"J", "S", "P", "M", "X"}
+	result := ""
+	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+		if d[b] > 0 {
+			if result != "" {
+				result += " "
+			}
+			result += fmt.Sprintf("%d%s", d[b], letters[b])
+		}
+	}
+	if result == "" {
+		return "(empty)"
+	}
+	return result
+}
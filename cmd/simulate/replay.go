@@ -0,0 +1,167 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/policy"
+	"github.com/iadams749/JBFieldsSolver/internal/record"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// traceAlternatives is the number of alternatives shown per decision.
+const traceAlternatives = 3
+
+// traceOption is one move considered at a decision, valued as the
+// expected final score after taking it.
+type traceOption struct {
+	label string // "keep JJ" or a category code with its points, "mix 22"
+	value float64
+}
+
+// traceStep is one decision of a replayed game.
+type traceStep struct {
+	chosen       traceOption
+	alternatives []traceOption // best first
+	score        bool          // chosen move scores
+}
+
+// tracingPlayer plays optimally, as the simulator does, and records every
+// move it considered.
+type tracingPlayer struct {
+	table *ev.Table
+	cs    game.CategorySet
+	rs    *solver.RoundSolver
+	steps []traceStep
+}
+
+func (pl *tracingPlayer) Decide(st game.GameState) policy.Decision {
+	if pl.rs == nil || pl.cs != st.CategoriesLeft {
+		pl.cs, pl.rs = st.CategoriesLeft, solver.NewRoundSolver(st.CategoriesLeft, pl.table)
+	}
+	dice, rollsLeft := st.CurrentDice, int(st.RollsLeft)
+	base := float64(st.Score)
+
+	var options []traceOption
+	if rollsLeft > 0 {
+		for _, opt := range pl.rs.RerollOptions(dice, rollsLeft) {
+			options = append(options, traceOption{label: "keep " + keepLetters(opt.Keep), value: base + opt.EV})
+		}
+	}
+	st.CategoriesLeft.ForEach(func(c game.Category) {
+		v := pl.rs.ActionEV(dice, rollsLeft, solver.Action{Type: solver.ScoreAction, Category: c})
+		options = append(options, traceOption{label: categoryLabel(dice, c), value: base + v})
+	})
+	slices.SortStableFunc(options, func(a, b traceOption) int {
+		return cmp.Compare(b.value, a.value)
+	})
+
+	best := pl.rs.Solve(dice, rollsLeft).BestAction
+	d := policy.Decision{Category: best.Category}
+	label := categoryLabel(dice, best.Category)
+	if best.Type == solver.RerollAction {
+		d = policy.Decision{Reroll: true, Keep: best.Keep}
+		label = "keep " + keepLetters(best.Keep)
+	}
+
+	step := traceStep{score: !d.Reroll}
+	for _, o := range options {
+		switch {
+		case o.label == label:
+			step.chosen = o
+		case len(step.alternatives) < traceAlternatives:
+			step.alternatives = append(step.alternatives, o)
+		}
+	}
+	pl.steps = append(pl.steps, step)
+	return d
+}
+
+// replayGame replays game i (0-based) of a run with seed from start,
+// returning its record with each round's decisions in the comment, and
+// the trace of every decision in play order.
+func replayGame(table *ev.Table, seed uint64, i int, start game.GameState) (*record.Game, []traceStep, error) {
+	dice, _ := newGameSources(seed, i, table.Model())
+	pl := &tracingPlayer{table: table}
+	g, err := policy.Play(dice, pl, start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	steps := pl.steps
+	for r := range g.Rounds {
+		n := len(g.Rounds[r].Keeps) + 1
+		var notes []string
+		for _, s := range steps[:n] {
+			note := fmt.Sprintf("%s %.2f", s.chosen.label, s.chosen.value)
+			if len(s.alternatives) > 0 {
+				alt := s.alternatives[0]
+				note += fmt.Sprintf(" > %s %.2f", alt.label, alt.value)
+			}
+			notes = append(notes, note)
+		}
+		g.Rounds[r].Comment = strings.Join(notes, "; ")
+		steps = steps[n:]
+	}
+	g.Tags = append([]record.Tag{
+		{Name: record.TagEvent, Value: fmt.Sprintf("Simulation game %d", i+1)},
+		{Name: record.TagPlayer, Value: "optimal"},
+		{Name: record.TagSeed, Value: strconv.FormatUint(seed, 10)},
+	}, g.Tags...)
+	if start != game.NewGame() {
+		g.SetTag("Start", describeStart(start))
+	}
+	return g, pl.steps, nil
+}
+
+// printReplay writes the decision trace of a replayed game.
+func printReplay(w io.Writer, g *record.Game, steps []traceStep, seed uint64, i int, start game.GameState) {
+	fmt.Fprintf(w, "Replay of game %d (seed %d, stream %d)\n", i+1, seed, i)
+	if start != game.NewGame() {
+		fmt.Fprintf(w, "Starting from %s\n", describeStart(start))
+	}
+	fmt.Fprintln(w, "Values are expected final scores under optimal play.")
+
+	score := int(start.Score)
+	for r, round := range g.Rounds {
+		fmt.Fprintf(w, "\nRound %d  (score %d)\n", start.Round()+r, score)
+		for k, roll := range round.Rolls {
+			s := steps[0]
+			steps = steps[1:]
+			fmt.Fprintf(w, "  Roll %d: %s\n", k+1, roll)
+			verb := "Keep"
+			if s.score {
+				verb = "Score"
+			}
+			label := strings.TrimPrefix(s.chosen.label, "keep ")
+			fmt.Fprintf(w, "    %-5s %-10s %8.2f\n", verb, label, s.chosen.value)
+			for _, alt := range s.alternatives {
+				fmt.Fprintf(w, "      vs  %-10s %8.2f  (%+.2f)\n", alt.label, alt.value, alt.value-s.chosen.value)
+			}
+		}
+		score += round.Points
+		fmt.Fprintf(w, "  Scored %s: %d\n", round.Category, round.Points)
+	}
+	fmt.Fprintf(w, "\nFinal score: %d\n", score)
+}
+
+// keepLetters returns the letters of the held dice in face order, or "-"
+// if nothing is held.
+func keepLetters(keep game.Dice) string {
+	if keep.Total() == 0 {
+		return "-"
+	}
+	return game.RollFromDice(keep).String()[:keep.Total()]
+}
+
+// categoryLabel returns a category code with the points d scores in it,
+// e.g. "mix 22".
+func categoryLabel(d game.Dice, c game.Category) string {
+	return c.Code() + " " + strconv.Itoa(game.Score(d, c))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iadams749/JBFieldsSolver/internal/chart"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+	"github.com/iadams749/JBFieldsSolver/internal/stats"
+)
+
+// boxLabel explains the parts of every box plot.
+const boxLabel = "box: quartiles, whiskers: 5th to 95th percentile, dot: mean"
+
+// writeSVG writes charts to path as one SVG document.
+func writeSVG(path, title string, panels ...chart.Panel) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := chart.Write(f, title, panels...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// summaryTitle returns the document title of a simulation run from start.
+func summaryTitle(start game.GameState) string {
+	if start == game.NewGame() {
+		return "Simulated games under optimal play"
+	}
+	return "Simulated games from " + describeStart(start)
+}
+
+// comparisonTitle returns the document title of a policy comparison.
+func comparisonTitle(start game.GameState) string {
+	if start == game.NewGame() {
+		return "Policy comparison"
+	}
+	return "Policy comparison from " + describeStart(start)
+}
+
+// summaryCharts returns the score histogram, with the exact distribution
+// overlaid, and the per-category box plot of a run.
+func summaryCharts(sum summary, st *simStats, exact []solver.ScoreProb) []chart.Panel {
+	hist := &chart.Histogram{
+		Title:        fmt.Sprintf("Final score (%d games)", sum.Games),
+		XLabel:       "final score",
+		BarLabel:     "simulated",
+		OverlayLabel: "exact",
+	}
+	for _, b := range sum.Histogram {
+		hist.Bins = append(hist.Bins, chart.Bin{From: b.From, To: b.To, Fraction: b.Fraction})
+		if exact == nil {
+			continue
+		}
+		p := 0.0
+		for _, sp := range exact {
+			if sp.Score >= b.From && sp.Score <= b.To {
+				p += sp.Prob
+			}
+		}
+		hist.Overlay = append(hist.Overlay, p)
+	}
+
+	box := &chart.BoxPlot{Title: "Points by category", XLabel: "points (" + boxLabel + ")"}
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		if st.cats[c].N() > 0 {
+			box.Boxes = append(box.Boxes, boxOf(c.String(), st.cats[c]))
+		}
+	}
+	return []chart.Panel{hist, box}
+}
+
+// comparisonCharts returns the mean score of each policy with its
+// confidence interval, each policy's score distribution, and the paired
+// differences from the first policy.
+func comparisonCharts(cmp comparison) []chart.Panel {
+	means := &chart.IntervalChart{
+		Title:    fmt.Sprintf("Mean final score (%.0f%% CI, %d games)", ciLevel*100, cmp.Games),
+		XLabel:   "final score",
+		Decimals: 2,
+	}
+	box := &chart.BoxPlot{Title: "Final score", XLabel: "final score (" + boxLabel + ")"}
+	z := stats.NormalQuantile(0.5 + ciLevel/2)
+	for _, p := range cmp.Policies {
+		means.Rows = append(means.Rows, chart.Interval{
+			Label: p.Name,
+			Value: p.Mean,
+			Low:   p.Mean - z*p.StdError,
+			High:  p.Mean + z*p.StdError,
+		})
+		box.Boxes = append(box.Boxes, boxOf(p.Name, p.scores))
+	}
+
+	first := cmp.Policies[0].Name
+	diffs := &chart.IntervalChart{
+		Title:    fmt.Sprintf("Paired difference from %s (%.0f%% CI)", first, ciLevel*100),
+		XLabel:   "mean score difference, same dice",
+		Marks:    []chart.Mark{{Value: 0}},
+		Decimals: 2,
+	}
+	for _, p := range cmp.Pairs {
+		if p.A == first {
+			// Pairs hold first - other; flip to other - first.
+			diffs.Rows = append(diffs.Rows, chart.Interval{
+				Label: p.B,
+				Value: -p.MeanDifference,
+				Low:   -p.CIHigh,
+				High:  -p.CILow,
+			})
+		}
+	}
+	return []chart.Panel{means, box, diffs}
+}
+
+// boxOf returns the box plot of s.
+func boxOf(label string, s *stats.IntSample) chart.Box {
+	return chart.Box{
+		Label:  label,
+		Low:    float64(s.Quantile(0.05)),
+		Q1:     float64(s.Quantile(0.25)),
+		Median: float64(s.Quantile(0.5)),
+		Q3:     float64(s.Quantile(0.75)),
+		High:   float64(s.Quantile(0.95)),
+		Mean:   s.Mean(),
+	}
+}
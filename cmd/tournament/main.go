@@ -0,0 +1,324 @@
+// Package main runs a round-robin tournament between Jumbleberry Fields
+// policies. Every group of -players policies plays a match of -n games,
+// each player's score decides the game, and the results feed Elo ratings
+// reported as a leaderboard.
+//
+// Game i of match m rolls from its own PCG stream, so results are
+// identical for any number of workers. With -shared-dice every player in
+// a game replays the same stream, so differences in score come from the
+// decisions alone.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/chart"
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/policy"
+	"github.com/iadams749/JBFieldsSolver/internal/rating"
+)
+
+// defaultPolicies is the lineup when -policies is not given.
+const defaultPolicies = "optimal,target:130,risk:0.05,heuristic,greedy,softmax:1,softmax:4"
+
+// Game streams pack the match, game and seat; the top bit marks the
+// decision streams of randomized policies.
+const (
+	gameShift      = 8
+	matchShift     = 40
+	decisionStream = 1 << 63
+)
+
+// standing is one row of the leaderboard.
+type standing struct {
+	Rank      int     `json:"rank"`
+	Policy    string  `json:"policy"`
+	Rating    float64 `json:"rating"`
+	Games     int     `json:"games"`
+	Wins      float64 `json:"wins"` // ties for first share the win
+	WinRate   float64 `json:"win_rate"`
+	MeanScore float64 `json:"mean_score"`
+}
+
+// result is the outcome of a tournament, printed as text or JSON.
+type result struct {
+	Policies        []string   `json:"policies"`
+	PlayersPerMatch int        `json:"players_per_match"`
+	GamesPerMatch   int        `json:"games_per_match"`
+	Matches         int        `json:"matches"`
+	Seed            uint64     `json:"seed"`
+	SharedDice      bool       `json:"shared_dice"`
+	EloK            float64    `json:"elo_k"`
+	ElapsedSeconds  float64    `json:"elapsed_seconds"`
+	Leaderboard     []standing `json:"leaderboard"`
+}
+
+func main() {
+	evPath := flag.String("ev", "ev_table.json", "path to EV table JSON")
+	specs := flag.String("policies", defaultPolicies, "comma-separated policies to enter")
+	players := flag.Int("players", 2, "players per match; every group of this many policies plays a match")
+	numGames := flag.Int("n", 200, "games per match")
+	seed := flag.Uint64("seed", 0, "random seed (0 = use current time)")
+	sharedDice := flag.Bool("shared-dice", true, "give every player in a game the same dice")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "number of games to play in parallel")
+	eloK := flag.Float64("k", rating.DefaultK, "Elo update factor")
+	format := flag.String("format", "text", "output format: text or json")
+	svgPath := flag.String("svg", "", "write a chart of the leaderboard to this SVG file")
+	flag.Parse()
+
+	if *numGames < 1 || *numGames >= 1<<(matchShift-gameShift) || *workers < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -n and -workers must be at least 1, and -n below 2^32")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, `Error: -format must be "text" or "json"`)
+		os.Exit(1)
+	}
+
+	table, err := ev.LoadJSON(*evPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading EV table: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run the CLI or API first to generate ev_table.json")
+		os.Exit(1)
+	}
+
+	policies, err := policy.ParseList(*specs, table)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -policies: %v\n", err)
+		os.Exit(1)
+	}
+	seen := make(map[string]bool)
+	for _, p := range policies {
+		if seen[p.Name()] {
+			fmt.Fprintf(os.Stderr, "Error: policy %s entered twice\n", p.Name())
+			os.Exit(1)
+		}
+		seen[p.Name()] = true
+	}
+	if *players < 2 || *players > len(policies) || *players >= 1<<gameShift {
+		fmt.Fprintf(os.Stderr, "Error: -players must be between 2 and the number of policies (%d)\n", len(policies))
+		os.Exit(1)
+	}
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+
+	// Progress goes to stderr when stdout carries JSON.
+	progress := os.Stdout
+	if *format == "json" {
+		progress = os.Stderr
+	}
+
+	matches := combinations(len(policies), *players)
+	fmt.Fprintf(progress, "%d policies, %d matches of %d games on %d workers...\n\n",
+		len(policies), len(matches), *numGames, *workers)
+
+	start := time.Now()
+	scores, err := playAll(policies, matches, *numGames, *seed, *sharedDice, *workers, func(done, total int) {
+		fmt.Fprintf(progress, "  %d/%d games  (%v elapsed)\n", done, total, time.Since(start).Round(time.Millisecond))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+
+	res := result{
+		PlayersPerMatch: *players,
+		GamesPerMatch:   *numGames,
+		Matches:         len(matches),
+		Seed:            *seed,
+		SharedDice:      *sharedDice,
+		EloK:            *eloK,
+		ElapsedSeconds:  time.Since(start).Seconds(),
+		Leaderboard:     rate(policies, matches, scores, *eloK),
+	}
+	for _, p := range policies {
+		res.Policies = append(res.Policies, p.Name())
+	}
+
+	if *svgPath != "" {
+		if err := writeSVG(*svgPath, res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing SVG: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printLeaderboard(res)
+}
+
+// combinations returns every k-element subset of 0..n-1 in lexicographic
+// order.
+func combinations(n, k int) [][]int {
+	var out [][]int
+	var rec func(from int, cur []int)
+	rec = func(from int, cur []int) {
+		if len(cur) == k {
+			out = append(out, append([]int(nil), cur...))
+			return
+		}
+		for i := from; i < n; i++ {
+			rec(i+1, append(cur, i))
+		}
+	}
+	rec(0, nil)
+	return out
+}
+
+// playAll plays every game of every match and returns the final scores,
+// indexed by game number times len(matches) plus match, then seat.
+// Interleaving the matches this way lets ratings evolve evenly.
+func playAll(policies []policy.Policy, matches [][]int, n int, seed uint64, shared bool, workers int, onProgress func(done, total int)) ([][]int, error) {
+	total := n * len(matches)
+	scores := make([][]int, total)
+	progressStep := int64(max(total/10, 1))
+
+	var next, done atomic.Int64
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for {
+				j := int(next.Add(1) - 1)
+				if j >= total {
+					return
+				}
+				i, m := j/len(matches), j%len(matches)
+				row := make([]int, len(matches[m]))
+				for seat, p := range matches[m] {
+					stream := uint64(m)<<matchShift | uint64(i)<<gameShift
+					if !shared {
+						stream |= uint64(seat)
+					}
+					src := game.NewRandSource(rand.New(rand.NewPCG(seed, stream)))
+					rng := rand.New(rand.NewPCG(seed, stream|uint64(seat)|decisionStream))
+					g, err := policy.Play(src, policies[p].NewPlayer(rng), game.NewGame())
+					if err != nil {
+						errs <- fmt.Errorf("match %d, game %d, policy %s: %w", m+1, i+1, policies[p].Name(), err)
+						next.Store(int64(total)) // stop the other workers
+						return
+					}
+					row[seat] = g.Total()
+				}
+				scores[j] = row
+				if d := done.Add(1); d%progressStep == 0 {
+					onProgress(int(d), total)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	return scores, <-errs
+}
+
+// rate runs the Elo ratings over the games in order and returns the
+// leaderboard.
+func rate(policies []policy.Policy, matches [][]int, scores [][]int, k float64) []standing {
+	elo := rating.NewElo(k)
+	wins := make(map[string]float64)
+	points := make(map[string]int)
+	for j, row := range scores {
+		match := matches[j%len(matches)]
+		names := make([]string, len(match))
+		top, numTop := row[0], 0
+		for seat, p := range match {
+			names[seat] = policies[p].Name()
+			points[names[seat]] += row[seat]
+			top = max(top, row[seat])
+		}
+		for _, s := range row {
+			if s == top {
+				numTop++
+			}
+		}
+		for seat, s := range row {
+			if s == top {
+				wins[names[seat]] += 1 / float64(numTop)
+			}
+		}
+		elo.Update(names, row)
+	}
+
+	var board []standing
+	for rank, name := range elo.Players() {
+		games := elo.Games(name)
+		board = append(board, standing{
+			Rank:      rank + 1,
+			Policy:    name,
+			Rating:    elo.Rating(name),
+			Games:     games,
+			Wins:      wins[name],
+			WinRate:   wins[name] / float64(games),
+			MeanScore: float64(points[name]) / float64(games),
+		})
+	}
+	return board
+}
+
+// printLeaderboard writes the human-readable leaderboard.
+func printLeaderboard(res result) {
+	fmt.Println()
+	fmt.Println("=== Leaderboard ===")
+	fmt.Printf("%d-player matches, %d games each, seed %d", res.PlayersPerMatch, res.GamesPerMatch, res.Seed)
+	if res.SharedDice {
+		fmt.Print(", shared dice")
+	}
+	fmt.Println()
+	fmt.Printf("Time elapsed: %v\n", time.Duration(res.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Println()
+
+	fmt.Printf("  %4s  %-14s  %7s  %6s  %8s  %9s\n", "Rank", "Policy", "Elo", "Games", "Win rate", "Avg score")
+	for _, s := range res.Leaderboard {
+		fmt.Printf("  %4d  %-14s  %7.1f  %6d  %7.1f%%  %9.2f\n",
+			s.Rank, s.Policy, s.Rating, s.Games, s.WinRate*100, s.MeanScore)
+	}
+}
+
+// writeSVG writes the ratings and mean scores of the leaderboard to path
+// as an SVG chart.
+func writeSVG(path string, res result) error {
+	ratings := &chart.IntervalChart{
+		Title:  "Elo rating",
+		XLabel: fmt.Sprintf("rating (K = %g, start %d)", res.EloK, rating.InitialRating),
+		Marks:  []chart.Mark{{Value: rating.InitialRating}},
+	}
+	scores := &chart.IntervalChart{
+		Title:    "Mean final score",
+		XLabel:   "final score",
+		Decimals: 2,
+	}
+	for _, s := range res.Leaderboard {
+		ratings.Rows = append(ratings.Rows, chart.Interval{Label: s.Policy, Value: s.Rating, Low: s.Rating, High: s.Rating})
+		scores.Rows = append(scores.Rows, chart.Interval{Label: s.Policy, Value: s.MeanScore, Low: s.MeanScore, High: s.MeanScore})
+	}
+	title := fmt.Sprintf("Tournament: %d-player matches, %d games each, seed %d", res.PlayersPerMatch, res.GamesPerMatch, res.Seed)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := chart.Write(f, title, ratings, scores); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"syscall/js"
 
 	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
 	"github.com/iadams749/JBFieldsSolver/internal/solver"
 )
 
@@ -14,6 +15,7 @@ var table *ev.Table
 
 func main() {
 	js.Global().Set("jbfSolve", js.FuncOf(solve))
+	js.Global().Set("jbfPlan", js.FuncOf(plan))
 	js.Global().Set("jbfLoadEVTable", js.FuncOf(loadEVTable))
 	js.Global().Set("jbfComputeEVTable", js.FuncOf(computeEVTable))
 	js.Global().Set("jbfReady", js.ValueOf(true))
@@ -55,15 +57,21 @@ func computeEVTable(_ js.Value, _ []js.Value) any {
 }
 
 type solveRequest struct {
-	Dice       string `json:"dice"`
-	RollsLeft  int    `json:"rolls_left"`
-	Categories string `json:"categories"`
+	Dice       string          `json:"dice"`
+	RollsLeft  int             `json:"rolls_left"`
+	Categories string          `json:"categories"`
+	Scorecard  json.RawMessage `json:"scorecard"` // notation string or object; replaces categories
 }
 
 type errorResponse struct {
 	Error string `json:"error"`
 }
 
+type planRequest struct {
+	solveRequest
+	Threshold *float64 `json:"threshold"` // default solver.DefaultPlanThreshold; 0 keeps all branches
+}
+
 // solve takes a JSON request string and returns a JSON response string.
 // Call: jbfSolve(jsonString) → JSON string.
 func solve(_ js.Value, args []js.Value) any {
@@ -80,29 +88,111 @@ func solve(_ js.Value, args []js.Value) any {
 		return marshalError("invalid JSON: " + err.Error())
 	}
 
-	dice, err := solver.ParseDice(req.Dice)
-	if err != nil {
-		return marshalError("invalid dice: " + err.Error())
+	dice, cs, card, errMsg := parseState(req)
+	if errMsg != "" {
+		return marshalError(errMsg)
+	}
+
+	rec := solver.Solve(dice, req.RollsLeft, cs, table)
+	rec.Scorecard = card
+	solver.Explain(&rec, dice, req.RollsLeft, cs, table)
+	solver.AddOutcomes(&rec, req.RollsLeft, cs, table)
+	if req.RollsLeft < game.RollsPerRound {
+		roll := rollOrder(req.Dice, dice)
+		rec.Roll = &roll
 	}
+	result := solver.RecommendationToJSON(rec)
+
+	data, _ := json.Marshal(result)
+	return string(data)
+}
 
-	if req.RollsLeft < 0 || req.RollsLeft > 2 {
-		return marshalError("rolls_left must be 0, 1, or 2")
+// plan takes a JSON request string (as for jbfSolve, plus an optional
+// "threshold") and returns the round plan tree as a JSON string.
+// Call: jbfPlan(jsonString) → JSON string.
+func plan(_ js.Value, args []js.Value) any {
+	if table == nil {
+		return marshalError("EV table not loaded")
+	}
+
+	if len(args) < 1 {
+		return marshalError("missing JSON argument")
+	}
+
+	var req planRequest
+	if err := json.Unmarshal([]byte(args[0].String()), &req); err != nil {
+		return marshalError("invalid JSON: " + err.Error())
+	}
+
+	dice, cs, _, errMsg := parseState(req.solveRequest)
+	if errMsg != "" {
+		return marshalError(errMsg)
+	}
+
+	threshold := solver.DefaultPlanThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+	if threshold < 0 || threshold >= 1 {
+		return marshalError("threshold must be in [0, 1)")
+	}
+
+	root := solver.PlanRound(dice, req.RollsLeft, cs, table, threshold)
+	data, _ := json.Marshal(solver.PlanToJSON(root))
+	return string(data)
+}
+
+// parseState validates the game state in req, returning an error message
+// if it is invalid. The scorecard is nil unless the request gives one.
+func parseState(req solveRequest) (game.Dice, game.CategorySet, *game.Scorecard, string) {
+	if req.RollsLeft < 0 || req.RollsLeft > game.RollsPerRound {
+		return game.Dice{}, 0, nil, "rolls_left must be 0, 1, 2, or 3"
+	}
+
+	// With 3 rolls left the dice have not been rolled yet and are ignored.
+	var dice game.Dice
+	if req.RollsLeft < game.RollsPerRound {
+		var err error
+		dice, err = solver.ParseDice(req.Dice)
+		if err != nil {
+			return game.Dice{}, 0, nil, "invalid dice: " + err.Error()
+		}
+	}
+
+	if len(req.Scorecard) > 0 && string(req.Scorecard) != "null" {
+		if req.Categories != "" {
+			return game.Dice{}, 0, nil, "give either categories or scorecard, not both"
+		}
+		card, err := solver.ParseScorecardJSON(req.Scorecard)
+		if err != nil {
+			return game.Dice{}, 0, nil, "invalid scorecard: " + err.Error()
+		}
+		if card.CategoriesLeft() == 0 {
+			return game.Dice{}, 0, nil, "scorecard is full: the game is over"
+		}
+		return dice, card.CategoriesLeft(), &card, ""
 	}
 
 	cs, err := solver.ParseCategories(req.Categories)
 	if err != nil {
-		return marshalError("invalid categories: " + err.Error())
+		return game.Dice{}, 0, nil, "invalid categories: " + err.Error()
 	}
 
 	if cs == 0 {
-		return marshalError("at least one category must be selected")
+		return game.Dice{}, 0, nil, "at least one category must be selected"
 	}
 
-	rec := solver.Solve(dice, req.RollsLeft, cs, table)
-	result := solver.RecommendationToJSON(rec)
+	return dice, cs, nil, ""
+}
 
-	data, _ := json.Marshal(result)
-	return string(data)
+// rollOrder returns the dice in the order they were given so hold masks
+// line up with the caller's dice. Count-format input has no order, so the
+// dice are laid out canonically (J, S, P, M, X).
+func rollOrder(input string, dice game.Dice) game.Roll {
+	if roll, err := solver.ParseRoll(input); err == nil {
+		return roll
+	}
+	return game.RollFromDice(dice)
 }
 
 func marshalError(msg string) string {
@@ -0,0 +1,213 @@
+// Package adaptive learns the face probabilities of a physical die set
+// during play. A Posterior keeps a Dirichlet distribution over the face
+// probabilities and updates it with every observed die; an Advisor
+// re-solves the EV table with the posterior mean once the estimate has
+// drifted far enough to matter, and reports when its advice differs from
+// the advice for standard dice.
+package adaptive
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// DefaultStrength is the weight of the prior in dice: the prior counts as
+// much as 250 observed dice (50 rolls of five), so a few unlucky rolls do
+// not swing the advice.
+const DefaultStrength = 250
+
+// DefaultTolerance is the L1 distance between the posterior mean and the
+// probabilities of the current table at which an Advisor re-solves.
+// Smaller drifts move EVs by hundredths of a point and rarely change a
+// decision.
+const DefaultTolerance = 0.03
+
+// Posterior is a Dirichlet distribution over the face probabilities of a
+// die set. The zero value is not usable; call NewPosterior.
+type Posterior struct {
+	alpha [game.NumBerryTypes]float64
+	dice  int // dice observed
+}
+
+// NewPosterior returns the prior Dirichlet(strength · p) with p the face
+// probabilities of prior. strength is the number of dice the prior is
+// worth and must be positive.
+func NewPosterior(prior *game.DiceModel, strength float64) (*Posterior, error) {
+	if !(strength > 0) || math.IsInf(strength, 1) {
+		return nil, fmt.Errorf("prior strength must be positive and finite, got %v", strength)
+	}
+	p := &Posterior{}
+	for b, q := range prior.FaceProb() {
+		p.alpha[b] = strength * q
+	}
+	return p, nil
+}
+
+// Observe adds the faces of freshly rolled dice. Dice kept from an
+// earlier roll must not be observed again.
+func (p *Posterior) Observe(d game.Dice) {
+	for b, n := range d {
+		p.alpha[b] += float64(n)
+	}
+	p.dice += d.Total()
+}
+
+// Dice returns the number of dice observed.
+func (p *Posterior) Dice() int {
+	return p.dice
+}
+
+// Alpha returns the Dirichlet parameters: the prior pseudo-counts plus
+// the observed counts of each face.
+func (p *Posterior) Alpha() [game.NumBerryTypes]float64 {
+	return p.alpha
+}
+
+// total returns the sum of the Dirichlet parameters.
+func (p *Posterior) total() float64 {
+	sum := 0.0
+	for _, a := range p.alpha {
+		sum += a
+	}
+	return sum
+}
+
+// Mean returns the posterior mean of each face probability. It is also
+// the predictive probability of each face on the next die rolled.
+func (p *Posterior) Mean() [game.NumBerryTypes]float64 {
+	total := p.total()
+	var mean [game.NumBerryTypes]float64
+	for b, a := range p.alpha {
+		mean[b] = a / total
+	}
+	return mean
+}
+
+// StdDev returns the posterior standard deviation of the probability of
+// face b.
+func (p *Posterior) StdDev(b game.Berry) float64 {
+	total := p.total()
+	a := p.alpha[b]
+	return math.Sqrt(a * (total - a) / (total * total * (total + 1)))
+}
+
+// Model returns the dice model of the posterior mean.
+func (p *Posterior) Model() *game.DiceModel {
+	m, err := game.NewDiceModel(p.Mean())
+	if err != nil {
+		panic(err) // the mean is a probability vector
+	}
+	return m
+}
+
+// distance returns the L1 distance between two probability vectors.
+func distance(a, b [game.NumBerryTypes]float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += math.Abs(a[i] - b[i])
+	}
+	return d
+}
+
+// Advisor gives advice for a die set whose probabilities it learns from
+// the rolls it is shown. It solves with an EV table for the posterior
+// mean, which it recomputes (about a second) whenever the mean drifts
+// more than Tolerance from the probabilities the table was built for.
+type Advisor struct {
+	// Tolerance is the drift that triggers a re-solve; see
+	// DefaultTolerance.
+	Tolerance float64
+
+	posterior *Posterior
+	standard  *ev.Table // standard dice, for comparison
+	table     *ev.Table // posterior mean as of the last re-solve
+	resolves  int
+}
+
+// NewAdvisor returns an advisor starting from posterior. standard is the
+// EV table of the standard dice; prior is the table for the posterior's
+// current mean, or nil to compute it (standard is used if the mean is the
+// standard dice).
+func NewAdvisor(standard *ev.Table, posterior *Posterior, prior *ev.Table) *Advisor {
+	a := &Advisor{Tolerance: DefaultTolerance, posterior: posterior, standard: standard, table: prior}
+	if a.table == nil {
+		if m := posterior.Model(); m.IsDefault() {
+			a.table = standard
+		} else {
+			a.table = ev.ComputeWith(m, nil)
+		}
+	}
+	return a
+}
+
+// Posterior returns the advisor's posterior.
+func (a *Advisor) Posterior() *Posterior {
+	return a.posterior
+}
+
+// Table returns the EV table the advisor currently solves with.
+func (a *Advisor) Table() *ev.Table {
+	return a.table
+}
+
+// Resolves returns the number of times the table has been recomputed.
+func (a *Advisor) Resolves() int {
+	return a.resolves
+}
+
+// Drift returns the L1 distance between the posterior mean and the
+// probabilities of the current table.
+func (a *Advisor) Drift() float64 {
+	return distance(a.posterior.Mean(), a.table.Model().FaceProb())
+}
+
+// Observe adds freshly rolled dice to the posterior and re-solves if the
+// estimate has drifted past Tolerance. It reports whether it re-solved.
+func (a *Advisor) Observe(d game.Dice) bool {
+	a.posterior.Observe(d)
+	if a.Drift() <= a.Tolerance {
+		return false
+	}
+	m := a.posterior.Model()
+	if m.IsDefault() {
+		a.table = a.standard
+	} else {
+		a.table = ev.ComputeWith(m, nil)
+	}
+	a.resolves++
+	return true
+}
+
+// Advice is the advisor's recommendation for a state, with the
+// recommendation for standard dice beside it.
+type Advice struct {
+	Recommendation solver.Recommendation // for the learned dice
+	Standard       solver.Action         // best action for standard dice
+
+	// Differs reports whether Standard is a different move from the
+	// learned best action. Gain is then how much more the learned best
+	// action is expected to score than Standard, both valued with the
+	// learned dice.
+	Differs bool
+	Gain    float64
+}
+
+// Advise solves the state for the learned dice and compares the result
+// with the advice for standard dice.
+func (a *Advisor) Advise(dice game.Dice, rollsLeft int, cs game.CategorySet) Advice {
+	rs := solver.NewRoundSolver(cs, a.table)
+	adv := Advice{
+		Recommendation: rs.Solve(dice, rollsLeft),
+		Standard:       solver.Solve(dice, rollsLeft, cs, a.standard).BestAction,
+	}
+	best := adv.Recommendation.BestAction
+	if !best.SameMove(adv.Standard) {
+		adv.Differs = true
+		adv.Gain = best.EV - rs.ActionEV(dice, rollsLeft, adv.Standard)
+	}
+	return adv
+}
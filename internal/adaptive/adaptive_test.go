@@ -0,0 +1,144 @@
+package adaptive
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+func TestPosterior(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewPosterior(game.DefaultModel, 0); err == nil {
+		t.Error("NewPosterior(strength 0) succeeded")
+	}
+
+	p, err := NewPosterior(game.DefaultModel, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Mean(); got != game.FaceProb {
+		t.Errorf("prior mean = %v, want %v", got, game.FaceProb)
+	}
+	if !p.Model().IsDefault() {
+		t.Errorf("prior model = %v, want the standard dice", p.Model())
+	}
+
+	// Alpha = 3,3,2,1,1 plus 10 Moonberries = 3,3,2,11,1 of 20.
+	p.Observe(game.Dice{0, 0, 0, 5, 0})
+	p.Observe(game.Dice{0, 0, 0, 5, 0})
+	if p.Dice() != 10 {
+		t.Errorf("Dice() = %d, want 10", p.Dice())
+	}
+	want := [game.NumBerryTypes]float64{0.15, 0.15, 0.1, 0.55, 0.05}
+	for b, m := range p.Mean() {
+		if math.Abs(m-want[b]) > 1e-12 {
+			t.Errorf("Mean()[%v] = %v, want %v", game.Berry(b), m, want[b])
+		}
+	}
+	// Beta(11, 9) has variance 11·9 / (20²·21).
+	if got, want := p.StdDev(game.Moonberry), math.Sqrt(99.0/(400*21)); math.Abs(got-want) > 1e-12 {
+		t.Errorf("StdDev(Moonberry) = %v, want %v", got, want)
+	}
+}
+
+func TestPosteriorConverges(t *testing.T) {
+	t.Parallel()
+
+	truth := [game.NumBerryTypes]float64{0.2, 0.3, 0.2, 0.2, 0.1}
+	model, err := game.NewDiceModel(truth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := game.NewModelSource(rand.New(rand.NewPCG(1, 2)), model)
+	p, _ := NewPosterior(game.DefaultModel, DefaultStrength)
+	for range 4000 {
+		faces, err := src.Roll(game.NumDice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var d game.Dice
+		for _, b := range faces {
+			d[b]++
+		}
+		p.Observe(d)
+	}
+	for b, m := range p.Mean() {
+		// 20,000 dice: a few standard deviations is under 0.02.
+		if math.Abs(m-truth[b]) > 0.02 {
+			t.Errorf("Mean()[%v] = %v after 20000 dice, want about %v", game.Berry(b), m, truth[b])
+		}
+	}
+}
+
+func TestAdvisor(t *testing.T) {
+	t.Parallel()
+
+	standard := ev.NewTable()
+	p, _ := NewPosterior(game.DefaultModel, DefaultStrength)
+	a := NewAdvisor(standard, p, nil)
+	if a.Table() != standard {
+		t.Fatal("advisor with a standard prior does not start from the standard table")
+	}
+
+	// One roll barely moves the estimate: no re-solve.
+	if a.Observe(game.Dice{0, 0, 0, 1, 0}) {
+		t.Errorf("re-solved after one die, drift %v", a.Drift())
+	}
+
+	// Pests that never show up: the dice have no Pest face. Once the
+	// estimate drifts past the tolerance the advisor re-solves.
+	resolved := false
+	for range 40 {
+		resolved = a.Observe(game.Dice{2, 1, 1, 1, 0}) || resolved
+	}
+	if !resolved || a.Resolves() == 0 {
+		t.Fatalf("no re-solve after 200 dice without a Pest, drift %v", a.Drift())
+	}
+	if a.Drift() > a.Tolerance {
+		t.Errorf("Drift() = %v after re-solving, want at most %v", a.Drift(), a.Tolerance)
+	}
+	if a.Table().Model().FaceProb()[game.Pest] >= 0.1 {
+		t.Errorf("table Pest probability = %v, want below the standard 0.1", a.Table().Model().FaceProb()[game.Pest])
+	}
+
+	// The learned advice is optimal for the learned dice, so it never
+	// gains less than the standard advice.
+	cs := game.AllCategories
+	adv := a.Advise(game.Dice{1, 1, 1, 1, 1}, 2, cs)
+	if adv.Differs && adv.Gain < 0 {
+		t.Errorf("Gain = %v, want non-negative", adv.Gain)
+	}
+	if !adv.Differs && adv.Gain != 0 {
+		t.Errorf("Gain = %v for the same advice", adv.Gain)
+	}
+}
+
+func TestAdvisorDiffers(t *testing.T) {
+	t.Parallel()
+
+	// Dice that almost always roll Moonberries: with Jumbleberry and
+	// Moonberry left, standard dice keep the Moonberries, but these dice
+	// keep the Jumbleberries and count on rerolling Moonberries.
+	biased, err := game.NewDiceModel([game.NumBerryTypes]float64{0.05, 0.05, 0.05, 0.8, 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := NewPosterior(biased, DefaultStrength)
+	a := NewAdvisor(ev.NewTable(), p, nil)
+
+	cs := game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatJumbleberry)
+	dice := game.Dice{3, 0, 0, 2, 0}
+	adv := a.Advise(dice, 1, cs)
+	best := adv.Recommendation.BestAction
+	if best.Type != solver.RerollAction || best.Keep != (game.Dice{3, 0, 0, 0, 0}) {
+		t.Errorf("learned best action = %+v, want keep the three Jumbleberries", best)
+	}
+	if !adv.Differs || adv.Gain <= 0 {
+		t.Errorf("Differs = %v, Gain = %v, want different advice with a positive gain (standard %+v)", adv.Differs, adv.Gain, adv.Standard)
+	}
+}
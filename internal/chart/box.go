@@ -0,0 +1,53 @@
+package chart
+
+// Box summarizes one distribution: whiskers from Low to High, a box from
+// Q1 to Q3 split at the median, and a dot at the mean.
+type Box struct {
+	Label                     string
+	Low, Q1, Median, Q3, High float64
+	Mean                      float64
+}
+
+// BoxPlot draws one horizontal box per row on a shared axis.
+type BoxPlot struct {
+	Title  string
+	XLabel string
+	Boxes  []Box
+}
+
+func (p *BoxPlot) height() float64 {
+	return titleHeight + float64(len(p.Boxes))*rowHeight + axisHeight
+}
+
+func (p *BoxPlot) draw(c *canvas, top float64) {
+	labels := make([]string, len(p.Boxes))
+	lo, hi := 0.0, 0.0
+	for i, b := range p.Boxes {
+		labels[i] = b.Label
+		if i == 0 {
+			lo, hi = b.Low, b.High
+		}
+		lo, hi = min(lo, b.Low, b.Mean), max(hi, b.High, b.Mean)
+	}
+	left := labelMargin(labels)
+	c.text(left, top+22, "start", 14, true, p.Title)
+
+	plotTop := top + titleHeight
+	bottom := plotTop + float64(len(p.Boxes))*rowHeight
+	lo, hi = niceRange(lo, hi, 8)
+	xs := scale{lo: lo, hi: hi, from: left, to: Width - marginRight}
+	c.xAxis(xs, plotTop, bottom, p.XLabel, formatTick)
+
+	for i, b := range p.Boxes {
+		mid := plotTop + (float64(i)+0.5)*rowHeight
+		half := rowHeight * 0.3
+		c.text(left-8, mid+4, "end", 12, false, b.Label)
+		c.line(xs.at(b.Low), mid, xs.at(b.Q1), mid, colorPrimary, 1, false)
+		c.line(xs.at(b.Q3), mid, xs.at(b.High), mid, colorPrimary, 1, false)
+		c.line(xs.at(b.Low), mid-half/2, xs.at(b.Low), mid+half/2, colorPrimary, 1, false)
+		c.line(xs.at(b.High), mid-half/2, xs.at(b.High), mid+half/2, colorPrimary, 1, false)
+		c.rect(xs.at(b.Q1), mid-half, xs.at(b.Q3)-xs.at(b.Q1), 2*half, colorFill, colorPrimary)
+		c.line(xs.at(b.Median), mid-half, xs.at(b.Median), mid+half, colorAxis, 2, false)
+		c.circle(xs.at(b.Mean), mid, 3, colorOverlay)
+	}
+}
@@ -0,0 +1,235 @@
+// Package chart renders simple statistical charts as standalone SVG
+// documents using only the standard library. A document stacks one or
+// more panels, each a histogram, box plot or interval chart with its own
+// title and axes.
+package chart
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Width is the width of every document, in pixels.
+const Width = 720
+
+// Layout, in pixels.
+const (
+	titleHeight = 36  // document and panel titles
+	axisHeight  = 46  // tick labels and axis label below a plot
+	plotHeight  = 240 // plot area of a histogram
+	rowHeight   = 26  // one row of a box plot or interval chart
+	panelGap    = 20
+	marginRight = 24
+	charWidth   = 7 // rough width of a label character
+)
+
+// Colors.
+const (
+	colorPrimary = "#4e79a7"
+	colorFill    = "#a0cbe8"
+	colorOverlay = "#e15759"
+	colorAxis    = "#333333"
+	colorGrid    = "#e0e0e0"
+	colorMark    = "#888888"
+)
+
+// Panel is one chart in a document.
+type Panel interface {
+	height() float64
+	draw(c *canvas, top float64)
+}
+
+// Write writes an SVG document holding panels stacked top to bottom,
+// under title if it is not empty.
+func Write(w io.Writer, title string, panels ...Panel) error {
+	c := &canvas{}
+	y := 0.0
+	if title != "" {
+		c.text(Width/2, 26, "middle", 18, true, title)
+		y = titleHeight
+	}
+	for _, p := range panels {
+		p.draw(c, y)
+		y += p.height() + panelGap
+	}
+	height := math.Ceil(y)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%.0f" viewBox="0 0 %d %.0f" font-family="sans-serif">`+"\n",
+		Width, height, Width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	b.WriteString(c.b.String())
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// canvas accumulates SVG elements.
+type canvas struct {
+	b strings.Builder
+}
+
+func (c *canvas) rect(x, y, w, h float64, fill, stroke string) {
+	fmt.Fprintf(&c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"`, num(x), num(y), num(max(w, 0)), num(max(h, 0)), fill)
+	if stroke != "" {
+		fmt.Fprintf(&c.b, ` stroke="%s"`, stroke)
+	}
+	c.b.WriteString("/>\n")
+}
+
+func (c *canvas) line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool) {
+	fmt.Fprintf(&c.b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"`,
+		num(x1), num(y1), num(x2), num(y2), stroke, num(width))
+	if dashed {
+		c.b.WriteString(` stroke-dasharray="4 3"`)
+	}
+	c.b.WriteString("/>\n")
+}
+
+func (c *canvas) polyline(xs, ys []float64, stroke string, width float64) {
+	var pts []string
+	for i := range xs {
+		pts = append(pts, num(xs[i])+","+num(ys[i]))
+	}
+	fmt.Fprintf(&c.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s"/>`+"\n",
+		strings.Join(pts, " "), stroke, num(width))
+}
+
+func (c *canvas) circle(x, y, r float64, fill string) {
+	fmt.Fprintf(&c.b, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", num(x), num(y), num(r), fill)
+}
+
+// text writes s anchored at x ("start", "middle" or "end") with its
+// baseline at y.
+func (c *canvas) text(x, y float64, anchor string, size float64, bold bool, s string) {
+	fmt.Fprintf(&c.b, `<text x="%s" y="%s" text-anchor="%s" font-size="%s"`, num(x), num(y), anchor, num(size))
+	if bold {
+		c.b.WriteString(` font-weight="bold"`)
+	}
+	fmt.Fprintf(&c.b, ">%s</text>\n", html.EscapeString(s))
+}
+
+// legend writes swatches and labels right-aligned on the title line of a
+// panel at top. Empty labels are skipped.
+func (c *canvas) legend(top float64, entries []legendEntry) {
+	x := float64(Width - marginRight)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.label == "" {
+			continue
+		}
+		c.text(x, top+22, "end", 12, false, e.label)
+		x -= float64(len(e.label)*charWidth) + 6
+		c.rect(x-12, top+12, 12, 12, e.color, "")
+		x -= 28
+	}
+}
+
+// legendEntry is a color swatch with its label.
+type legendEntry struct {
+	color, label string
+}
+
+// scale maps the data interval [lo, hi] linearly onto pixels [from, to].
+type scale struct {
+	lo, hi, from, to float64
+}
+
+func (s scale) at(v float64) float64 {
+	if s.hi == s.lo {
+		return (s.from + s.to) / 2
+	}
+	return s.from + (v-s.lo)/(s.hi-s.lo)*(s.to-s.from)
+}
+
+// xAxis draws grid lines for the ticks of sc between top and bottom, with
+// tick labels and label below bottom.
+func (c *canvas) xAxis(sc scale, top, bottom float64, label string, format func(float64) string) {
+	for _, t := range ticks(sc.lo, sc.hi, 8) {
+		x := sc.at(t)
+		c.line(x, top, x, bottom, colorGrid, 1, false)
+		c.text(x, bottom+16, "middle", 11, false, format(t))
+	}
+	c.line(sc.from, bottom, sc.to, bottom, colorAxis, 1, false)
+	if label != "" {
+		c.text((sc.from+sc.to)/2, bottom+36, "middle", 12, false, label)
+	}
+}
+
+// yAxis draws grid lines for the ticks of sc between left and right, with
+// tick labels left of left.
+func (c *canvas) yAxis(sc scale, left, right float64, format func(float64) string) {
+	for _, t := range ticks(sc.lo, sc.hi, 5) {
+		y := sc.at(t)
+		c.line(left, y, right, y, colorGrid, 1, false)
+		c.text(left-6, y+4, "end", 11, false, format(t))
+	}
+	c.line(left, sc.from, left, sc.to, colorAxis, 1, false)
+}
+
+// ticks returns round values covering [lo, hi] about n apart, the first
+// at or below lo and the last at or above hi.
+func ticks(lo, hi float64, n int) []float64 {
+	step := tickStep(lo, hi, n)
+	first := math.Floor(lo/step) * step
+	var out []float64
+	for i := 0; ; i++ {
+		t := first + float64(i)*step
+		if math.Abs(t) < step*1e-9 {
+			t = 0
+		}
+		out = append(out, t)
+		if t >= hi-step*1e-9 {
+			return out
+		}
+	}
+}
+
+// tickStep returns 1, 2 or 5 times a power of ten, making about n steps
+// between lo and hi.
+func tickStep(lo, hi float64, n int) float64 {
+	span := hi - lo
+	if span <= 0 {
+		span = math.Max(math.Abs(lo), 1)
+	}
+	raw := span / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+// niceRange widens [lo, hi] to the ticks around it.
+func niceRange(lo, hi float64, n int) (float64, float64) {
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	t := ticks(lo, hi, n)
+	return t[0], t[len(t)-1]
+}
+
+// labelMargin returns the left margin that fits the longest label.
+func labelMargin(labels []string) float64 {
+	longest := 0
+	for _, l := range labels {
+		longest = max(longest, len(l))
+	}
+	return float64(max(longest*charWidth+20, 60))
+}
+
+// num formats a coordinate with at most two decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// formatTick formats a tick value with no more decimals than needed.
+func formatTick(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
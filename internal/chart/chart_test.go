@@ -0,0 +1,141 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTicks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lo, hi float64
+		n      int
+		want   []float64
+	}{
+		{0, 10, 5, []float64{0, 2, 4, 6, 8, 10}},
+		{61, 183, 8, []float64{60, 80, 100, 120, 140, 160, 180, 200}},
+		{0, 0.043, 5, []float64{0, 0.01, 0.02, 0.03, 0.04, 0.05}},
+		{-3.2, 4.9, 4, []float64{-5, 0, 5}},
+		{1500, 1500, 5, []float64{1500}},
+	}
+	for _, tt := range tests {
+		got := ticks(tt.lo, tt.hi, tt.n)
+		if len(got) != len(tt.want) {
+			t.Errorf("ticks(%v, %v, %d) = %v, want %v", tt.lo, tt.hi, tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if d := got[i] - tt.want[i]; d > 1e-9 || d < -1e-9 {
+				t.Errorf("ticks(%v, %v, %d) = %v, want %v", tt.lo, tt.hi, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestNiceRange(t *testing.T) {
+	t.Parallel()
+
+	if lo, hi := niceRange(118.3, 124.9, 6); lo != 118 || hi != 126 {
+		t.Errorf("niceRange(118.3, 124.9) = %v, %v, want 118, 126", lo, hi)
+	}
+	if lo, hi := niceRange(5, 5, 6); lo >= 5 || hi <= 5 {
+		t.Errorf("niceRange(5, 5) = %v, %v, want a range around 5", lo, hi)
+	}
+}
+
+// parseSVG checks that doc is well-formed XML and returns the number of
+// each element and the text content.
+func parseSVG(t *testing.T, doc string) (map[string]int, []string) {
+	t.Helper()
+	counts := make(map[string]int)
+	var texts []string
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, doc)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			counts[tok.Name.Local]++
+			inText = tok.Name.Local == "text"
+		case xml.EndElement:
+			inText = false
+		case xml.CharData:
+			if inText {
+				texts = append(texts, string(tok))
+			}
+		}
+	}
+	return counts, texts
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	hist := &Histogram{
+		Title:        "Final score",
+		XLabel:       "score",
+		Bins:         []Bin{{100, 109, 0.2}, {110, 119, 0.5}, {120, 129, 0.3}},
+		BarLabel:     "simulated",
+		Overlay:      []float64{0.25, 0.45, 0.3},
+		OverlayLabel: "exact",
+	}
+	box := &BoxPlot{
+		Title: "Points by category",
+		Boxes: []Box{
+			{Label: "Jumbleberry", Low: 0, Q1: 4, Median: 6, Q3: 8, High: 10, Mean: 5.9},
+			{Label: "Full <House> & co", Low: 0, Q1: 25, Median: 25, Q3: 25, High: 25, Mean: 20},
+		},
+	}
+	intervals := &IntervalChart{
+		Title:    "Mean final score",
+		Rows:     []Interval{{"optimal", 121.8, 121.6, 122.0}, {"greedy", 110.2, 110.2, 110.2}},
+		Marks:    []Mark{{121.8, "EV"}},
+		Decimals: 2,
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "Report", hist, box, intervals); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	counts, texts := parseSVG(t, buf.String())
+
+	if counts["svg"] != 1 {
+		t.Errorf("%d svg elements, want 1", counts["svg"])
+	}
+	// Background, legend swatches, histogram bars, boxes.
+	if want := 1 + 2 + len(hist.Bins) + len(box.Boxes); counts["rect"] != want {
+		t.Errorf("%d rect elements, want %d", counts["rect"], want)
+	}
+	if counts["polyline"] != 1 {
+		t.Errorf("%d polylines, want 1 overlay", counts["polyline"])
+	}
+	for _, want := range []string{"Report", "Final score", "exact", "Full <House> & co", "121.80", "110.20", "EV"} {
+		if !slices.Contains(texts, want) {
+			t.Errorf("text %q missing from %q", want, texts)
+		}
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := Write(&buf, "", &Histogram{Title: "None"}, &BoxPlot{}, &IntervalChart{})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	parseSVG(t, buf.String())
+}
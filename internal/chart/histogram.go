@@ -0,0 +1,99 @@
+package chart
+
+import (
+	"strconv"
+)
+
+// histMarginLeft is the left margin of a histogram, room for percentages.
+const histMarginLeft = 60
+
+// Bin is one histogram bar covering the integers From..To inclusive.
+type Bin struct {
+	From, To int
+	Fraction float64 // fraction of observations in the bin
+}
+
+// Histogram draws the fraction of observations in each bin, optionally
+// with an expected fraction per bin overlaid as a line.
+type Histogram struct {
+	Title        string
+	XLabel       string
+	Bins         []Bin     // ascending and contiguous
+	BarLabel     string    // legend for the bars, if set
+	Overlay      []float64 // expected fraction per bin; nil for none
+	OverlayLabel string    // legend for the overlay, if set
+}
+
+func (h *Histogram) height() float64 {
+	return titleHeight + plotHeight + axisHeight
+}
+
+func (h *Histogram) draw(c *canvas, top float64) {
+	c.text(histMarginLeft, top+22, "start", 14, true, h.Title)
+	var legend []legendEntry
+	legend = append(legend, legendEntry{colorFill, h.BarLabel})
+	if h.Overlay != nil {
+		legend = append(legend, legendEntry{colorOverlay, h.OverlayLabel})
+	}
+	c.legend(top, legend)
+
+	plotTop := top + titleHeight
+	bottom := plotTop + plotHeight
+	if len(h.Bins) == 0 {
+		c.line(histMarginLeft, bottom, Width-marginRight, bottom, colorAxis, 1, false)
+		return
+	}
+
+	peak := 0.0
+	for i, b := range h.Bins {
+		peak = max(peak, b.Fraction)
+		if i < len(h.Overlay) {
+			peak = max(peak, h.Overlay[i])
+		}
+	}
+	_, yHi := niceRange(0, peak, 5)
+	ys := scale{lo: 0, hi: yHi, from: bottom, to: plotTop}
+	first, last := h.Bins[0].From, h.Bins[len(h.Bins)-1].To+1
+	xs := scale{lo: float64(first), hi: float64(last), from: histMarginLeft, to: Width - marginRight}
+
+	c.yAxis(ys, histMarginLeft, Width-marginRight, formatPercent)
+	for _, b := range h.Bins {
+		x0, x1 := xs.at(float64(b.From)), xs.at(float64(b.To+1))
+		y := ys.at(b.Fraction)
+		c.rect(x0+0.5, y, x1-x0-1, bottom-y, colorFill, colorPrimary)
+	}
+
+	if h.Overlay != nil {
+		var px, py []float64
+		for i, b := range h.Bins {
+			if i >= len(h.Overlay) {
+				break
+			}
+			px = append(px, xs.at(float64(b.From+b.To+1)/2))
+			py = append(py, ys.at(h.Overlay[i]))
+		}
+		c.polyline(px, py, colorOverlay, 2)
+		for i := range px {
+			c.circle(px[i], py[i], 2.5, colorOverlay)
+		}
+	}
+
+	// Label bin edges rather than round ticks, so labels line up with bars.
+	step := max(1, len(h.Bins)/10)
+	for i := 0; i <= len(h.Bins); i += step {
+		edge := last
+		if i < len(h.Bins) {
+			edge = h.Bins[i].From
+		}
+		c.text(xs.at(float64(edge)), bottom+16, "middle", 11, false, strconv.Itoa(edge))
+	}
+	c.line(xs.from, bottom, xs.to, bottom, colorAxis, 1, false)
+	if h.XLabel != "" {
+		c.text((xs.from+xs.to)/2, bottom+36, "middle", 12, false, h.XLabel)
+	}
+}
+
+// formatPercent formats a fraction as a percentage.
+func formatPercent(v float64) string {
+	return formatTick(v*100) + "%"
+}
@@ -0,0 +1,78 @@
+package chart
+
+import "strconv"
+
+// intervalValueWidth is the room right of an interval chart for values.
+const intervalValueWidth = 70
+
+// Interval is a point estimate with an interval around it, such as a mean
+// and its confidence interval. Low and High equal to Value draw a dot only.
+type Interval struct {
+	Label            string
+	Value, Low, High float64
+}
+
+// Mark is a labeled vertical reference line.
+type Mark struct {
+	Value float64
+	Label string
+}
+
+// IntervalChart draws one interval per row on a shared axis, with each
+// value printed at the right.
+type IntervalChart struct {
+	Title    string
+	XLabel   string
+	Rows     []Interval
+	Marks    []Mark
+	Decimals int // decimals of the printed values
+}
+
+func (p *IntervalChart) height() float64 {
+	return titleHeight + float64(len(p.Rows))*rowHeight + axisHeight
+}
+
+func (p *IntervalChart) draw(c *canvas, top float64) {
+	labels := make([]string, len(p.Rows))
+	lo, hi := 0.0, 0.0
+	for i, r := range p.Rows {
+		labels[i] = r.Label
+		if i == 0 {
+			lo, hi = r.Low, r.High
+		}
+		lo, hi = min(lo, r.Low, r.Value), max(hi, r.High, r.Value)
+	}
+	for i, m := range p.Marks {
+		if i == 0 && len(p.Rows) == 0 {
+			lo, hi = m.Value, m.Value
+		}
+		lo, hi = min(lo, m.Value), max(hi, m.Value)
+	}
+	left := labelMargin(labels)
+	c.text(left, top+22, "start", 14, true, p.Title)
+
+	plotTop := top + titleHeight
+	bottom := plotTop + float64(len(p.Rows))*rowHeight
+	lo, hi = niceRange(lo, hi, 6)
+	xs := scale{lo: lo, hi: hi, from: left, to: Width - marginRight - intervalValueWidth}
+	c.xAxis(xs, plotTop, bottom, p.XLabel, formatTick)
+
+	for _, m := range p.Marks {
+		x := xs.at(m.Value)
+		c.line(x, plotTop, x, bottom, colorMark, 1, true)
+		if m.Label != "" {
+			c.text(x+3, plotTop-3, "start", 10, false, m.Label)
+		}
+	}
+	for i, r := range p.Rows {
+		mid := plotTop + (float64(i)+0.5)*rowHeight
+		c.text(left-8, mid+4, "end", 12, false, r.Label)
+		if r.Low != r.High {
+			c.line(xs.at(r.Low), mid, xs.at(r.High), mid, colorPrimary, 2, false)
+			c.line(xs.at(r.Low), mid-4, xs.at(r.Low), mid+4, colorPrimary, 2, false)
+			c.line(xs.at(r.High), mid-4, xs.at(r.High), mid+4, colorPrimary, 2, false)
+		}
+		c.circle(xs.at(r.Value), mid, 4, colorPrimary)
+		c.text(Width-marginRight, mid+4, "end", 12, false, strconv.FormatFloat(r.Value, 'f', p.Decimals, 64))
+	}
+}
@@ -0,0 +1,127 @@
+package ev
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// MaxBankCap is the largest bank of rerolls ComputeBanked accepts. The
+// table grows with the cap and a bank beyond a handful of rerolls is
+// rarely filled.
+const MaxBankCap = 10
+
+// rerollsPerRound is the number of rerolls a round grants.
+const rerollsPerRound = game.RollsPerRound - 1
+
+// BankedTable holds precomputed expected values for the banked-rerolls
+// variant, in which rerolls not used in a round are saved for later
+// rounds, up to a cap. The bank adds a dimension to the state:
+// ev[bank][cs] is the expected total score of the remaining rounds with
+// categories cs left and bank rerolls saved, before any rolls.
+type BankedTable struct {
+	cap   int
+	model *game.DiceModel
+	ev    [][512]float64
+}
+
+// Cap returns the most rerolls the bank can hold.
+func (t *BankedTable) Cap() int {
+	return t.cap
+}
+
+// Model returns the dice model the table was computed for.
+func (t *BankedTable) Model() *game.DiceModel {
+	return t.model
+}
+
+// EV returns the expected value for the given category set with bank
+// rerolls saved.
+func (t *BankedTable) EV(cs game.CategorySet, bank int) float64 {
+	return t.ev[bank][cs]
+}
+
+// BankAfter returns the bank carried into the next round when a round
+// ends with rerolls still available, counting both the round's own and
+// the saved ones.
+func (t *BankedTable) BankAfter(rerolls int) int {
+	return min(rerolls, t.cap)
+}
+
+// MaxRerolls returns the most rerolls a round can have: its own two plus
+// a full bank.
+func (t *BankedTable) MaxRerolls() int {
+	return rerollsPerRound + t.cap
+}
+
+// ComputeBanked builds the EV table of the banked-rerolls variant with a
+// bank of at most bankCap rerolls, for dice following model. With a cap
+// of 0 it is the table of Compute. A round with a bank of b has 2+b
+// rerolls, so each category set needs 2+cap reroll layers instead of 2;
+// a cap of 3 takes a little over twice as long as Compute.
+func ComputeBanked(model *game.DiceModel, bankCap int, onProgress func(size, total int)) (*BankedTable, error) {
+	if bankCap < 0 || bankCap > MaxBankCap {
+		return nil, fmt.Errorf("bank cap %d out of range 0-%d", bankCap, MaxBankCap)
+	}
+	t := &BankedTable{cap: bankCap, model: model, ev: make([][512]float64, bankCap+1)}
+	for size := 1; size <= int(game.NumCategories); size++ {
+		for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+			if cs.Count() != size {
+				continue
+			}
+			layers := t.Layers(cs)
+			for bank := 0; bank <= bankCap; bank++ {
+				v := layers[rerollsPerRound+bank]
+				ev := 0.0
+				for i := range v {
+					ev += model.FirstRollProb(i) * v[i]
+				}
+				t.ev[bank][cs] = ev
+			}
+		}
+		if onProgress != nil {
+			onProgress(size, int(game.NumCategories))
+		}
+	}
+	return t, nil
+}
+
+// ScoreValue returns the value of scoring dice d in cat with rerolls
+// still available: the points plus the EV of the remaining categories
+// with the rerolls saved. Scoring early is worth the rerolls it banks.
+func (t *BankedTable) ScoreValue(d game.Dice, cat game.Category, cs game.CategorySet, rerolls int) float64 {
+	return float64(game.Score(d, cat)) + t.ev[t.BankAfter(rerolls)][cs.Remove(cat)]
+}
+
+// Layers returns the value of every dice outcome in a round with
+// categories cs left, for every number of rerolls available from 0 to
+// MaxRerolls: layers[r][diceIdx]. EVs of the subsets of cs must already
+// be in the table.
+//
+//	V(d, 0) = S(d, 0)
+//	V(d, r) = max(S(d, r), max over keeps of E[V(keep + reroll, r-1)])
+//
+// where S(d, r) is the best ScoreValue with r rerolls left over.
+func (t *BankedTable) Layers(cs game.CategorySet) [][]float64 {
+	allDice := game.AllDice()
+	layers := make([][]float64, t.MaxRerolls()+1)
+	for r := range layers {
+		cur := make([]float64, len(allDice))
+		for i := range cur {
+			cur[i] = math.Inf(-1)
+		}
+		if r > 0 {
+			// The layer's baseline of holding every die is worth
+			// layers[r-1], which never beats V(d, r), so it is harmless.
+			ComputeRerollLayerWith(t.model, allDice, layers[r-1], cur)
+		}
+		for i, d := range allDice {
+			cs.ForEach(func(cat game.Category) {
+				cur[i] = math.Max(cur[i], t.ScoreValue(d, cat, cs, r))
+			})
+		}
+		layers[r] = cur
+	}
+	return layers
+}
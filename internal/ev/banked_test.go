@@ -0,0 +1,112 @@
+package ev
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestComputeBankedRejectsCap(t *testing.T) {
+	t.Parallel()
+
+	for _, bankCap := range []int{-1, MaxBankCap + 1} {
+		if _, err := ComputeBanked(game.DefaultModel, bankCap, nil); err == nil {
+			t.Errorf("ComputeBanked(cap %d) succeeded", bankCap)
+		}
+	}
+}
+
+func TestComputeBankedCapZero(t *testing.T) {
+	t.Parallel()
+
+	// With nothing carried over, the variant is the standard game.
+	banked, err := ComputeBanked(game.DefaultModel, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := Compute(nil)
+	for cs := game.CategorySet(0); cs <= game.AllCategories; cs++ {
+		if got, want := banked.EV(cs, 0), table.EV(cs); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("EV(%v, 0) = %v, want %v", cs, got, want)
+		}
+	}
+}
+
+func TestComputeBanked(t *testing.T) {
+	t.Parallel()
+
+	banked, err := ComputeBanked(game.DefaultModel, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if banked.Cap() != 2 || banked.MaxRerolls() != 4 {
+		t.Errorf("Cap() = %d, MaxRerolls() = %d, want 2 and 4", banked.Cap(), banked.MaxRerolls())
+	}
+	for _, tt := range []struct{ rerolls, want int }{{0, 0}, {1, 1}, {2, 2}, {4, 2}} {
+		if got := banked.BankAfter(tt.rerolls); got != tt.want {
+			t.Errorf("BankAfter(%d) = %d, want %d", tt.rerolls, got, tt.want)
+		}
+	}
+
+	// Saved rerolls are worth something in every state, and more of them
+	// are worth more.
+	table := Compute(nil)
+	for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+		if got := banked.EV(cs, 0); got < table.EV(cs)-1e-9 {
+			t.Fatalf("EV(%v, 0) = %v, below the standard %v", cs, got, table.EV(cs))
+		}
+		for bank := 1; bank <= 2; bank++ {
+			if banked.EV(cs, bank) <= banked.EV(cs, bank-1) {
+				t.Fatalf("EV(%v, %d) = %v, not above EV with a bank of %d (%v)",
+					cs, bank, banked.EV(cs, bank), bank-1, banked.EV(cs, bank-1))
+			}
+		}
+	}
+	if got, base := banked.EV(game.AllCategories, 0), table.EV(game.AllCategories); got <= base {
+		t.Errorf("EV(all, 0) = %v, want more than the standard %v", got, base)
+	}
+
+	// Scoring with rerolls to spare banks them for the rounds left.
+	cs := game.CategorySet(0).Add(game.CatJumbleberry).Add(game.CatMoonberry)
+	d := game.Dice{game.Jumbleberry: 5}
+	if early, late := banked.ScoreValue(d, game.CatJumbleberry, cs, 2), banked.ScoreValue(d, game.CatJumbleberry, cs, 0); early <= late {
+		t.Errorf("ScoreValue with 2 rerolls left = %v, want more than with none (%v)", early, late)
+	}
+}
+
+func TestBankedLayers(t *testing.T) {
+	t.Parallel()
+
+	// With a cap of 0 the layers are the standard round's.
+	banked, err := ComputeBanked(game.DefaultModel, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := game.CategorySet(0).Add(game.CatPickleberry).Add(game.CatBasketOfThree).Add(game.CatFreeRoll)
+	layers := banked.Layers(cs)
+	if len(layers) != game.RollsPerRound {
+		t.Fatalf("len(Layers) = %d, want %d", len(layers), game.RollsPerRound)
+	}
+
+	allDice := game.AllDice()
+	want := make([]float64, len(allDice))
+	for i, d := range allDice {
+		want[i] = math.Inf(-1)
+		cs.ForEach(func(cat game.Category) {
+			want[i] = math.Max(want[i], float64(game.Score(d, cat))+banked.EV(cs.Remove(cat), 0))
+		})
+	}
+	for r := range layers {
+		if r > 0 {
+			next := make([]float64, len(allDice))
+			ComputeRerollLayerWith(game.DefaultModel, allDice, want, next)
+			want = next
+		}
+		for i := range want {
+			if math.Abs(layers[r][i]-want[i]) > 1e-9 {
+				t.Fatalf("layers[%d][%v] = %v, want %v", r, allDice[i], layers[r][i], want[i])
+			}
+		}
+	}
+}
@@ -12,7 +12,16 @@ import (
 // ev[cs] = expected total score added across all remaining rounds
 // when categories cs remain, under optimal play, before any rolls.
 type Table struct {
-	ev [512]float64
+	ev    [512]float64
+	model *game.DiceModel // nil means game.DefaultModel
+}
+
+// Model returns the dice model the table was computed for.
+func (t *Table) Model() *game.DiceModel {
+	if t.model == nil {
+		return game.DefaultModel
+	}
+	return t.model
 }
 
 // EV returns the expected value for the given category set.
@@ -38,7 +47,13 @@ func NewTable() *Table {
 // onProgress is called after each subset size is completed, with the
 // size just finished and total (9). Pass nil to suppress progress.
 func Compute(onProgress func(size, total int)) *Table {
-	t := &Table{}
+	return ComputeWith(game.DefaultModel, onProgress)
+}
+
+// ComputeWith builds the EV table for dice with the face probabilities of
+// model, as Compute does for the standard dice.
+func ComputeWith(model *game.DiceModel, onProgress func(size, total int)) *Table {
+	t := &Table{model: model}
 	// ev[0] = 0 already (no categories left = no more score)
 
 	allDice := game.AllDice()
@@ -79,15 +94,15 @@ func Compute(onProgress func(size, total int)) *Table {
 			}
 
 			// Layer 1: rollsLeft = 1
-			ComputeRerollLayer(allDice, v0, v1)
+			ComputeRerollLayerWith(model, allDice, v0, v1)
 
 			// Layer 2: rollsLeft = 2
-			ComputeRerollLayer(allDice, v1, v2)
+			ComputeRerollLayerWith(model, allDice, v1, v2)
 
 			// EV[cs] = expected value over the first roll (all 5 dice)
 			ev := 0.0
 			for i := range allDice {
-				ev += game.FirstRollProb(i) * v2[i]
+				ev += model.FirstRollProb(i) * v2[i]
 			}
 			t.ev[cs] = ev
 		}
@@ -108,6 +123,12 @@ func Compute(onProgress func(size, total int)) *Table {
 //	V(d, r) = max over all keep decisions k of:
 //	  sum over reroll outcomes o of P(o) * prevLayer[index(k + o)]
 func ComputeRerollLayer(allDice []game.Dice, prevLayer, curLayer []float64) {
+	ComputeRerollLayerWith(game.DefaultModel, allDice, prevLayer, curLayer)
+}
+
+// ComputeRerollLayerWith is ComputeRerollLayer with reroll outcomes drawn
+// from model.
+func ComputeRerollLayerWith(model *game.DiceModel, allDice []game.Dice, prevLayer, curLayer []float64) {
 	for i, d := range allDice {
 		// Baseline: keep all dice (no reroll)
 		bestEV := prevLayer[i]
@@ -118,7 +139,7 @@ func ComputeRerollLayer(allDice []game.Dice, prevLayer, curLayer []float64) {
 			}
 
 			numRerolled := game.NumDice - numKept
-			rerolls := game.Rerolls(numRerolled)
+			rerolls := model.Rerolls(numRerolled)
 
 			ev := 0.0
 			for _, ro := range rerolls {
@@ -185,8 +206,16 @@ func (t *Table) SaveJSON(path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-// LoadJSON reads an EV table from a previously saved JSON file.
+// LoadJSON reads an EV table for the standard dice from a previously
+// saved JSON file.
 func LoadJSON(path string) (*Table, error) {
+	return LoadJSONWith(path, game.DefaultModel)
+}
+
+// LoadJSONWith reads an EV table computed for model from a previously
+// saved JSON file. The file does not record the model, so callers keep
+// tables for different models apart by path.
+func LoadJSONWith(path string, model *game.DiceModel) (*Table, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -195,7 +224,7 @@ func LoadJSON(path string) (*Table, error) {
 	if err := json.Unmarshal(data, &entries); err != nil {
 		return nil, err
 	}
-	t := &Table{}
+	t := &Table{model: model}
 	for _, e := range entries {
 		t.ev[e.CategorySet] = e.EV
 	}
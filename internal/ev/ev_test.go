@@ -207,6 +207,37 @@ func TestComputeBasic(t *testing.T) {
 	}
 }
 
+func TestComputeWith(t *testing.T) {
+	t.Parallel()
+
+	// Dice that always show a Moonberry: every roll is MMMMM, so the EV of
+	// a set of categories is what MMMMM scores in each.
+	model, err := game.NewDiceModel([game.NumBerryTypes]float64{game.Moonberry: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := ComputeWith(model, nil)
+	all := game.Dice{game.Moonberry: game.NumDice}
+	for _, cs := range []game.CategorySet{1 << game.CatMoonberry, 1<<game.CatJumbleberry | 1<<game.CatBasketOfFive, game.AllCategories} {
+		want := 0.0
+		cs.ForEach(func(c game.Category) {
+			want += float64(game.Score(all, c))
+		})
+		if got := table.EV(cs); math.Abs(got-want) > 1e-9 {
+			t.Errorf("EV(%v) = %v, want %v", cs, got, want)
+		}
+	}
+
+	// More Moonberries are worth more.
+	richer, err := game.NewDiceModel([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.15, 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, base := ComputeWith(richer, nil).EV(game.AllCategories), Compute(nil).EV(game.AllCategories); got <= base {
+		t.Errorf("EV with more Moonberries = %v, want above %v", got, base)
+	}
+}
+
 func TestSaveAndLoadJSON(t *testing.T) {
 	// Create a temporary file for testing
 	t.Parallel()
@@ -0,0 +1,185 @@
+package ev
+
+import (
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// MixedTable holds precomputed expected values for all category subsets
+// when the dice are not identical (a game.DieSet), as Table does for
+// identical dice.
+type MixedTable struct {
+	ev   [512]float64
+	dice *game.DieSet
+}
+
+// Dice returns the die set the table was computed for.
+func (t *MixedTable) Dice() *game.DieSet {
+	return t.dice
+}
+
+// EV returns the expected value for the given category set.
+func (t *MixedTable) EV(cs game.CategorySet) float64 {
+	return t.ev[cs]
+}
+
+// ComputeMixed builds the EV table for a die set as Compute does for
+// identical dice. States are ordered rolls instead of face counts, so
+// which die shows which face is known, and the expectation over a reroll
+// is taken one die at a time (see HoldValues). It takes about as long as
+// Compute.
+func ComputeMixed(dice *game.DieSet, onProgress func(size, total int)) *MixedTable {
+	t := &MixedTable{dice: dice}
+	allRolls := game.AllRolls()
+
+	// Scores depend only on the face counts.
+	var scoreTab [game.NumCategories][]float64
+	for cat := game.Category(0); cat < game.NumCategories; cat++ {
+		scoreTab[cat] = make([]float64, len(allRolls))
+		for i, r := range allRolls {
+			scoreTab[cat][i] = float64(game.Score(r.Dice(), cat))
+		}
+	}
+
+	v0 := make([]float64, len(allRolls))
+	v1 := make([]float64, len(allRolls))
+	v2 := make([]float64, len(allRolls))
+	for size := 1; size <= int(game.NumCategories); size++ {
+		for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+			if cs.Count() != size {
+				continue
+			}
+			for i := range allRolls {
+				bestVal := math.Inf(-1)
+				cs.ForEach(func(cat game.Category) {
+					if val := scoreTab[cat][i] + t.ev[cs.Remove(cat)]; val > bestVal {
+						bestVal = val
+					}
+				})
+				v0[i] = bestVal
+			}
+			ComputeMixedRerollLayer(dice, v0, v1)
+			ComputeMixedRerollLayer(dice, v1, v2)
+
+			// The first roll is a reroll of every die.
+			t.ev[cs] = NewHoldValues(dice, v2).Value(game.Roll{}, 0)
+		}
+		if onProgress != nil {
+			onProgress(size, int(game.NumCategories))
+		}
+	}
+	return t
+}
+
+// ComputeMixedRerollLayer is ComputeRerollLayer for a die set: layers
+// are indexed by game.RollIndex and a keep is any subset of the dice.
+//
+//	V(r, n) = max over holds m of E[prevLayer[r with the dice not in m rerolled]]
+func ComputeMixedRerollLayer(dice *game.DieSet, prevLayer, curLayer []float64) {
+	h := NewHoldValues(dice, prevLayer)
+	for i, r := range game.AllRolls() {
+		best := prevLayer[i] // hold every die
+		for m := game.HoldMask(0); m < 1<<game.NumDice-1; m++ {
+			if v := h.Value(r, m); v > best {
+				best = v
+			}
+		}
+		curLayer[i] = best
+	}
+}
+
+// numPartial is the number of partial rolls: each die shows one of the
+// faces or is still to be rolled.
+const numPartial = 7776
+
+// rolling is the digit of a die still to be rolled in a partial roll
+// index.
+const rolling = int(game.NumBerryTypes)
+
+// partialStride[i] is the place value of die i in a partial roll index.
+var partialStride = [game.NumDice]int{1, 6, 36, 216, 1296}
+
+// partialOrder lists the partial roll indexes with fewer dice to roll
+// first, each with the position of its first die to roll (-1 for none).
+var partialOrder = func() []partial {
+	var byFree [game.NumDice + 1][]partial
+	for idx := range numPartial {
+		free, first := 0, -1
+		for i := game.NumDice - 1; i >= 0; i-- {
+			if idx/partialStride[i]%(rolling+1) == rolling {
+				free++
+				first = i
+			}
+		}
+		byFree[free] = append(byFree[free], partial{idx: idx, first: first})
+	}
+	var order []partial
+	for _, ps := range byFree {
+		order = append(order, ps...)
+	}
+	return order
+}()
+
+// partial is a partial roll index and its first die to roll.
+type partial struct {
+	idx, first int
+}
+
+// partialIndex returns the index of roll r with the dice not held in m
+// still to be rolled.
+func partialIndex(r game.Roll, m game.HoldMask) int {
+	idx := 0
+	for i, b := range r {
+		digit := rolling
+		if m.Held(i) {
+			digit = int(b)
+		}
+		idx += digit * partialStride[i]
+	}
+	return idx
+}
+
+// HoldValues holds the expected value, over the dice still to be rolled,
+// of every partial roll, given the value of every full roll. It is
+// filled in one die at a time: a partial roll is worth the average over
+// the faces of its first die to roll of the partial roll with that die
+// showing the face, weighted by that die's own probabilities. That
+// costs five steps per partial roll instead of one per outcome.
+type HoldValues struct {
+	vals [numPartial]float64
+}
+
+// NewHoldValues computes the hold values for a die set whose full rolls
+// are worth values, indexed by game.RollIndex.
+func NewHoldValues(dice *game.DieSet, values []float64) *HoldValues {
+	h := &HoldValues{}
+	for _, p := range partialOrder {
+		if p.first < 0 {
+			// Full roll: its digits are the faces, read in base 6.
+			var r game.Roll
+			for i := range r {
+				r[i] = game.Berry(p.idx / partialStride[i] % (rolling + 1))
+			}
+			h.vals[p.idx] = values[game.RollIndex(r)]
+			continue
+		}
+		stride := partialStride[p.first]
+		base := p.idx - rolling*stride
+		probs := dice.Die(p.first).FaceProb()
+		val := 0.0
+		for b, q := range probs {
+			if q > 0 {
+				val += q * h.vals[base+b*stride]
+			}
+		}
+		h.vals[p.idx] = val
+	}
+	return h
+}
+
+// Value returns the expected value of holding the dice of r in m and
+// rerolling the others.
+func (h *HoldValues) Value(r game.Roll, m game.HoldMask) float64 {
+	return h.vals[partialIndex(r, m)]
+}
@@ -0,0 +1,66 @@
+package ev
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestComputeMixedMatchesCompute(t *testing.T) {
+	t.Parallel()
+
+	// Five identical dice are the count-based model over again.
+	model, err := game.NewDiceModel([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.15, 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := ComputeMixed(game.NewDieSet([game.NumDice]*game.DiceModel{model, model, model, model, model}), nil)
+	table := ComputeWith(model, nil)
+	for cs := game.CategorySet(0); cs <= game.AllCategories; cs++ {
+		if got, want := mixed.EV(cs), table.EV(cs); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("EV(%v) = %v, want %v", cs, got, want)
+		}
+	}
+}
+
+func TestComputeMixedRerollLayer(t *testing.T) {
+	t.Parallel()
+
+	golden, err := game.NewDiceModel([game.NumBerryTypes]float64{0.2, 0.2, 0.2, 0.3, 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dice, err := game.GoldenDieSet(game.DefaultModel, golden, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A value that depends on which die shows what: the points, plus a
+	// bonus for a Pest on the first die.
+	allRolls := game.AllRolls()
+	prev := make([]float64, len(allRolls))
+	for i, r := range allRolls {
+		prev[i] = float64(r.Dice().Points())
+		if r[0] == game.Pest {
+			prev[i] += 5
+		}
+	}
+	cur := make([]float64, len(allRolls))
+	ComputeMixedRerollLayer(dice, prev, cur)
+
+	// Brute force: every hold, every outcome.
+	for i, r := range allRolls {
+		want := math.Inf(-1)
+		for m := game.HoldMask(0); m < 1<<game.NumDice; m++ {
+			v := 0.0
+			for _, o := range dice.Rerolls(r, m) {
+				v += o.Prob * prev[game.RollIndex(o.Roll)]
+			}
+			want = math.Max(want, v)
+		}
+		if math.Abs(cur[i]-want) > 1e-9 {
+			t.Fatalf("value of %v = %v, want %v", r, cur[i], want)
+		}
+	}
+}
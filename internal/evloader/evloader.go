@@ -4,16 +4,29 @@
 package evloader
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
 )
 
 // Load attempts to load the EV table from path. If the file doesn't exist
 // or is invalid, it computes the table from scratch, saves it, and returns it.
 func Load(path string) (*ev.Table, error) {
-	table, err := ev.LoadJSON(path)
+	return LoadModel(path, game.DefaultModel)
+}
+
+// LoadModel is Load for dice with the face probabilities of model. Tables
+// for custom dice are kept beside the standard one, at ModelPath.
+func LoadModel(path string, model *game.DiceModel) (*ev.Table, error) {
+	path = ModelPath(path, model)
+	table, err := ev.LoadJSONWith(path, model)
 	if err == nil {
 		fmt.Println("EV table loaded from", path)
 		return table, nil
@@ -21,7 +34,7 @@ func Load(path string) (*ev.Table, error) {
 
 	fmt.Println("EV table not found, computing...")
 	start := time.Now()
-	table = ev.Compute(func(size, total int) {
+	table = ev.ComputeWith(model, func(size, total int) {
 		elapsed := time.Since(start)
 		fmt.Printf("  Completed size %d/%d  (%v elapsed)\n", size, total, elapsed.Round(time.Millisecond))
 	})
@@ -32,3 +45,215 @@ func Load(path string) (*ev.Table, error) {
 	fmt.Printf("EV table computed and saved to %s\n", path)
 	return table, nil
 }
+
+// ModelPath returns where the table for model is kept, given the path of
+// the standard table: the path itself for the standard dice, otherwise
+// the path with the model's key before the extension, e.g.
+// "ev_table-0.25-0.3-0.2-0.15-0.1.json".
+func ModelPath(path string, model *game.DiceModel) string {
+	if model.IsDefault() {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + model.Key() + ext
+}
+
+// ParseModel parses a -faces flag: empty for the standard dice, the path
+// of a JSON file ending in .json, or face weights in game.ParseFaceProbs
+// notation.
+func ParseModel(spec string) (*game.DiceModel, error) {
+	switch {
+	case spec == "":
+		return game.DefaultModel, nil
+	case strings.HasSuffix(spec, ".json"):
+		data, err := os.ReadFile(spec)
+		if err != nil {
+			return nil, err
+		}
+		model, err := game.ParseFaceProbsJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec, err)
+		}
+		return model, nil
+	default:
+		return game.ParseFaceProbs(spec)
+	}
+}
+
+// maxCached is the number of custom-dice tables a Cache holds.
+const maxCached = 16
+
+// ErrBusy is returned by Cache.Get for a table it does not hold while
+// another is being computed.
+var ErrBusy = errors.New("another dice table is being computed")
+
+// Cache holds EV tables per dice model, computing each on first use. It
+// is safe for concurrent use. Tables for custom dice are computed in
+// memory and never written to disk, one at a time; once maxCached are
+// held, the least recently used one is dropped.
+type Cache struct {
+	standard  *ev.Table       // set by NewCache only
+	tables    *lru[*ev.Table] // custom dice, by model key
+	computing chan struct{}   // holds a token while a table is computed
+}
+
+// NewCache returns a cache serving standard for the standard dice.
+func NewCache(standard *ev.Table) *Cache {
+	return &Cache{standard: standard, tables: newLRU[*ev.Table](maxCached), computing: make(chan struct{}, 1)}
+}
+
+// Get returns the EV table for model. Computing a new table takes about
+// a second, during which callers asking for the same model wait and
+// held models are served. A model that is neither held nor being
+// computed fails with ErrBusy meanwhile, so clients varying the face
+// probabilities cannot keep more than one core busy.
+func (c *Cache) Get(model *game.DiceModel) (*ev.Table, error) {
+	if model.IsDefault() {
+		return c.standard, nil
+	}
+	return c.tables.get(model.Key(), func() (*ev.Table, error) {
+		select {
+		case c.computing <- struct{}{}:
+			defer func() { <-c.computing }()
+		default:
+			return nil, ErrBusy
+		}
+		return ev.ComputeWith(model, nil), nil
+	})
+}
+
+// Put adds a table for custom dice computed or loaded elsewhere, e.g. by
+// LoadModel, so Get does not compute it again. The standard dice's table
+// is the one given to NewCache; a table for them is ignored.
+func (c *Cache) Put(t *ev.Table) {
+	if t.Model().IsDefault() {
+		return
+	}
+	c.tables.put(t.Model().Key(), t)
+}
+
+// maxBanked is the number of banked-rerolls tables a BankedCache holds.
+const maxBanked = 8
+
+// BankedCache holds banked-rerolls EV tables per bank cap and dice model,
+// computing each on first use, one at a time, as Cache does for the
+// standard game.
+type BankedCache struct {
+	tables    *lru[*ev.BankedTable] // by cap and model key
+	computing chan struct{}         // holds a token while a table is computed
+}
+
+// NewBankedCache returns an empty BankedCache.
+func NewBankedCache() *BankedCache {
+	return &BankedCache{tables: newLRU[*ev.BankedTable](maxBanked), computing: make(chan struct{}, 1)}
+}
+
+// Get returns the banked EV table for bankCap and model. Computing a new
+// table takes a few seconds, during which callers asking for the same
+// table wait and others not held fail with ErrBusy. It fails if bankCap
+// is out of range.
+func (c *BankedCache) Get(model *game.DiceModel, bankCap int) (*ev.BankedTable, error) {
+	return c.tables.get(fmt.Sprintf("%d/%s", bankCap, model.Key()), func() (*ev.BankedTable, error) {
+		select {
+		case c.computing <- struct{}{}:
+			defer func() { <-c.computing }()
+		default:
+			return nil, ErrBusy
+		}
+		return ev.ComputeBanked(model, bankCap, nil)
+	})
+}
+
+// lru holds up to max values by key, dropping the least recently used.
+// Each value is computed once, outside the lock, so a slow computation
+// holds up only the callers asking for the same key.
+type lru[T any] struct {
+	max   int
+	mu    sync.Mutex
+	items map[string]*lruEntry[T]
+	order []string // keys, least recently used first
+}
+
+// lruEntry is a value of an lru, computed by the first caller to ask.
+type lruEntry[T any] struct {
+	once sync.Once
+	val  T
+	err  error
+}
+
+func newLRU[T any](max int) *lru[T] {
+	return &lru[T]{max: max, items: make(map[string]*lruEntry[T])}
+}
+
+// get returns the value for key, calling compute for it if it is not
+// held. A failed computation is not kept, so a later call retries.
+func (c *lru[T]) get(key string, compute func() (T, error)) (T, error) {
+	c.mu.Lock()
+	e, ok := c.items[key]
+	if ok {
+		c.touch(key)
+	} else {
+		e = &lruEntry[T]{}
+		c.add(key, e)
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() {
+		e.val, e.err = compute()
+	})
+	if e.err != nil {
+		c.mu.Lock()
+		if c.items[key] == e {
+			c.remove(key)
+		}
+		c.mu.Unlock()
+	}
+	return e.val, e.err
+}
+
+// put stores v under key, replacing any value held.
+func (c *lru[T]) put(key string, v T) {
+	e := &lruEntry[T]{val: v}
+	e.once.Do(func() {})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		c.items[key] = e
+		c.touch(key)
+		return
+	}
+	c.add(key, e)
+}
+
+// add stores e under key, dropping the least recently used entry if the
+// cache is full. c.mu must be held.
+func (c *lru[T]) add(key string, e *lruEntry[T]) {
+	if len(c.order) == c.max {
+		delete(c.items, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.items[key] = e
+	c.order = append(c.order, key)
+}
+
+// touch marks key as the most recently used. c.mu must be held.
+func (c *lru[T]) touch(key string) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(append(c.order[:i:i], c.order[i+1:]...), key)
+			return
+		}
+	}
+}
+
+// remove drops key. c.mu must be held.
+func (c *lru[T]) remove(key string) {
+	delete(c.items, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package evloader
 
 import (
+	"errors"
 	"os"
 	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
 )
 
 func TestLoadExisting(t *testing.T) {
@@ -71,3 +75,192 @@ func TestLoadInvalidFile(t *testing.T) {
 		t.Fatal("Load() returned nil table")
 	}
 }
+
+func TestModelPath(t *testing.T) {
+	t.Parallel()
+
+	model, err := game.NewDiceModel([game.NumBerryTypes]float64{0.25, 0.3, 0.2, 0.15, 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		model *game.DiceModel
+		want  string
+	}{
+		{"ev_table.json", game.DefaultModel, "ev_table.json"},
+		{"ev_table.json", model, "ev_table-0.25-0.3-0.2-0.15-0.1.json"},
+		{"data/ev", model, "data/ev-0.25-0.3-0.2-0.15-0.1"},
+	}
+	for _, tt := range tests {
+		if got := ModelPath(tt.path, tt.model); got != tt.want {
+			t.Errorf("ModelPath(%q, %v) = %q, want %q", tt.path, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestLoadModel(t *testing.T) {
+	t.Parallel()
+
+	model, err := ParseModel("3,3,2,2,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/ev_table.json"
+	table, err := LoadModel(path, model)
+	if err != nil {
+		t.Fatalf("LoadModel() error = %v", err)
+	}
+	if table.Model() != model {
+		t.Errorf("table.Model() = %v, want %v", table.Model(), model)
+	}
+
+	// The table is saved beside the standard path and loads back.
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("custom table written to the standard path %q", path)
+	}
+	again, err := LoadModel(path, model)
+	if err != nil {
+		t.Fatalf("LoadModel() again error = %v", err)
+	}
+	if again.EV(game.AllCategories) != table.EV(game.AllCategories) || again.Model().Key() != model.Key() {
+		t.Errorf("reloaded table differs: EV %v, model %v", again.EV(game.AllCategories), again.Model())
+	}
+}
+
+func TestParseModelFile(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/dice.json"
+	if err := os.WriteFile(path, []byte(`{"J": 3, "S": 3, "P": 2, "M": 1, "X": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := ParseModel(path)
+	if err != nil {
+		t.Fatalf("ParseModel(%q) error = %v", path, err)
+	}
+	if !model.IsDefault() {
+		t.Errorf("ParseModel(%q) = %v, want the standard dice", path, model)
+	}
+	if _, err := ParseModel(t.TempDir() + "/missing.json"); err == nil {
+		t.Error("ParseModel(missing file) succeeded")
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	standard := ev.NewTable()
+	c := NewCache(standard)
+	if got, _ := c.Get(game.DefaultModel); got != standard {
+		t.Error("Get(DefaultModel) is not the standard table")
+	}
+
+	a, _ := ParseModel("1,1,1,1,1")
+	b, _ := ParseModel("0.2 0.2 0.2 0.2 0.2") // same probabilities as a
+	ta, err := c.Get(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ta.Model() != a || ta.EV(game.AllCategories) <= 0 {
+		t.Errorf("Get(a) = table for %v with EV %v", ta.Model(), ta.EV(game.AllCategories))
+	}
+	if got, _ := c.Get(b); got != ta {
+		t.Error("Get() computed a second table for equal probabilities")
+	}
+
+	other, _ := ParseModel("2,2,2,2,1")
+	loaded := ev.ComputeWith(other, nil)
+	c.Put(loaded)
+	if got, _ := c.Get(other); got != loaded {
+		t.Error("Get() after Put() did not return the put table")
+	}
+	c.Put(ev.NewTable())
+	if got, _ := c.Get(game.DefaultModel); got != standard {
+		t.Error("Put() replaced the standard table")
+	}
+
+	// While a table is being computed, held tables are served and new
+	// ones are refused rather than computed alongside it.
+	c.computing <- struct{}{}
+	third, _ := ParseModel("3,2,2,2,1")
+	if _, err := c.Get(third); !errors.Is(err, ErrBusy) {
+		t.Errorf("Get(new model) while computing: error = %v, want ErrBusy", err)
+	}
+	if got, err := c.Get(a); got != ta || err != nil {
+		t.Errorf("Get(held model) while computing = %p, %v; want the held table", got, err)
+	}
+	<-c.computing
+	if got, err := c.Get(third); err != nil || got.Model() != third {
+		t.Errorf("Get(new model) afterwards failed: %v", err)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	t.Parallel()
+
+	// A table being computed holds up only the callers asking for it.
+	c := newLRU[int](2)
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan int)
+	go func() {
+		v, _ := c.get("slow", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- v
+	}()
+	<-started
+	if v, _ := c.get("fast", func() (int, error) { return 2, nil }); v != 2 {
+		t.Errorf("get(fast) = %d, want 2", v)
+	}
+	close(release)
+	if v := <-done; v != 1 {
+		t.Errorf("get(slow) = %d, want 1", v)
+	}
+	calls := 0
+	if v, _ := c.get("slow", func() (int, error) { calls++; return 3, nil }); v != 1 || calls != 0 {
+		t.Errorf("get(slow) again = %d after %d computations, want the held 1", v, calls)
+	}
+
+	// The least recently used key goes first; a failure is not kept.
+	c.get("third", func() (int, error) { return 3, nil })
+	if _, ok := c.items["fast"]; ok {
+		t.Error("least recently used key kept")
+	}
+	if _, err := c.get("bad", func() (int, error) { return 0, errors.New("fail") }); err == nil {
+		t.Error("get(bad) succeeded")
+	}
+	if _, ok := c.items["bad"]; ok || len(c.order) != len(c.items) {
+		t.Errorf("failed key kept: order %v", c.order)
+	}
+}
+
+func TestBankedCache(t *testing.T) {
+	t.Parallel()
+
+	c := NewBankedCache()
+	if _, err := c.Get(game.DefaultModel, ev.MaxBankCap+1); err == nil {
+		t.Error("Get(cap too large) succeeded")
+	}
+	t1, err := c.Get(game.DefaultModel, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t1.Cap() != 1 || t1.Model() != game.DefaultModel {
+		t.Errorf("Get(cap 1) = table with cap %d", t1.Cap())
+	}
+	if t2, _ := c.Get(game.DefaultModel, 1); t2 != t1 {
+		t.Error("Get() computed a second table for the same cap")
+	}
+
+	c.computing <- struct{}{}
+	if _, err := c.Get(game.DefaultModel, 2); !errors.Is(err, ErrBusy) {
+		t.Errorf("Get(cap 2) while computing: error = %v, want ErrBusy", err)
+	}
+	if t2, err := c.Get(game.DefaultModel, 1); t2 != t1 || err != nil {
+		t.Errorf("Get(cap 1) while computing = %p, %v; want the held table", t2, err)
+	}
+	<-c.computing
+}
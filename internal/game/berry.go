@@ -1,6 +1,9 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Berry represents a face on one of the 5 dice.
 type Berry uint8
@@ -38,6 +41,52 @@ func (b Berry) String() string {
 	return fmt.Sprintf("Berry(%d)", b)
 }
 
+var berryLetters = [NumBerryTypes]byte{
+	Jumbleberry: 'J',
+	Sugarberry:  'S',
+	Pickleberry: 'P',
+	Moonberry:   'M',
+	Pest:        'X',
+}
+
+// Letter returns the single-letter notation for the berry (J, S, P, M, X).
+func (b Berry) Letter() byte {
+	if b < NumBerryTypes {
+		return berryLetters[b]
+	}
+	return '?'
+}
+
+// ParseBerry returns the berry for a single letter (case-insensitive).
+// It reports false if c is not one of J, S, P, M or X.
+func ParseBerry(c byte) (Berry, bool) {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	for b, l := range berryLetters {
+		if l == c {
+			return Berry(b), true
+		}
+	}
+	return 0, false
+}
+
+// ParseFace parses a face by letter or name, in any case, e.g. "M",
+// "m" or "moonberry".
+func ParseFace(name string) (Berry, error) {
+	if len(name) == 1 {
+		if b, ok := ParseBerry(name[0]); ok {
+			return b, nil
+		}
+	}
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		if strings.EqualFold(name, b.String()) {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown face %q (use J, S, P, M, or X)", name)
+}
+
 // NumDice is the number of dice rolled each turn.
 const NumDice = 5
 
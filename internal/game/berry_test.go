@@ -221,3 +221,49 @@ func TestBerryPoints(t *testing.T) {
 		})
 	}
 }
+
+func TestBerryLetter(t *testing.T) {
+	t.Parallel()
+
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		got, ok := ParseBerry(b.Letter())
+		if !ok || got != b {
+			t.Errorf("ParseBerry(%q) = %v, %v; want %v", b.Letter(), got, ok, b)
+		}
+		lower := b.Letter() + ('a' - 'A')
+		if got, ok := ParseBerry(lower); !ok || got != b {
+			t.Errorf("ParseBerry(%q) = %v, %v; want %v", lower, got, ok, b)
+		}
+	}
+
+	if _, ok := ParseBerry('Q'); ok {
+		t.Error("ParseBerry('Q') reported ok")
+	}
+	if got := Berry(99).Letter(); got != '?' {
+		t.Errorf("Berry(99).Letter() = %q, want '?'", got)
+	}
+}
+
+func TestParseFace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    Berry
+		wantErr bool
+	}{
+		{"M", Moonberry, false},
+		{"x", Pest, false},
+		{"Jumbleberry", Jumbleberry, false},
+		{"sugarBERRY", Sugarberry, false},
+		{"Q", 0, true},
+		{"Moon", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFace(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFace(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -37,6 +37,38 @@ func (c Category) String() string {
 	return fmt.Sprintf("Category(%d)", c)
 }
 
+var categoryCodes = [NumCategories]string{
+	CatJumbleberry:   "j",
+	CatSugarberry:    "s",
+	CatPickleberry:   "p",
+	CatMoonberry:     "m",
+	CatBasketOfThree: "3k",
+	CatBasketOfFour:  "4k",
+	CatBasketOfFive:  "5k",
+	CatMixedBasket:   "mix",
+	CatFreeRoll:      "fr",
+}
+
+// Code returns the short notation for the category (j, s, p, m, 3k, 4k,
+// 5k, mix, fr), as used in scorecards and game records.
+func (c Category) Code() string {
+	if c < NumCategories {
+		return categoryCodes[c]
+	}
+	return "?"
+}
+
+// ParseCategoryCode returns the category for a short code (see Code).
+// Matching is exact; it reports false for any other string.
+func ParseCategoryCode(code string) (Category, bool) {
+	for c, s := range categoryCodes {
+		if s == code {
+			return Category(c), true
+		}
+	}
+	return 0, false
+}
+
 // CategorySet is a bitmask representing a set of categories.
 // Bit i is set if category i is in the set.
 type CategorySet uint16
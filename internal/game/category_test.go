@@ -274,3 +274,20 @@ func TestAllCategories(t *testing.T) {
 		}
 	}
 }
+
+func TestCategoryCode(t *testing.T) {
+	t.Parallel()
+
+	for c := Category(0); c < NumCategories; c++ {
+		got, ok := ParseCategoryCode(c.Code())
+		if !ok || got != c {
+			t.Errorf("ParseCategoryCode(%q) = %v, %v; want %v", c.Code(), got, ok, c)
+		}
+	}
+	if _, ok := ParseCategoryCode("3K"); ok {
+		t.Error(`ParseCategoryCode("3K") reported ok; matching is exact`)
+	}
+	if got := Category(99).Code(); got != "?" {
+		t.Errorf("Category(99).Code() = %q, want %q", got, "?")
+	}
+}
@@ -1,6 +1,14 @@
 package game
 
-import "math"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
 
 // FaceProb holds the probability of each face appearing on a single die.
 // Each die has 10 faces: 3 Jumbleberry, 3 Sugarberry, 2 Pickleberry, 1 Moonberry, 1 Pest.
@@ -15,21 +23,270 @@ var FaceProb = [NumBerryTypes]float64{
 // factorials[n] = n! for n in 0..5.
 var factorials = [NumDice + 1]float64{1, 1, 2, 6, 24, 120}
 
+// DiceModel holds the face probabilities of a set of identical dice and
+// the outcome probabilities derived from them. DefaultModel is built from
+// FaceProb; NewDiceModel builds one for a biased or house-made set.
+type DiceModel struct {
+	faceProb  [NumBerryTypes]float64
+	firstRoll [126]float64
+	rerolls   [NumDice + 1][]RerollOutcome
+}
+
+// DefaultModel is the DiceModel of FaceProb.
+var DefaultModel *DiceModel
+
+// probTolerance is how far face probabilities may sum from 1.
+const probTolerance = 1e-9
+
+// NewDiceModel returns the model of dice with the given face
+// probabilities, which must be non-negative and sum to 1.
+func NewDiceModel(faceProb [NumBerryTypes]float64) (*DiceModel, error) {
+	sum := 0.0
+	for b, p := range faceProb {
+		if p < 0 || math.IsNaN(p) {
+			return nil, fmt.Errorf("probability of %s is %v", Berry(b), p)
+		}
+		sum += p
+	}
+	if math.Abs(sum-1) > probTolerance {
+		return nil, fmt.Errorf("face probabilities sum to %v, not 1", sum)
+	}
+
+	m := &DiceModel{faceProb: faceProb}
+	for i, d := range allDiceCache {
+		m.firstRoll[i] = m.DiceProb(d, NumDice)
+	}
+	for n := 0; n <= NumDice; n++ {
+		m.rerolls[n] = m.enumerateRerolls(n)
+	}
+	return m, nil
+}
+
+// FaceProb returns the probability of each face.
+func (m *DiceModel) FaceProb() [NumBerryTypes]float64 {
+	return m.faceProb
+}
+
+// IsDefault reports whether m has the standard face probabilities.
+func (m *DiceModel) IsDefault() bool {
+	return m.faceProb == DefaultModel.faceProb
+}
+
+// Key returns the face probabilities in face order joined by "-", e.g.
+// "0.3-0.3-0.2-0.1-0.1". Models with equal probabilities have equal keys,
+// so it names cached tables.
+func (m *DiceModel) Key() string {
+	parts := make([]string, NumBerryTypes)
+	for b, p := range m.faceProb {
+		parts[b] = strconv.FormatFloat(p, 'g', -1, 64)
+	}
+	return strings.Join(parts, "-")
+}
+
+// String returns the face probabilities as FormatFaceProbs does.
+func (m *DiceModel) String() string {
+	return FormatFaceProbs(m.faceProb)
+}
+
+// FormatFaceProbs returns face probabilities as percentages, e.g.
+// "J 30% S 30% P 20% M 10% X 10%".
+func FormatFaceProbs(faceProb [NumBerryTypes]float64) string {
+	parts := make([]string, NumBerryTypes)
+	for b, p := range faceProb {
+		parts[b] = fmt.Sprintf("%c %s%%", Berry(b).Letter(), FormatPercent(p))
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatPercent returns a probability as a percentage to two decimal
+// places, without trailing zeros or the percent sign, e.g. "12.5".
+func FormatPercent(p float64) string {
+	return strconv.FormatFloat(math.Round(p*1e4)/100, 'f', -1, 64)
+}
+
+// ParseFaceProbs parses the face probabilities of a die set into a dice
+// model. Weights are normalized, so counts of faces work as well as
+// probabilities.
+//
+// Supported formats:
+//   - Positional: "3,3,2,1,1" or "0.3 0.3 0.2 0.1 0.1" (J, S, P, M, X order)
+//   - Named: "J=3 S=3 P=2 M=1 X=1" (letters or names; omitted faces are 0)
+//   - "default" for the standard dice
+func ParseFaceProbs(input string) (*DiceModel, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("empty face probabilities")
+	}
+	if strings.EqualFold(input, "default") {
+		return DefaultModel, nil
+	}
+
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	var weights [NumBerryTypes]float64
+	if strings.Contains(input, "=") {
+		seen := make(map[Berry]bool)
+		for _, f := range fields {
+			name, value, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid face weight %q (expected format like 'M=0.1')", f)
+			}
+			b, err := ParseFace(name)
+			if err != nil {
+				return nil, err
+			}
+			if seen[b] {
+				return nil, fmt.Errorf("face %s given twice", b)
+			}
+			seen[b] = true
+			if weights[b], err = parseWeight(value); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if len(fields) != int(NumBerryTypes) {
+			return nil, fmt.Errorf("need %d face weights (J, S, P, M, X), got %d", NumBerryTypes, len(fields))
+		}
+		for b, f := range fields {
+			var err error
+			if weights[b], err = parseWeight(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return normalizeFaces(weights)
+}
+
+// ParseFaceProbsJSON decodes face probabilities from a JSON request field
+// or file: a string in ParseFaceProbs notation, an array of five weights
+// in face order, or an object of weights keyed by face letter or name.
+func ParseFaceProbsJSON(data []byte) (*DiceModel, error) {
+	var notation string
+	if err := json.Unmarshal(data, &notation); err == nil {
+		return ParseFaceProbs(notation)
+	}
+	var list []float64
+	if err := json.Unmarshal(data, &list); err == nil {
+		if len(list) != int(NumBerryTypes) {
+			return nil, fmt.Errorf("need %d face weights (J, S, P, M, X), got %d", NumBerryTypes, len(list))
+		}
+		var weights [NumBerryTypes]float64
+		copy(weights[:], list)
+		return normalizeFaces(weights)
+	}
+	var named map[string]float64
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("face probabilities must be a string, an array or an object")
+	}
+	// The map checks the shape; read the keys again in order, since it
+	// keeps only the last of two keys naming the same face, such as "J"
+	// and "Jumbleberry".
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.Token() // {
+	var weights [NumBerryTypes]float64
+	var seen [NumBerryTypes]bool
+	for dec.More() {
+		tok, _ := dec.Token()
+		var w float64
+		if err := dec.Decode(&w); err != nil {
+			return nil, err
+		}
+		b, err := ParseFace(tok.(string))
+		if err != nil {
+			return nil, err
+		}
+		if seen[b] {
+			return nil, fmt.Errorf("face %s given twice", b)
+		}
+		seen[b] = true
+		weights[b] = w
+	}
+	return normalizeFaces(weights)
+}
+
+// parseWeight parses one face weight.
+func parseWeight(s string) (float64, error) {
+	w, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid face weight %q", s)
+	}
+	return w, nil
+}
+
+// normalizeFaces scales non-negative weights to sum to 1 and returns
+// their dice model.
+func normalizeFaces(weights [NumBerryTypes]float64) (*DiceModel, error) {
+	sum := 0.0
+	for b, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weight of %s must be a non-negative number, got %v", Berry(b), w)
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("face weights are all zero")
+	}
+	standard := true
+	for b := range weights {
+		weights[b] /= sum
+		standard = standard && math.Abs(weights[b]-FaceProb[b]) < 1e-12
+	}
+	if standard {
+		return DefaultModel, nil // share the standard dice's tables
+	}
+	return NewDiceModel(weights)
+}
+
+// faceFromUniform maps u in [0, 1) to a face using the cumulative face
+// probabilities.
+func (m *DiceModel) faceFromUniform(u float64) Berry {
+	cumulative := 0.0
+	last := Pest
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		if m.faceProb[b] == 0 {
+			continue
+		}
+		last = b
+		cumulative += m.faceProb[b]
+		if u < cumulative {
+			return b
+		}
+	}
+	return last // rounding safety
+}
+
 // DiceProb returns the multinomial probability of rolling dice outcome d
 // when rolling n dice.
 //
-// P(d | n) = n! / (d[0]!*d[1]!*...*d[4]!) * prod(FaceProb[i]^d[i])
-func DiceProb(d Dice, n int) float64 {
+// P(d | n) = n! / (d[0]!*d[1]!*...*d[4]!) * prod(p[i]^d[i])
+func (m *DiceModel) DiceProb(d Dice, n int) float64 {
 	prob := factorials[n]
 	for b := Berry(0); b < NumBerryTypes; b++ {
 		prob /= factorials[d[b]]
 		if d[b] > 0 {
-			prob *= math.Pow(FaceProb[b], float64(d[b]))
+			prob *= math.Pow(m.faceProb[b], float64(d[b]))
 		}
 	}
 	return prob
 }
 
+// Rerolls returns precomputed outcomes for rolling n dice.
+func (m *DiceModel) Rerolls(n int) []RerollOutcome {
+	return m.rerolls[n]
+}
+
+// FirstRollProb returns P(AllDice()[idx]) for the initial roll of all 5 dice.
+func (m *DiceModel) FirstRollProb(idx int) float64 {
+	return m.firstRoll[idx]
+}
+
+// DiceProb returns the multinomial probability of rolling dice outcome d
+// when rolling n dice under DefaultModel.
+func DiceProb(d Dice, n int) float64 {
+	return DefaultModel.DiceProb(d, n)
+}
+
 // RerollOutcome pairs a dice count tuple with its probability.
 type RerollOutcome struct {
 	Dice Dice
@@ -43,12 +300,6 @@ var allDiceCache []Dice
 // Pack space is 6^5 = 7776 (each count 0..5, base-6 encoding).
 var diceToIndex [7776]uint16
 
-// firstRollProb[i] = P(allDiceCache[i]) when rolling all 5 dice.
-var firstRollProb [126]float64
-
-// rerollCache[n] holds precomputed outcomes for rolling n dice (0 <= n <= 5).
-var rerollCache [NumDice + 1][]RerollOutcome
-
 func init() {
 	allDiceCache = EnumerateAllDice()
 
@@ -56,13 +307,11 @@ func init() {
 		diceToIndex[packDice(d)] = uint16(i)
 	}
 
-	for i, d := range allDiceCache {
-		firstRollProb[i] = DiceProb(d, NumDice)
-	}
-
-	for n := 0; n <= NumDice; n++ {
-		rerollCache[n] = enumerateRerolls(n)
+	m, err := NewDiceModel(FaceProb)
+	if err != nil {
+		panic(err)
 	}
+	DefaultModel = m
 }
 
 // packDice encodes a Dice into a unique integer via base-6 encoding.
@@ -85,14 +334,16 @@ func NumAllDice() int {
 	return len(allDiceCache)
 }
 
-// Rerolls returns precomputed outcomes for rolling n dice.
+// Rerolls returns precomputed outcomes for rolling n dice under
+// DefaultModel.
 func Rerolls(n int) []RerollOutcome {
-	return rerollCache[n]
+	return DefaultModel.Rerolls(n)
 }
 
-// FirstRollProb returns P(AllDice()[idx]) for the initial roll of all 5 dice.
+// FirstRollProb returns P(AllDice()[idx]) for the initial roll of all 5
+// dice under DefaultModel.
 func FirstRollProb(idx int) float64 {
-	return firstRollProb[idx]
+	return DefaultModel.FirstRollProb(idx)
 }
 
 // AddDice returns the component-wise sum of two Dice.
@@ -106,7 +357,7 @@ func AddDice(a, b Dice) Dice {
 
 // enumerateRerolls generates all possible outcomes when rolling n dice,
 // each paired with its multinomial probability.
-func enumerateRerolls(n int) []RerollOutcome {
+func (m *DiceModel) enumerateRerolls(n int) []RerollOutcome {
 	if n == 0 {
 		return []RerollOutcome{{Dice: Dice{}, Prob: 1.0}}
 	}
@@ -117,7 +368,7 @@ func enumerateRerolls(n int) []RerollOutcome {
 			current[berry] = remaining
 			result = append(result, RerollOutcome{
 				Dice: current,
-				Prob: DiceProb(current, n),
+				Prob: m.DiceProb(current, n),
 			})
 			return
 		}
@@ -233,3 +233,156 @@ func TestFaceProb(t *testing.T) {
 		}
 	})
 }
+
+func TestNewDiceModel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		probs   [NumBerryTypes]float64
+		wantErr bool
+	}{
+		{"default", FaceProb, false},
+		{"uniform", [NumBerryTypes]float64{0.2, 0.2, 0.2, 0.2, 0.2}, false},
+		{"no pests", [NumBerryTypes]float64{0.4, 0.3, 0.2, 0.1, 0}, false},
+		{"negative", [NumBerryTypes]float64{0.5, 0.3, 0.2, 0.1, -0.1}, true},
+		{"sum below one", [NumBerryTypes]float64{0.3, 0.3, 0.2, 0.1, 0.05}, true},
+		{"NaN", [NumBerryTypes]float64{math.NaN(), 0.3, 0.3, 0.2, 0.2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := NewDiceModel(tt.probs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDiceModel(%v) error = %v, wantErr %v", tt.probs, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			sum := 0.0
+			for i := range AllDice() {
+				sum += m.FirstRollProb(i)
+			}
+			if math.Abs(sum-1) > 1e-10 {
+				t.Errorf("FirstRollProb sum = %v, want 1", sum)
+			}
+			for n := 0; n <= NumDice; n++ {
+				sum := 0.0
+				for _, ro := range m.Rerolls(n) {
+					sum += ro.Prob
+				}
+				if math.Abs(sum-1) > 1e-10 {
+					t.Errorf("Rerolls(%d) prob sum = %v, want 1", n, sum)
+				}
+			}
+		})
+	}
+}
+
+func TestDiceModelProb(t *testing.T) {
+	t.Parallel()
+
+	// A die that shows a Moonberry 40% of the time and a Pest otherwise.
+	m, err := NewDiceModel([NumBerryTypes]float64{Moonberry: 0.4, Pest: 0.6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.FirstRollProb(DiceIndex(Dice{Moonberry: 2, Pest: 3})), 10*0.4*0.4*0.6*0.6*0.6; math.Abs(got-want) > 1e-12 {
+		t.Errorf("P(MMXXX) = %v, want %v", got, want)
+	}
+	if got := m.DiceProb(Dice{Jumbleberry: 1}, 1); got != 0 {
+		t.Errorf("P(J) = %v, want 0", got)
+	}
+	if m.FaceProb() != [NumBerryTypes]float64{Moonberry: 0.4, Pest: 0.6} {
+		t.Errorf("FaceProb() = %v", m.FaceProb())
+	}
+	if DefaultModel.FaceProb() != FaceProb {
+		t.Errorf("DefaultModel.FaceProb() = %v, want %v", DefaultModel.FaceProb(), FaceProb)
+	}
+}
+
+func TestParseFaceProbs(t *testing.T) {
+	t.Parallel()
+
+	type probs = [NumBerryTypes]float64
+	tests := []struct {
+		input   string
+		want    probs
+		wantErr bool
+	}{
+		{"3,3,2,1,1", FaceProb, false},
+		{"0.3 0.3 0.2 0.1 0.1", FaceProb, false},
+		{"default", FaceProb, false},
+		{"J=3 S=3 P=2 M=1 X=1", FaceProb, false},
+		{"moonberry=1, pest=1", probs{Moonberry: 0.5, Pest: 0.5}, false},
+		{"1 1 1 1", probs{}, true},
+		{"J=1 J=2", probs{}, true},
+		{"Q=1", probs{}, true},
+		{"0,0,0,0,0", probs{}, true},
+		{"1,1,1,1,-1", probs{}, true},
+		{"M=a", probs{}, true},
+		{"", probs{}, true},
+	}
+	for _, tt := range tests {
+		m, err := ParseFaceProbs(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFaceProbs(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		for b, p := range m.FaceProb() {
+			if math.Abs(p-tt.want[b]) > 1e-12 {
+				t.Errorf("ParseFaceProbs(%q) = %v, want %v", tt.input, m.FaceProb(), tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseFaceProbsJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{`"3,3,2,1,1"`, false},
+		{`[3, 3, 2, 1, 1]`, false},
+		{`{"J": 0.3, "sugarberry": 0.3, "P": 0.2, "M": 0.1, "X": 0.1}`, false},
+		{`[3, 3, 2, 1]`, true},
+		{`{"Z": 1}`, true},
+		{`{"J": 1, "Jumbleberry": 3, "S": 3, "P": 2, "M": 1, "X": 1}`, true},
+		{`{"m": 1, "M": 1, "J": 3, "S": 3, "P": 2, "X": 1}`, true},
+		{`true`, true},
+	}
+	for _, tt := range tests {
+		m, err := ParseFaceProbsJSON([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFaceProbsJSON(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !m.IsDefault() {
+			t.Errorf("ParseFaceProbsJSON(%s) = %v, want the standard dice", tt.input, m)
+		}
+	}
+}
+
+func TestFormatFaceProbs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		probs [NumBerryTypes]float64
+		want  string
+	}{
+		{FaceProb, "J 30% S 30% P 20% M 10% X 10%"},
+		{[NumBerryTypes]float64{0.125, 0.375, 0.2, 0.3, 0}, "J 12.5% S 37.5% P 20% M 30% X 0%"},
+		{[NumBerryTypes]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, "J 33.33% S 33.33% P 33.33% M 0% X 0%"},
+	}
+	for _, tt := range tests {
+		if got := FormatFaceProbs(tt.probs); got != tt.want {
+			t.Errorf("FormatFaceProbs(%v) = %q, want %q", tt.probs, got, tt.want)
+		}
+	}
+}
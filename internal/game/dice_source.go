@@ -0,0 +1,145 @@
+package game
+
+import (
+	"bufio"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+)
+
+// DiceSource produces die faces for an Engine.
+type DiceSource interface {
+	// Roll returns the faces of n freshly rolled dice.
+	Roll(n int) ([]Berry, error)
+}
+
+// faceFromUniform maps u in [0, 1) to a face using the cumulative
+// FaceProb distribution.
+func faceFromUniform(u float64) Berry {
+	return DefaultModel.faceFromUniform(u)
+}
+
+// RandSource rolls dice using a math/rand/v2 generator.
+type RandSource struct {
+	rng   *rand.Rand
+	model *DiceModel
+}
+
+// NewRandSource returns a DiceSource drawing from rng.
+func NewRandSource(rng *rand.Rand) *RandSource {
+	return NewModelSource(rng, DefaultModel)
+}
+
+// NewModelSource returns a DiceSource drawing from rng with the face
+// probabilities of model, for simulating a biased or house-made set.
+func NewModelSource(rng *rand.Rand, model *DiceModel) *RandSource {
+	return &RandSource{rng: rng, model: model}
+}
+
+// NewPCGSource returns a reproducible DiceSource seeded with seed.
+func NewPCGSource(seed uint64) *RandSource {
+	return NewRandSource(rand.New(rand.NewPCG(seed, 0)))
+}
+
+// Roll returns n faces, one uniform draw per die. It never fails.
+func (s *RandSource) Roll(n int) ([]Berry, error) {
+	faces := make([]Berry, n)
+	for i := range faces {
+		faces[i] = s.model.faceFromUniform(s.rng.Float64())
+	}
+	return faces, nil
+}
+
+// CryptoSource rolls dice using the operating system's cryptographic
+// random number generator, for games where rolls must be unpredictable.
+type CryptoSource struct{}
+
+// Roll returns n faces drawn from crypto/rand.
+func (CryptoSource) Roll(n int) ([]Berry, error) {
+	faces := make([]Berry, n)
+	var buf [8]byte
+	for i := range faces {
+		if _, err := crand.Read(buf[:]); err != nil {
+			return nil, fmt.Errorf("crypto source: %w", err)
+		}
+		// Use the top 53 bits for a uniform float64 in [0, 1).
+		u := float64(binary.LittleEndian.Uint64(buf[:])>>11) / (1 << 53)
+		faces[i] = faceFromUniform(u)
+	}
+	return faces, nil
+}
+
+// ErrReplayExhausted is returned by a ReplaySource that has no faces left.
+var ErrReplayExhausted = errors.New("replay source exhausted")
+
+// ReplaySource replays a recorded sequence of faces in order.
+type ReplaySource struct {
+	faces []Berry
+	pos   int
+}
+
+// NewReplaySource returns a DiceSource that replays faces in order.
+func NewReplaySource(faces []Berry) *ReplaySource {
+	return &ReplaySource{faces: faces}
+}
+
+// LoadReplaySource reads recorded faces from a file. See ReadReplaySource
+// for the format.
+func LoadReplaySource(path string) (*ReplaySource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadReplaySource(f)
+}
+
+// ReadReplaySource reads recorded faces as berry letters (J, S, P, M, X),
+// one per die in the order rolled. Whitespace is ignored and '#' starts a
+// comment that runs to the end of the line.
+func ReadReplaySource(r io.Reader) (*ReplaySource, error) {
+	var faces []Berry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			if c == '#' {
+				break
+			}
+			if c == ' ' || c == '\t' || c == '\r' {
+				continue
+			}
+			b, ok := ParseBerry(c)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown die face %q (use J, S, P, M, or X)", line, string(c))
+			}
+			faces = append(faces, b)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewReplaySource(faces), nil
+}
+
+// Roll returns the next n recorded faces.
+func (s *ReplaySource) Roll(n int) ([]Berry, error) {
+	if s.pos+n > len(s.faces) {
+		return nil, fmt.Errorf("rolling %d dice after %d faces: %w", n, s.pos, ErrReplayExhausted)
+	}
+	faces := append([]Berry(nil), s.faces[s.pos:s.pos+n]...)
+	s.pos += n
+	return faces, nil
+}
+
+// Remaining returns the number of recorded faces not yet rolled.
+func (s *ReplaySource) Remaining() int {
+	return len(s.faces) - s.pos
+}
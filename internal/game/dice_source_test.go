@@ -0,0 +1,161 @@
+package game
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestFaceFromUniform(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		u    float64
+		want Berry
+	}{
+		{0.0, Jumbleberry},
+		{0.29, Jumbleberry},
+		{0.31, Sugarberry},
+		{0.61, Pickleberry},
+		{0.85, Moonberry},
+		{0.95, Pest},
+		{0.999999, Pest},
+	}
+
+	for _, tt := range tests {
+		if got := faceFromUniform(tt.u); got != tt.want {
+			t.Errorf("faceFromUniform(%v) = %v, want %v", tt.u, got, tt.want)
+		}
+	}
+}
+
+func TestModelSource(t *testing.T) {
+	t.Parallel()
+
+	// No Jumbleberries or Pests: the draws cover only S, P and M.
+	model, err := NewDiceModel([NumBerryTypes]float64{Sugarberry: 0.5, Pickleberry: 0.25, Moonberry: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		u    float64
+		want Berry
+	}{{0, Sugarberry}, {0.6, Pickleberry}, {0.8, Moonberry}, {0.999999, Moonberry}} {
+		if got := model.faceFromUniform(tt.u); got != tt.want {
+			t.Errorf("faceFromUniform(%v) = %v, want %v", tt.u, got, tt.want)
+		}
+	}
+
+	const n = 100000
+	faces, err := NewModelSource(rand.New(rand.NewPCG(3, 0)), model).Roll(n)
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	var counts [NumBerryTypes]int
+	for _, f := range faces {
+		counts[f]++
+	}
+	for b, p := range model.FaceProb() {
+		if got := float64(counts[b]) / n; math.Abs(got-p) > 0.01 {
+			t.Errorf("frequency of %v = %.4f, want about %.2f", Berry(b), got, p)
+		}
+	}
+}
+
+func TestDiceModelKey(t *testing.T) {
+	t.Parallel()
+
+	if got, want := DefaultModel.Key(), "0.3-0.3-0.2-0.1-0.1"; got != want {
+		t.Errorf("DefaultModel.Key() = %q, want %q", got, want)
+	}
+	if got, want := DefaultModel.String(), "J 30% S 30% P 20% M 10% X 10%"; got != want {
+		t.Errorf("DefaultModel.String() = %q, want %q", got, want)
+	}
+	m, _ := NewDiceModel(FaceProb)
+	if !m.IsDefault() || m.Key() != DefaultModel.Key() {
+		t.Errorf("model of FaceProb is not the default")
+	}
+}
+
+func TestPCGSourceReproducible(t *testing.T) {
+	t.Parallel()
+
+	a, _ := NewPCGSource(42).Roll(50)
+	b, _ := NewPCGSource(42).Roll(50)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("PCG sources with the same seed diverge at face %d", i)
+		}
+	}
+}
+
+func TestRandSourceFrequencies(t *testing.T) {
+	t.Parallel()
+
+	const n = 100000
+	faces, err := NewPCGSource(7).Roll(n)
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	var counts [NumBerryTypes]int
+	for _, f := range faces {
+		counts[f]++
+	}
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		got := float64(counts[b]) / n
+		if math.Abs(got-FaceProb[b]) > 0.01 {
+			t.Errorf("frequency of %v = %.4f, want about %.2f", b, got, FaceProb[b])
+		}
+	}
+}
+
+func TestCryptoSource(t *testing.T) {
+	t.Parallel()
+
+	faces, err := CryptoSource{}.Roll(NumDice)
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if len(faces) != NumDice {
+		t.Fatalf("Roll() returned %d faces, want %d", len(faces), NumDice)
+	}
+	for _, f := range faces {
+		if f >= NumBerryTypes {
+			t.Errorf("invalid face %v", f)
+		}
+	}
+}
+
+func TestReadReplaySource(t *testing.T) {
+	t.Parallel()
+
+	input := "# recorded session\nJJSPM\nmm x  # two moonberries and a pest\n"
+	src, err := ReadReplaySource(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadReplaySource() error = %v", err)
+	}
+	if src.Remaining() != 8 {
+		t.Fatalf("Remaining() = %d, want 8", src.Remaining())
+	}
+
+	got, err := src.Roll(NumDice)
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	want := []Berry{Jumbleberry, Jumbleberry, Sugarberry, Pickleberry, Moonberry}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("face %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if _, err := src.Roll(4); !errors.Is(err, ErrReplayExhausted) {
+		t.Errorf("Roll() past end error = %v, want ErrReplayExhausted", err)
+	}
+
+	if _, err := ReadReplaySource(strings.NewReader("JJQ")); err == nil {
+		t.Error("ReadReplaySource() accepted an unknown face")
+	}
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NumRolls is the number of distinct ordered rolls, NumBerryTypes^NumDice.
+const NumRolls = 3125
+
+// DieSet is a set of dice that need not be identical, such as four
+// standard dice and one golden die with its own face odds. Die i is the
+// die at position i of a Roll. Dice and DiceProb count faces and so
+// assume identical dice; with a DieSet a state is a Roll and a keep is a
+// HoldMask, since which die is kept matters.
+type DieSet struct {
+	dice  [NumDice]*DiceModel
+	names [NumDice]string
+}
+
+// NewDieSet returns the set with die i following dice[i].
+func NewDieSet(dice [NumDice]*DiceModel) *DieSet {
+	return &DieSet{dice: dice}
+}
+
+// GoldenDieSet returns a set of standard dice following normal, except
+// the die at position pos (0-based), named "golden", which follows
+// golden.
+func GoldenDieSet(normal, golden *DiceModel, pos int) (*DieSet, error) {
+	if pos < 0 || pos >= NumDice {
+		return nil, fmt.Errorf("golden die position %d out of range 1-%d", pos+1, NumDice)
+	}
+	s := &DieSet{}
+	for i := range s.dice {
+		s.dice[i] = normal
+	}
+	s.dice[pos] = golden
+	s.names[pos] = "golden"
+	return s, nil
+}
+
+// Die returns the model of die i.
+func (s *DieSet) Die(i int) *DiceModel {
+	return s.dice[i]
+}
+
+// Name returns the name of die i, or "" for an unnamed die.
+func (s *DieSet) Name(i int) string {
+	return s.names[i]
+}
+
+// Alike reports whether dice i and j have the same face probabilities,
+// so that holding one instead of the other, showing the same face, makes
+// no difference.
+func (s *DieSet) Alike(i, j int) bool {
+	return s.dice[i].faceProb == s.dice[j].faceProb
+}
+
+// String describes the dice, grouping alike ones, e.g.
+// "dice 1-4: J 30% S 30% P 20% M 10% X 10%; die 5 (golden): ...".
+func (s *DieSet) String() string {
+	var parts []string
+	for i := 0; i < NumDice; {
+		j := i + 1
+		for j < NumDice && s.Alike(i, j) && s.names[i] == s.names[j] {
+			j++
+		}
+		label := fmt.Sprintf("die %d", i+1)
+		if j > i+1 {
+			label = fmt.Sprintf("dice %d-%d", i+1, j)
+		}
+		if s.names[i] != "" {
+			label += " (" + s.names[i] + ")"
+		}
+		parts = append(parts, label+": "+s.dice[i].String())
+		i = j
+	}
+	return strings.Join(parts, "; ")
+}
+
+// RollIndex returns the index of r among AllRolls, reading the faces as
+// the digits of a base-5 number with die 0 least significant.
+func RollIndex(r Roll) int {
+	idx := 0
+	for i := NumDice - 1; i >= 0; i-- {
+		idx = idx*int(NumBerryTypes) + int(r[i])
+	}
+	return idx
+}
+
+// allRollsCache holds every ordered roll, indexed by RollIndex.
+var allRollsCache = func() []Roll {
+	rolls := make([]Roll, NumRolls)
+	for idx := range rolls {
+		n := idx
+		for i := range NumDice {
+			rolls[idx][i] = Berry(n % int(NumBerryTypes))
+			n /= int(NumBerryTypes)
+		}
+	}
+	return rolls
+}()
+
+// AllRolls returns every ordered roll of NumDice dice, indexed by
+// RollIndex.
+func AllRolls() []Roll {
+	return allRollsCache
+}
+
+// RollProb returns the probability that rerolling the dice of r not held
+// in held makes each of them show its face in r. With held = 0 it is the
+// probability of rolling r.
+func (s *DieSet) RollProb(r Roll, held HoldMask) float64 {
+	p := 1.0
+	for i, b := range r {
+		if !held.Held(i) {
+			p *= s.dice[i].faceProb[b]
+		}
+	}
+	return p
+}
+
+// RollOutcome pairs an ordered roll with its probability.
+type RollOutcome struct {
+	Roll Roll
+	Prob float64
+}
+
+// Rerolls returns every roll that can follow r when the dice not held in
+// held are rerolled, with its probability. Rolls with probability zero
+// are left out.
+func (s *DieSet) Rerolls(r Roll, held HoldMask) []RollOutcome {
+	var out []RollOutcome
+	var rec func(i int, cur Roll, p float64)
+	rec = func(i int, cur Roll, p float64) {
+		if i == NumDice {
+			out = append(out, RollOutcome{Roll: cur, Prob: p})
+			return
+		}
+		if held.Held(i) {
+			rec(i+1, cur, p)
+			return
+		}
+		for b := Berry(0); b < NumBerryTypes; b++ {
+			if q := s.dice[i].faceProb[b]; q > 0 {
+				cur[i] = b
+				rec(i+1, cur, p*q)
+			}
+		}
+	}
+	rec(0, r, 1)
+	return out
+}
+
+// EnumerateHolds calls fn for every distinct way of holding dice of r,
+// including holding none and holding all. Holds that differ only by
+// swapping alike dice showing the same face are the same keep, so only
+// the first of them, holding the leftmost such dice, is reported.
+func (s *DieSet) EnumerateHolds(r Roll, fn func(m HoldMask)) {
+	for m := HoldMask(0); m < 1<<NumDice; m++ {
+		if s.canonical(r, m) {
+			fn(m)
+		}
+	}
+}
+
+// canonical reports whether no held die of r could be swapped for an
+// unheld alike die to its left showing the same face.
+func (s *DieSet) canonical(r Roll, m HoldMask) bool {
+	for i := range NumDice {
+		if !m.Held(i) {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if !m.Held(j) && r[j] == r[i] && s.Alike(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
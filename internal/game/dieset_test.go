@@ -0,0 +1,151 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// goldenModel is a die that favors Moonberries.
+func goldenModel(t *testing.T) *DiceModel {
+	t.Helper()
+	m, err := NewDiceModel([NumBerryTypes]float64{0.2, 0.2, 0.2, 0.3, 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRollIndexRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	rolls := AllRolls()
+	if len(rolls) != NumRolls {
+		t.Fatalf("len(AllRolls()) = %d, want %d", len(rolls), NumRolls)
+	}
+	seen := make(map[Roll]bool)
+	for i, r := range rolls {
+		if got := RollIndex(r); got != i {
+			t.Fatalf("RollIndex(%v) = %d, want %d", r, got, i)
+		}
+		seen[r] = true
+	}
+	if len(seen) != NumRolls {
+		t.Errorf("AllRolls() has %d distinct rolls, want %d", len(seen), NumRolls)
+	}
+}
+
+func TestGoldenDieSet(t *testing.T) {
+	t.Parallel()
+
+	golden := goldenModel(t)
+	if _, err := GoldenDieSet(DefaultModel, golden, NumDice); err == nil {
+		t.Error("GoldenDieSet(position 6) succeeded")
+	}
+	s, err := GoldenDieSet(DefaultModel, golden, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Die(2) != golden || s.Die(0) != DefaultModel || s.Name(2) != "golden" || s.Name(0) != "" {
+		t.Errorf("GoldenDieSet(position 3) put the golden die elsewhere: %v", s)
+	}
+	if s.Alike(0, 2) || !s.Alike(0, 4) {
+		t.Error("Alike does not tell the golden die apart")
+	}
+	want := "dice 1-2: J 30% S 30% P 20% M 10% X 10%; die 3 (golden): J 20% S 20% P 20% M 30% X 10%; dice 4-5: J 30% S 30% P 20% M 10% X 10%"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestDieSetRerolls(t *testing.T) {
+	t.Parallel()
+
+	s, err := GoldenDieSet(DefaultModel, goldenModel(t), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Roll{Jumbleberry, Moonberry, Pest, Sugarberry, Moonberry}
+	for m := HoldMask(0); m < 1<<NumDice; m++ {
+		outcomes := s.Rerolls(r, m)
+		if want := int(math.Pow(5, float64(NumDice-m.Count()))); len(outcomes) != want {
+			t.Fatalf("hold %05b: %d outcomes, want %d", m, len(outcomes), want)
+		}
+		sum := 0.0
+		for _, o := range outcomes {
+			for i := range r {
+				if m.Held(i) && o.Roll[i] != r[i] {
+					t.Fatalf("hold %05b: outcome %v changed held die %d", m, o.Roll, i+1)
+				}
+			}
+			if p := s.RollProb(o.Roll, m); math.Abs(p-o.Prob) > 1e-15 {
+				t.Fatalf("hold %05b: RollProb(%v) = %v, outcome says %v", m, o.Roll, p, o.Prob)
+			}
+			sum += o.Prob
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("hold %05b: probabilities sum to %v", m, sum)
+		}
+	}
+
+	// Rolling all five: the golden Moonberry is three times as likely.
+	if got, want := s.RollProb(r, 0), 0.3*0.1*0.1*0.3*0.3; math.Abs(got-want) > 1e-15 {
+		t.Errorf("RollProb(%v) = %v, want %v", r, got, want)
+	}
+}
+
+func TestEnumerateHolds(t *testing.T) {
+	t.Parallel()
+
+	golden := goldenModel(t)
+	uniform := NewDieSet([NumDice]*DiceModel{DefaultModel, DefaultModel, DefaultModel, DefaultModel, DefaultModel})
+	mixed, err := GoldenDieSet(DefaultModel, golden, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		set  *DieSet
+		roll string
+		want int
+	}{
+		// Holds are the face-count keeps: (3+1)·(1+1)·(1+1).
+		{name: "identical dice", set: uniform, roll: "JJJSM", want: 16},
+		{name: "identical dice, all alike", set: uniform, roll: "MMMMM", want: 6},
+		// The golden Moonberry is its own die: (4+1)·(1+1).
+		{name: "golden die", set: mixed, roll: "MMMMM", want: 10},
+		{name: "five different faces", set: mixed, roll: "JSPMX", want: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var r Roll
+			for i := range r {
+				r[i], _ = ParseBerry(tt.roll[i])
+			}
+			n := 0
+			keeps := make(map[[2]Dice]bool)
+			tt.set.EnumerateHolds(r, func(m HoldMask) {
+				n++
+				// Split the held faces by die kind; no two holds may agree.
+				var k [2]Dice
+				for i, b := range r {
+					if m.Held(i) {
+						kind := 0
+						if !tt.set.Alike(i, 0) {
+							kind = 1
+						}
+						k[kind][b]++
+					}
+				}
+				if keeps[k] {
+					t.Errorf("hold %05b repeats keep %v", m, k)
+				}
+				keeps[k] = true
+			})
+			if n != tt.want {
+				t.Errorf("EnumerateHolds(%s) gave %d holds, want %d", tt.roll, n, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by Engine for moves that break the rules.
+var (
+	ErrGameOver      = errors.New("game is over")
+	ErrGameNotOver   = errors.New("game is not over")
+	ErrNotRolled     = errors.New("dice have not been rolled this round")
+	ErrAlreadyRolled = errors.New("dice have already been rolled this round")
+	ErrNoRollsLeft   = errors.New("no rolls left this round")
+)
+
+// KeepError reports an attempt to keep dice that are not showing.
+type KeepError struct {
+	Keep Dice // the requested keep
+	Dice Dice // the dice actually showing
+}
+
+func (e *KeepError) Error() string {
+	return fmt.Sprintf("cannot keep %s from %s", e.Keep, e.Dice)
+}
+
+// CategoryError reports an attempt to score a category that is invalid or
+// has already been used.
+type CategoryError struct {
+	Category Category
+	Used     bool // false if the category is out of range
+}
+
+func (e *CategoryError) Error() string {
+	if e.Used {
+		return fmt.Sprintf("category %s has already been used", e.Category)
+	}
+	return fmt.Sprintf("invalid category %s", e.Category)
+}
+
+// Engine plays a game of Jumbleberry Fields, enforcing the rules on every
+// state transition. Each round is Roll, then up to two Keeps, then Score.
+type Engine struct {
+	state GameState
+	card  Scorecard
+	src   DiceSource
+}
+
+// NewEngine returns an engine at the start of a new game, rolling dice
+// from src.
+func NewEngine(src DiceSource) *Engine {
+	return &Engine{state: NewGame(), src: src}
+}
+
+// NewEngineAt returns an engine that picks up a game in state, rolling
+// further dice from src. If state.RollsLeft is below RollsPerRound the
+// round is in progress and state.CurrentDice must hold all five dice;
+// otherwise no dice may be showing.
+//
+// The engine's scorecard records only the categories scored through it,
+// while State().Score includes state.Score.
+func NewEngineAt(src DiceSource, state GameState) (*Engine, error) {
+	if state.CategoriesLeft&^AllCategories != 0 {
+		return nil, fmt.Errorf("invalid categories left %#x", uint16(state.CategoriesLeft))
+	}
+	if state.RollsLeft > RollsPerRound {
+		return nil, fmt.Errorf("rolls left %d exceeds %d", state.RollsLeft, RollsPerRound)
+	}
+	if state.RollsLeft == RollsPerRound {
+		if state.CurrentDice.Total() != 0 {
+			return nil, fmt.Errorf("dice %s showing before the round's first roll", state.CurrentDice)
+		}
+	} else {
+		if state.GameOver() {
+			return nil, ErrGameOver
+		}
+		if state.CurrentDice.Total() != NumDice {
+			return nil, fmt.Errorf("round in progress with %d dice, want %d", state.CurrentDice.Total(), NumDice)
+		}
+	}
+	return &Engine{state: state, src: src}, nil
+}
+
+// State returns the current game state.
+func (e *Engine) State() GameState {
+	return e.state
+}
+
+// Scorecard returns the points scored in each category so far.
+func (e *Engine) Scorecard() Scorecard {
+	return e.card
+}
+
+// Roll rolls all dice to start a round.
+func (e *Engine) Roll() (Dice, error) {
+	if e.state.GameOver() {
+		return Dice{}, ErrGameOver
+	}
+	if e.state.RollsLeft != RollsPerRound {
+		return Dice{}, ErrAlreadyRolled
+	}
+
+	rolled, err := e.rollDice(NumDice)
+	if err != nil {
+		return Dice{}, err
+	}
+	e.state.CurrentDice = rolled
+	e.state.RollsLeft--
+	return rolled, nil
+}
+
+// Keep holds the dice in keep and rerolls the rest, returning the new dice.
+func (e *Engine) Keep(keep Dice) (Dice, error) {
+	if e.state.GameOver() {
+		return Dice{}, ErrGameOver
+	}
+	if e.state.RollsLeft == RollsPerRound {
+		return Dice{}, ErrNotRolled
+	}
+	if e.state.RollsLeft == 0 {
+		return Dice{}, ErrNoRollsLeft
+	}
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		if keep[b] > e.state.CurrentDice[b] {
+			return Dice{}, &KeepError{Keep: keep, Dice: e.state.CurrentDice}
+		}
+	}
+
+	rolled, err := e.rollDice(NumDice - keep.Total())
+	if err != nil {
+		return Dice{}, err
+	}
+	e.state.CurrentDice = AddDice(keep, rolled)
+	e.state.RollsLeft--
+	return e.state.CurrentDice, nil
+}
+
+// Score scores the current dice in cat, ending the round, and returns the
+// points scored.
+func (e *Engine) Score(cat Category) (int, error) {
+	if e.state.GameOver() {
+		return 0, ErrGameOver
+	}
+	if e.state.RollsLeft == RollsPerRound {
+		return 0, ErrNotRolled
+	}
+	if cat >= NumCategories {
+		return 0, &CategoryError{Category: cat}
+	}
+	if !e.state.CategoriesLeft.Has(cat) {
+		return 0, &CategoryError{Category: cat, Used: true}
+	}
+
+	points := Score(e.state.CurrentDice, cat)
+	card, err := e.card.Record(cat, points)
+	if err != nil {
+		return 0, err
+	}
+	e.card = card
+	e.state.Score += uint16(points)
+	e.state.CategoriesLeft = e.state.CategoriesLeft.Remove(cat)
+	e.state.CurrentDice = Dice{}
+	e.state.RollsLeft = RollsPerRound
+	return points, nil
+}
+
+// Finish returns the final score once every category has been used.
+func (e *Engine) Finish() (int, error) {
+	if !e.state.GameOver() {
+		return 0, ErrGameNotOver
+	}
+	return int(e.state.Score), nil
+}
+
+// rollDice rolls n dice from the source and returns their counts.
+func (e *Engine) rollDice(n int) (Dice, error) {
+	faces, err := e.src.Roll(n)
+	if err != nil {
+		return Dice{}, fmt.Errorf("rolling %d dice: %w", n, err)
+	}
+	if len(faces) != n {
+		return Dice{}, fmt.Errorf("dice source returned %d faces, want %d", len(faces), n)
+	}
+	var d Dice
+	for _, f := range faces {
+		if f >= NumBerryTypes {
+			return Dice{}, fmt.Errorf("dice source returned invalid face %v", f)
+		}
+		d[f]++
+	}
+	return d, nil
+}
@@ -0,0 +1,220 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// faces converts a letter sequence like "JJSPM" into berries.
+func faces(t *testing.T, s string) []Berry {
+	t.Helper()
+	var out []Berry
+	for i := 0; i < len(s); i++ {
+		b, ok := ParseBerry(s[i])
+		if !ok {
+			t.Fatalf("bad face %q", s[i])
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestEngineRound(t *testing.T) {
+	t.Parallel()
+
+	// Initial roll JJSPM, keep 1M and reroll 4 -> MMMX, keep 4M reroll 1 -> M.
+	src := NewReplaySource(faces(t, "JJSPM"+"MMMX"+"M"))
+	eng := NewEngine(src)
+
+	dice, err := eng.Roll()
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if want := (Dice{2, 1, 1, 1, 0}); dice != want {
+		t.Errorf("Roll() = %v, want %v", dice, want)
+	}
+	if eng.State().RollsLeft != 2 {
+		t.Errorf("RollsLeft after Roll = %d, want 2", eng.State().RollsLeft)
+	}
+
+	dice, err = eng.Keep(Dice{0, 0, 0, 1, 0})
+	if err != nil {
+		t.Fatalf("Keep() error = %v", err)
+	}
+	if want := (Dice{0, 0, 0, 4, 1}); dice != want {
+		t.Errorf("Keep() = %v, want %v", dice, want)
+	}
+
+	dice, err = eng.Keep(Dice{0, 0, 0, 4, 0})
+	if err != nil {
+		t.Fatalf("Keep() error = %v", err)
+	}
+	if want := (Dice{0, 0, 0, 5, 0}); dice != want {
+		t.Errorf("Keep() = %v, want %v", dice, want)
+	}
+
+	if _, err := eng.Keep(Dice{0, 0, 0, 5, 0}); !errors.Is(err, ErrNoRollsLeft) {
+		t.Errorf("Keep() with 0 rolls left error = %v, want ErrNoRollsLeft", err)
+	}
+
+	points, err := eng.Score(CatBasketOfFive)
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if points != 35 {
+		t.Errorf("Score() = %d, want 35", points)
+	}
+
+	st := eng.State()
+	if st.Score != 35 || st.CategoriesLeft.Has(CatBasketOfFive) || st.RollsLeft != RollsPerRound {
+		t.Errorf("state after Score = %+v", st)
+	}
+}
+
+func TestEngineIllegalMoves(t *testing.T) {
+	t.Parallel()
+
+	eng := NewEngine(NewReplaySource(faces(t, "JJSPM"+"JJJJJ")))
+
+	if _, err := eng.Keep(Dice{}); !errors.Is(err, ErrNotRolled) {
+		t.Errorf("Keep() before Roll error = %v, want ErrNotRolled", err)
+	}
+	if _, err := eng.Score(CatFreeRoll); !errors.Is(err, ErrNotRolled) {
+		t.Errorf("Score() before Roll error = %v, want ErrNotRolled", err)
+	}
+	if _, err := eng.Finish(); !errors.Is(err, ErrGameNotOver) {
+		t.Errorf("Finish() mid-game error = %v, want ErrGameNotOver", err)
+	}
+
+	if _, err := eng.Roll(); err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if _, err := eng.Roll(); !errors.Is(err, ErrAlreadyRolled) {
+		t.Errorf("second Roll() error = %v, want ErrAlreadyRolled", err)
+	}
+
+	var keepErr *KeepError
+	if _, err := eng.Keep(Dice{0, 0, 0, 2, 0}); !errors.As(err, &keepErr) {
+		t.Errorf("Keep() of missing dice error = %v, want *KeepError", err)
+	}
+
+	if _, err := eng.Score(CatFreeRoll); err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if _, err := eng.Roll(); err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+
+	var catErr *CategoryError
+	if _, err := eng.Score(CatFreeRoll); !errors.As(err, &catErr) || !catErr.Used {
+		t.Errorf("Score() of used category error = %v, want used *CategoryError", err)
+	}
+	if _, err := eng.Score(NumCategories); !errors.As(err, &catErr) || catErr.Used {
+		t.Errorf("Score() of invalid category error = %v, want invalid *CategoryError", err)
+	}
+
+	// The replay has no faces left.
+	if _, err := eng.Keep(Dice{}); !errors.Is(err, ErrReplayExhausted) {
+		t.Errorf("Keep() past end of replay error = %v, want ErrReplayExhausted", err)
+	}
+}
+
+func TestEngineFullGame(t *testing.T) {
+	t.Parallel()
+
+	eng := NewEngine(NewPCGSource(1))
+	for c := Category(0); c < NumCategories; c++ {
+		if _, err := eng.Roll(); err != nil {
+			t.Fatalf("round %d: Roll() error = %v", c+1, err)
+		}
+		if _, err := eng.Score(c); err != nil {
+			t.Fatalf("round %d: Score() error = %v", c+1, err)
+		}
+	}
+
+	total, err := eng.Finish()
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if total != int(eng.State().Score) {
+		t.Errorf("Finish() = %d, state score = %d", total, eng.State().Score)
+	}
+	if _, err := eng.Roll(); !errors.Is(err, ErrGameOver) {
+		t.Errorf("Roll() after game over error = %v, want ErrGameOver", err)
+	}
+}
+
+func TestEngineScorecard(t *testing.T) {
+	t.Parallel()
+
+	eng := NewEngine(NewReplaySource(faces(t, "JJSPM")))
+	if _, err := eng.Roll(); err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if _, err := eng.Score(CatJumbleberry); err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+
+	sc := eng.Scorecard()
+	if p, ok := sc.Points(CatJumbleberry); !ok || p != 4 {
+		t.Errorf("scorecard Jumbleberry = %d, %v; want 4, true", p, ok)
+	}
+	if sc.Total() != int(eng.State().Score) || sc.CategoriesLeft() != eng.State().CategoriesLeft {
+		t.Errorf("scorecard %+v disagrees with state %+v", sc, eng.State())
+	}
+}
+
+func TestNewEngineAt(t *testing.T) {
+	t.Parallel()
+
+	// Mid-round: two categories left, JJJJM showing with one reroll left.
+	state := GameState{
+		CurrentDice:    Dice{4, 0, 0, 1, 0},
+		RollsLeft:      1,
+		CategoriesLeft: CategorySet(0).Add(CatJumbleberry).Add(CatMixedBasket),
+		Score:          100,
+	}
+	eng, err := NewEngineAt(NewReplaySource(faces(t, "J"+"JSPMX")), state)
+	if err != nil {
+		t.Fatalf("NewEngineAt() error = %v", err)
+	}
+	if _, err := eng.Roll(); !errors.Is(err, ErrAlreadyRolled) {
+		t.Errorf("Roll() mid-round error = %v, want ErrAlreadyRolled", err)
+	}
+	if _, err := eng.Keep(Dice{4, 0, 0, 0, 0}); err != nil {
+		t.Fatalf("Keep() error = %v", err)
+	}
+	if _, err := eng.Score(CatJumbleberry); err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if _, err := eng.Roll(); err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if _, err := eng.Score(CatMixedBasket); err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	total, err := eng.Finish()
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	card := eng.Scorecard()
+	if want := 100 + card.Total(); total != want {
+		t.Errorf("Finish() = %d, want %d", total, want)
+	}
+	if card.Used() != state.CategoriesLeft {
+		t.Errorf("Scorecard().Used() = %v, want the categories played", card.Used())
+	}
+
+	bad := []GameState{
+		{RollsLeft: 4, CategoriesLeft: AllCategories},
+		{RollsLeft: RollsPerRound, CategoriesLeft: 1 << NumCategories},
+		{RollsLeft: RollsPerRound, CategoriesLeft: AllCategories, CurrentDice: Dice{1, 0, 0, 0, 0}},
+		{RollsLeft: 2, CategoriesLeft: AllCategories, CurrentDice: Dice{4, 0, 0, 0, 0}},
+		{RollsLeft: 2, CurrentDice: Dice{5, 0, 0, 0, 0}},
+	}
+	for _, st := range bad {
+		if _, err := NewEngineAt(NewReplaySource(nil), st); err == nil {
+			t.Errorf("NewEngineAt(%v) succeeded, want error", st)
+		}
+	}
+}
@@ -20,11 +20,16 @@ type GameState struct {
 	Score          uint16      // cumulative point total
 }
 
+// RollsPerRound is the number of rolls in a round: the initial roll of all
+// dice plus two rerolls. A RollsLeft of RollsPerRound means the dice have
+// not been rolled yet this round.
+const RollsPerRound = 3
+
 // NewGame returns the initial game state: all categories available,
 // 3 rolls to use, no dice rolled yet, score 0.
 func NewGame() GameState {
 	return GameState{
-		RollsLeft:      3,
+		RollsLeft:      RollsPerRound,
 		CategoriesLeft: AllCategories,
 	}
 }
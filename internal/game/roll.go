@@ -0,0 +1,100 @@
+package game
+
+import "strings"
+
+// Roll is an ordered set of dice as they lie on the table: Roll[i] is the
+// face showing on die i. Unlike Dice it records which physical die shows
+// which berry, so a keep decision can be mapped to die positions.
+type Roll [NumDice]Berry
+
+// RollFromDice returns a Roll with the dice in canonical order (all
+// Jumbleberries first, then Sugarberries, and so on). d must represent a
+// full roll of NumDice dice; extra dice are dropped and missing ones are
+// left as Jumbleberry.
+func RollFromDice(d Dice) Roll {
+	var r Roll
+	i := 0
+	for b := Berry(0); b < NumBerryTypes; b++ {
+		for n := uint8(0); n < d[b] && i < NumDice; n++ {
+			r[i] = b
+			i++
+		}
+	}
+	return r
+}
+
+// Dice returns the face counts of the roll.
+func (r Roll) Dice() Dice {
+	var d Dice
+	for _, b := range r {
+		d[b]++
+	}
+	return d
+}
+
+// String returns the roll in sequence notation, e.g. "JJSPM".
+func (r Roll) String() string {
+	var sb strings.Builder
+	for _, b := range r {
+		sb.WriteByte(b.Letter())
+	}
+	return sb.String()
+}
+
+// Hold returns the positions to hold so that the held dice show exactly
+// keep. When several dice show the same face the leftmost ones are held.
+// It reports false if keep is not a subset of the roll.
+func (r Roll) Hold(keep Dice) (HoldMask, bool) {
+	var m HoldMask
+	for i, b := range r {
+		if keep[b] > 0 {
+			keep[b]--
+			m |= 1 << i
+		}
+	}
+	return m, keep.Total() == 0
+}
+
+// Reroll returns the roll after rerolling every die not held in m. faces
+// supplies the new faces in position order and must hold one berry per
+// rerolled die.
+func (r Roll) Reroll(m HoldMask, faces []Berry) Roll {
+	for i := range r {
+		if !m.Held(i) {
+			r[i] = faces[0]
+			faces = faces[1:]
+		}
+	}
+	return r
+}
+
+// HoldMask is a bitmask of die positions: bit i is set if die i is held.
+type HoldMask uint8
+
+// Held reports whether die i (0-based) is held.
+func (m HoldMask) Held(i int) bool {
+	return m&(1<<i) != 0
+}
+
+// Count returns the number of held dice.
+func (m HoldMask) Count() int {
+	n := 0
+	for i := 0; i < NumDice; i++ {
+		if m.Held(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// Positions returns the held die positions, numbered from 1 as a player
+// would count them.
+func (m HoldMask) Positions() []int {
+	var pos []int
+	for i := 0; i < NumDice; i++ {
+		if m.Held(i) {
+			pos = append(pos, i+1)
+		}
+	}
+	return pos
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRollDiceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range EnumerateAllDice() {
+		r := RollFromDice(d)
+		if got := r.Dice(); got != d {
+			t.Errorf("RollFromDice(%v).Dice() = %v", d, got)
+		}
+	}
+
+	r := Roll{Moonberry, Jumbleberry, Pest, Jumbleberry, Pickleberry}
+	if got := r.String(); got != "MJXJP" {
+		t.Errorf("String() = %q, want %q", got, "MJXJP")
+	}
+	if got := RollFromDice(r.Dice()).String(); got != "JJPMX" {
+		t.Errorf("canonical roll = %q, want %q", got, "JJPMX")
+	}
+}
+
+func TestRollHold(t *testing.T) {
+	t.Parallel()
+
+	// J M P M S
+	r := Roll{Jumbleberry, Moonberry, Pickleberry, Moonberry, Sugarberry}
+
+	tests := []struct {
+		name   string
+		keep   Dice
+		want   []int
+		wantOK bool
+	}{
+		{"nothing", Dice{}, nil, true},
+		{"both moonberries", Dice{0, 0, 0, 2, 0}, []int{2, 4}, true},
+		{"moonberry and pickleberry", Dice{0, 0, 1, 1, 0}, []int{2, 3}, true},
+		{"everything", r.Dice(), []int{1, 2, 3, 4, 5}, true},
+		{"not in roll", Dice{0, 0, 0, 0, 1}, nil, false},
+		{"too many", Dice{0, 0, 0, 3, 0}, []int{2, 4}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, ok := r.Hold(tt.keep)
+			if ok != tt.wantOK {
+				t.Errorf("Hold(%v) ok = %v, want %v", tt.keep, ok, tt.wantOK)
+			}
+			if got := m.Positions(); !slices.Equal(got, tt.want) {
+				t.Errorf("Hold(%v) positions = %v, want %v", tt.keep, got, tt.want)
+			}
+			if m.Count() != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", m.Count(), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRollReroll(t *testing.T) {
+	t.Parallel()
+
+	r := Roll{Jumbleberry, Moonberry, Pickleberry, Moonberry, Sugarberry}
+	m, _ := r.Hold(Dice{0, 0, 0, 2, 0})
+	got := r.Reroll(m, []Berry{Pest, Moonberry, Moonberry})
+	want := Roll{Pest, Moonberry, Moonberry, Moonberry, Moonberry}
+	if got != want {
+		t.Errorf("Reroll() = %v, want %v", got, want)
+	}
+}
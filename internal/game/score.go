@@ -40,6 +40,30 @@ func Score(d Dice, cat Category) int {
 	}
 }
 
+// ScoreWithPoints is Score with berries worth points instead of
+// BerryPoints, for weighing changes to the point values. Points may be
+// fractional.
+func ScoreWithPoints(d Dice, cat Category, points [NumBerryTypes]float64) float64 {
+	if !Qualifies(d, cat) {
+		return 0
+	}
+	switch cat {
+	case CatJumbleberry:
+		return float64(d[Jumbleberry]) * points[Jumbleberry]
+	case CatSugarberry:
+		return float64(d[Sugarberry]) * points[Sugarberry]
+	case CatPickleberry:
+		return float64(d[Pickleberry]) * points[Pickleberry]
+	case CatMoonberry:
+		return float64(d[Moonberry]) * points[Moonberry]
+	}
+	sum := 0.0
+	for b, n := range d {
+		sum += float64(n) * points[b]
+	}
+	return sum
+}
+
 // hasNOfAKind returns true if any type (including Pest) has count >= n.
 func hasNOfAKind(d Dice, n uint8) bool {
 	for _, count := range d {
@@ -49,3 +73,33 @@ func hasNOfAKind(d Dice, n uint8) bool {
 	}
 	return false
 }
+
+// Qualifies returns true if the dice meet the category's requirement:
+// at least one matching berry for the single-berry categories, the
+// required pattern for the baskets, and always for Free Roll.
+// Unlike Score > 0, a Pest-only basket qualifies even though it scores 0.
+func Qualifies(d Dice, cat Category) bool {
+	switch cat {
+	case CatJumbleberry:
+		return d[Jumbleberry] > 0
+	case CatSugarberry:
+		return d[Sugarberry] > 0
+	case CatPickleberry:
+		return d[Pickleberry] > 0
+	case CatMoonberry:
+		return d[Moonberry] > 0
+	case CatBasketOfThree:
+		return hasNOfAKind(d, 3)
+	case CatBasketOfFour:
+		return hasNOfAKind(d, 4)
+	case CatBasketOfFive:
+		return hasNOfAKind(d, 5)
+	case CatMixedBasket:
+		return d[Jumbleberry] >= 1 && d[Sugarberry] >= 1 &&
+			d[Pickleberry] >= 1 && d[Moonberry] >= 1
+	case CatFreeRoll:
+		return true
+	default:
+		panic("invalid category")
+	}
+}
@@ -252,6 +252,40 @@ func TestScore(t *testing.T) {
 	}
 }
 
+func TestScoreWithPoints(t *testing.T) {
+	t.Parallel()
+
+	var standard [NumBerryTypes]float64
+	for b, p := range BerryPoints {
+		standard[b] = float64(p)
+	}
+	for _, d := range AllDice() {
+		for cat := Category(0); cat < NumCategories; cat++ {
+			if got, want := ScoreWithPoints(d, cat, standard), float64(Score(d, cat)); got != want {
+				t.Errorf("ScoreWithPoints(%v, %v, standard) = %v, want %v", d, cat, got, want)
+			}
+		}
+	}
+
+	points := [NumBerryTypes]float64{1, 2, 3, 8.5, -1}
+	tests := []struct {
+		dice Dice
+		cat  Category
+		want float64
+	}{
+		{Dice{0, 0, 0, 2, 3}, CatMoonberry, 17},
+		{Dice{0, 0, 0, 2, 3}, CatBasketOfThree, 14},
+		{Dice{1, 1, 1, 1, 1}, CatMixedBasket, 13.5},
+		{Dice{1, 1, 1, 0, 2}, CatMixedBasket, 0},
+		{Dice{0, 0, 0, 0, 5}, CatBasketOfFive, -5},
+	}
+	for _, tt := range tests {
+		if got := ScoreWithPoints(tt.dice, tt.cat, points); got != tt.want {
+			t.Errorf("ScoreWithPoints(%v, %v, %v) = %v, want %v", tt.dice, tt.cat, points, got, tt.want)
+		}
+	}
+}
+
 func TestHasNOfAKind(t *testing.T) {
 	t.Parallel()
 
@@ -314,3 +348,41 @@ func TestHasNOfAKind(t *testing.T) {
 		})
 	}
 }
+
+func TestQualifies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		dice     Dice
+		category Category
+		want     bool
+	}{
+		{"jumbleberry present", Dice{1, 2, 2, 0, 0}, CatJumbleberry, true},
+		{"moonberry absent", Dice{1, 2, 2, 0, 0}, CatMoonberry, false},
+		{"three of a kind", Dice{0, 3, 1, 1, 0}, CatBasketOfThree, true},
+		{"no four of a kind", Dice{0, 3, 1, 1, 0}, CatBasketOfFour, false},
+		{"five pests qualify", Dice{0, 0, 0, 0, 5}, CatBasketOfFive, true},
+		{"mixed basket", Dice{1, 1, 1, 1, 1}, CatMixedBasket, true},
+		{"mixed basket missing moonberry", Dice{2, 1, 1, 0, 1}, CatMixedBasket, false},
+		{"free roll always", Dice{0, 0, 0, 0, 5}, CatFreeRoll, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Qualifies(tt.dice, tt.category); got != tt.want {
+				t.Errorf("Qualifies(%v, %v) = %v, want %v", tt.dice, tt.category, got, tt.want)
+			}
+		})
+	}
+
+	// Any scoring dice must qualify.
+	for _, d := range AllDice() {
+		for cat := Category(0); cat < NumCategories; cat++ {
+			if Score(d, cat) > 0 && !Qualifies(d, cat) {
+				t.Errorf("Score(%v, %v) > 0 but Qualifies is false", d, cat)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Scorecard records the points written in each category box. A used box
+// holding 0 points is a scratch: the category was spent on dice that did
+// not qualify (or a roll with no matching berries).
+type Scorecard struct {
+	used   CategorySet
+	points [NumCategories]uint8
+}
+
+// Record returns a copy of the scorecard with points written in cat.
+// It returns a *CategoryError if cat is invalid or already used, and an
+// error if no roll can score points in cat.
+func (sc Scorecard) Record(cat Category, points int) (Scorecard, error) {
+	if cat >= NumCategories {
+		return sc, &CategoryError{Category: cat}
+	}
+	if sc.used.Has(cat) {
+		return sc, &CategoryError{Category: cat, Used: true}
+	}
+	if !PossibleScore(cat, points) {
+		return sc, fmt.Errorf("%d is not a possible score for %s", points, cat)
+	}
+	sc.used = sc.used.Add(cat)
+	sc.points[cat] = uint8(points)
+	return sc, nil
+}
+
+// Scratch returns a copy of the scorecard with 0 points written in cat.
+func (sc Scorecard) Scratch(cat Category) (Scorecard, error) {
+	return sc.Record(cat, 0)
+}
+
+// Points returns the points in cat and whether the box has been used.
+func (sc Scorecard) Points(cat Category) (int, bool) {
+	if cat >= NumCategories || !sc.used.Has(cat) {
+		return 0, false
+	}
+	return int(sc.points[cat]), true
+}
+
+// Scratched reports whether cat has been used for 0 points.
+func (sc Scorecard) Scratched(cat Category) bool {
+	p, ok := sc.Points(cat)
+	return ok && p == 0
+}
+
+// Used returns the set of categories that have been scored.
+func (sc Scorecard) Used() CategorySet {
+	return sc.used
+}
+
+// CategoriesLeft returns the set of categories still open.
+func (sc Scorecard) CategoriesLeft() CategorySet {
+	return AllCategories &^ sc.used
+}
+
+// Total returns the sum of all used boxes.
+func (sc Scorecard) Total() int {
+	total := 0
+	sc.used.ForEach(func(c Category) {
+		total += int(sc.points[c])
+	})
+	return total
+}
+
+// GameState returns the state at the start of the next round for this
+// scorecard: dice not yet rolled, its open categories and total score.
+func (sc Scorecard) GameState() GameState {
+	return GameState{
+		RollsLeft:      RollsPerRound,
+		CategoriesLeft: sc.CategoriesLeft(),
+		Score:          uint16(sc.Total()),
+	}
+}
+
+// PossibleScore reports whether some roll scores exactly points in cat.
+func PossibleScore(cat Category, points int) bool {
+	if cat >= NumCategories || points < 0 {
+		return false
+	}
+	for _, d := range AllDice() {
+		if Score(d, cat) == points {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCategoryName returns the category with the given display name,
+// e.g. "Basket of Three". It reports false if there is none.
+func ParseCategoryName(name string) (Category, bool) {
+	for c, n := range categoryNames {
+		if n == name {
+			return Category(c), true
+		}
+	}
+	return 0, false
+}
+
+// MarshalJSON encodes the scorecard as an object mapping each used
+// category's name to its points, e.g. {"Jumbleberry":6,"Mixed Basket":0}.
+func (sc Scorecard) MarshalJSON() ([]byte, error) {
+	m := make(map[string]int, sc.used.Count())
+	sc.used.ForEach(func(c Category) {
+		m[c.String()] = int(sc.points[c])
+	})
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the object form written by MarshalJSON,
+// validating every category name and score.
+func (sc *Scorecard) UnmarshalJSON(data []byte) error {
+	var m map[string]int
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	var card Scorecard
+	for name, points := range m {
+		cat, ok := ParseCategoryName(name)
+		if !ok {
+			return fmt.Errorf("unknown category %q", name)
+		}
+		var err error
+		if card, err = card.Record(cat, points); err != nil {
+			return err
+		}
+	}
+	*sc = card
+	return nil
+}
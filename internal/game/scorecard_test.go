@@ -0,0 +1,134 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestScorecard(t *testing.T) {
+	t.Parallel()
+
+	var sc Scorecard
+	if sc.CategoriesLeft() != AllCategories || sc.Total() != 0 {
+		t.Fatalf("empty scorecard: left = %v, total = %d", sc.CategoriesLeft(), sc.Total())
+	}
+
+	sc, err := sc.Record(CatJumbleberry, 6)
+	if err != nil {
+		t.Fatalf("Record(J, 6) error = %v", err)
+	}
+	sc, err = sc.Record(CatBasketOfThree, 23)
+	if err != nil {
+		t.Fatalf("Record(3K, 23) error = %v", err)
+	}
+	sc, err = sc.Scratch(CatMixedBasket)
+	if err != nil {
+		t.Fatalf("Scratch(Mix) error = %v", err)
+	}
+
+	if got := sc.Total(); got != 29 {
+		t.Errorf("Total() = %d, want 29", got)
+	}
+	wantLeft := AllCategories.Remove(CatJumbleberry).Remove(CatBasketOfThree).Remove(CatMixedBasket)
+	if got := sc.CategoriesLeft(); got != wantLeft {
+		t.Errorf("CategoriesLeft() = %v, want %v", got, wantLeft)
+	}
+	if !sc.Scratched(CatMixedBasket) || sc.Scratched(CatJumbleberry) || sc.Scratched(CatFreeRoll) {
+		t.Error("Scratched() wrong")
+	}
+	if p, ok := sc.Points(CatBasketOfThree); !ok || p != 23 {
+		t.Errorf("Points(3K) = %d, %v; want 23, true", p, ok)
+	}
+	if _, ok := sc.Points(CatFreeRoll); ok {
+		t.Error("Points(FR) reported an open box as used")
+	}
+
+	gs := sc.GameState()
+	if gs.Score != 29 || gs.CategoriesLeft != wantLeft || gs.RollsLeft != RollsPerRound || gs.Round() != 4 {
+		t.Errorf("GameState() = %+v", gs)
+	}
+}
+
+func TestScorecardRecordErrors(t *testing.T) {
+	t.Parallel()
+
+	sc, _ := Scorecard{}.Record(CatMoonberry, 14)
+
+	var catErr *CategoryError
+	if _, err := sc.Record(CatMoonberry, 7); !errors.As(err, &catErr) || !catErr.Used {
+		t.Errorf("Record() on used box error = %v, want used *CategoryError", err)
+	}
+	if _, err := sc.Record(NumCategories, 0); !errors.As(err, &catErr) || catErr.Used {
+		t.Errorf("Record() on invalid category error = %v, want invalid *CategoryError", err)
+	}
+	for _, tt := range []struct {
+		cat    Category
+		points int
+	}{
+		{CatJumbleberry, 3},   // odd
+		{CatJumbleberry, 12},  // six Jumbleberries
+		{CatMoonberry, -7},    // negative
+		{CatBasketOfFive, 11}, // no five of a kind totals 11
+	} {
+		if _, err := sc.Record(tt.cat, tt.points); err == nil {
+			t.Errorf("Record(%v, %d) accepted an impossible score", tt.cat, tt.points)
+		}
+	}
+}
+
+func TestPossibleScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cat    Category
+		points int
+		want   bool
+	}{
+		{CatFreeRoll, 35, true},
+		{CatFreeRoll, 36, false},
+		{CatBasketOfFive, 0, true},
+		{CatBasketOfFive, 10, true},
+		{CatBasketOfFive, 20, true},
+		{CatBasketOfFive, 12, false},
+		{CatMixedBasket, 15, true},
+		{CatMixedBasket, 14, false},
+		{CatPickleberry, 8, true},
+		{CatPickleberry, 6, false},
+	}
+
+	for _, tt := range tests {
+		if got := PossibleScore(tt.cat, tt.points); got != tt.want {
+			t.Errorf("PossibleScore(%v, %d) = %v, want %v", tt.cat, tt.points, got, tt.want)
+		}
+	}
+}
+
+func TestScorecardJSON(t *testing.T) {
+	t.Parallel()
+
+	sc, _ := Scorecard{}.Record(CatSugarberry, 4)
+	sc, _ = sc.Scratch(CatBasketOfFive)
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"Basket of Five":0,"Sugarberry":4}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var got Scorecard
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != sc {
+		t.Errorf("round trip = %+v, want %+v", got, sc)
+	}
+
+	for _, bad := range []string{`{"Chocolate":4}`, `{"Sugarberry":5}`, `[1,2]`} {
+		if err := json.Unmarshal([]byte(bad), &got); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// roundSolvers keeps the round solver for the categories currently left,
+// so a player builds the value layers once per round.
+type roundSolvers struct {
+	table *ev.Table
+	cs    game.CategorySet
+	rs    *solver.RoundSolver
+}
+
+func (r *roundSolvers) get(cs game.CategorySet) *solver.RoundSolver {
+	if r.rs == nil || r.cs != cs {
+		r.cs, r.rs = cs, solver.NewRoundSolver(cs, r.table)
+	}
+	return r.rs
+}
+
+// evPolicy plays the solver's best action against an EV table.
+type evPolicy struct {
+	name  string
+	table *ev.Table
+}
+
+// Optimal returns the policy that maximizes expected final score.
+func Optimal(table *ev.Table) Policy {
+	return &evPolicy{name: "optimal", table: table}
+}
+
+// Greedy returns the policy that maximizes the expected score of the
+// current round, ignoring the value of the categories it uses up.
+func Greedy() Policy {
+	return &evPolicy{name: "greedy", table: ev.NewTable()}
+}
+
+func (p *evPolicy) Name() string {
+	return p.name
+}
+
+func (p *evPolicy) NewPlayer(*rand.Rand) Player {
+	return &evPlayer{solvers: roundSolvers{table: p.table}}
+}
+
+type evPlayer struct {
+	solvers roundSolvers
+}
+
+func (pl *evPlayer) Decide(st game.GameState) Decision {
+	rec := pl.solvers.get(st.CategoriesLeft).Solve(st.CurrentDice, int(st.RollsLeft))
+	if rec.BestAction.Type == solver.RerollAction {
+		return Decision{Reroll: true, Keep: rec.BestAction.Keep}
+	}
+	return Decision{Category: rec.BestAction.Category}
+}
+
+// softmaxPolicy picks each move at random, weighting it by the
+// exponential of its EV over a temperature.
+type softmaxPolicy struct {
+	table *ev.Table
+	temp  float64
+}
+
+// Softmax returns the policy that picks each legal move with probability
+// proportional to exp(EV/temp). Low temperatures approach optimal play;
+// high temperatures approach uniformly random play.
+func Softmax(table *ev.Table, temp float64) Policy {
+	return &softmaxPolicy{table: table, temp: temp}
+}
+
+func (p *softmaxPolicy) Name() string {
+	return "softmax:" + strconv.FormatFloat(p.temp, 'g', -1, 64)
+}
+
+func (p *softmaxPolicy) NewPlayer(rng *rand.Rand) Player {
+	return &softmaxPlayer{p: p, rng: rng, solvers: roundSolvers{table: p.table}}
+}
+
+type softmaxPlayer struct {
+	p       *softmaxPolicy
+	rng     *rand.Rand
+	solvers roundSolvers
+}
+
+func (pl *softmaxPlayer) Decide(st game.GameState) Decision {
+	rs := pl.solvers.get(st.CategoriesLeft)
+	rollsLeft := int(st.RollsLeft)
+
+	var moves []Decision
+	var values []float64
+	if rollsLeft > 0 {
+		for _, opt := range rs.RerollOptions(st.CurrentDice, rollsLeft) {
+			moves = append(moves, Decision{Reroll: true, Keep: opt.Keep})
+			values = append(values, opt.EV)
+		}
+	}
+	st.CategoriesLeft.ForEach(func(c game.Category) {
+		moves = append(moves, Decision{Category: c})
+		values = append(values, rs.ActionEV(st.CurrentDice, rollsLeft, solver.Action{Type: solver.ScoreAction, Category: c}))
+	})
+
+	best := math.Inf(-1)
+	for _, v := range values {
+		best = max(best, v)
+	}
+	var total float64
+	for i, v := range values {
+		values[i] = math.Exp((v - best) / pl.p.temp)
+		total += values[i]
+	}
+	x := pl.rng.Float64() * total
+	for i, w := range values {
+		if x < w {
+			return moves[i]
+		}
+		x -= w
+	}
+	return moves[len(moves)-1]
+}
@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"math/rand/v2"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// scratchOrder lists categories from the first to the last a heuristic
+// player gives up when nothing open scores.
+var scratchOrder = []game.Category{
+	game.CatBasketOfFive,
+	game.CatJumbleberry,
+	game.CatSugarberry,
+	game.CatPickleberry,
+	game.CatBasketOfFour,
+	game.CatMoonberry,
+	game.CatMixedBasket,
+	game.CatBasketOfThree,
+	game.CatFreeRoll,
+}
+
+// heuristicPolicy plays simple rules of thumb without an EV table.
+type heuristicPolicy struct{}
+
+// Heuristic returns a rule-of-thumb policy, as a casual player might play:
+// chase a Mixed Basket when three or more berry kinds are showing and it
+// is open, otherwise hold the most common berry (the more valuable one on
+// ties) and reroll the rest; score the open category worth the most now,
+// stopping early only on a made Mixed Basket, which it scores, or five of
+// a kind. It needs no EV table.
+func Heuristic() Policy {
+	return heuristicPolicy{}
+}
+
+func (heuristicPolicy) Name() string {
+	return "heuristic"
+}
+
+func (heuristicPolicy) NewPlayer(*rand.Rand) Player {
+	return heuristicPlayer{}
+}
+
+type heuristicPlayer struct{}
+
+func (heuristicPlayer) Decide(st game.GameState) Decision {
+	d := st.CurrentDice
+	if st.CategoriesLeft.Has(game.CatMixedBasket) && game.Qualifies(d, game.CatMixedBasket) {
+		return Decision{Category: game.CatMixedBasket}
+	}
+	if st.RollsLeft > 0 {
+		if keep := heuristicKeep(d, st.CategoriesLeft); keep != d {
+			return Decision{Reroll: true, Keep: keep}
+		}
+	}
+	return Decision{Category: heuristicCategory(d, st.CategoriesLeft)}
+}
+
+// heuristicKeep returns the dice to hold from d.
+func heuristicKeep(d game.Dice, cs game.CategorySet) game.Dice {
+	var kinds int
+	var one game.Dice
+	for b := game.Jumbleberry; b <= game.Moonberry; b++ {
+		if d[b] > 0 {
+			kinds++
+			one[b] = 1
+		}
+	}
+	var most game.Berry
+	for b := game.Jumbleberry; b <= game.Moonberry; b++ {
+		if d[b] >= d[most] {
+			most = b // berries are in increasing value
+		}
+	}
+	if cs.Has(game.CatMixedBasket) && kinds >= 3 && d[most] < 3 {
+		return one
+	}
+	var keep game.Dice
+	keep[most] = d[most]
+	return keep
+}
+
+// heuristicCategory returns the open category worth the most for d,
+// scratching by scratchOrder when none scores.
+func heuristicCategory(d game.Dice, cs game.CategorySet) game.Category {
+	best, bestCat := 0, game.Category(0)
+	cs.ForEach(func(c game.Category) {
+		if s := game.Score(d, c); s > best {
+			best, bestCat = s, c
+		}
+	})
+	if best > 0 {
+		return bestCat
+	}
+	for _, c := range scratchOrder {
+		if cs.Has(c) {
+			return c
+		}
+	}
+	return bestCat
+}
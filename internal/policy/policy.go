@@ -0,0 +1,153 @@
+// Package policy defines strategies for playing Jumbleberry Fields and
+// plays games with them, so simulations and tournaments can compare
+// strategies on equal terms.
+package policy
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/record"
+)
+
+// Decision is a move with the dice showing: reroll all dice except Keep,
+// or score them in Category.
+type Decision struct {
+	Reroll   bool
+	Keep     game.Dice     // dice held, if Reroll
+	Category game.Category // category scored, if not Reroll
+}
+
+// Policy is a strategy. A Policy is safe for concurrent use; the state of
+// one game lives in the Player it returns.
+type Policy interface {
+	// Name returns the policy's spec, as accepted by Parse.
+	Name() string
+	// NewPlayer returns a player for one game. Randomized policies draw
+	// from rng, which is independent of the dice.
+	NewPlayer(rng *rand.Rand) Player
+}
+
+// Player makes the decisions of one game.
+type Player interface {
+	// Decide returns the move for st, which always has dice showing
+	// (st.RollsLeft < game.RollsPerRound). A Keep must be part of the
+	// dice and a Category must be open.
+	Decide(st game.GameState) Decision
+}
+
+// Play plays the rest of a game from start with p, rolling from src. It
+// returns the record of the rounds played; the final score is start.Score
+// plus the record's total. When start is a new game the record carries a
+// Result tag.
+func Play(src game.DiceSource, p Player, start game.GameState) (*record.Game, error) {
+	eng, err := game.NewEngineAt(src, start)
+	if err != nil {
+		return nil, err
+	}
+	g := &record.Game{}
+
+	for !eng.State().GameOver() {
+		dice := eng.State().CurrentDice
+		if eng.State().RollsLeft == game.RollsPerRound {
+			if dice, err = eng.Roll(); err != nil {
+				return nil, err
+			}
+		}
+		round := record.Round{Rolls: []game.Roll{game.RollFromDice(dice)}}
+
+		for {
+			d := p.Decide(eng.State())
+			if !d.Reroll {
+				points, err := eng.Score(d.Category)
+				if err != nil {
+					return nil, err
+				}
+				round.Scored, round.Category, round.Points = true, d.Category, points
+				break
+			}
+			if dice, err = eng.Keep(d.Keep); err != nil {
+				return nil, err
+			}
+			round.Keeps = append(round.Keeps, d.Keep)
+			round.Rolls = append(round.Rolls, game.RollFromDice(dice))
+		}
+		g.Rounds = append(g.Rounds, round)
+	}
+
+	total, err := eng.Finish()
+	if err != nil {
+		return nil, err
+	}
+	// The record holds only the rounds played, so a Result tag would not
+	// match its total for a game picked up mid-way.
+	if start == game.NewGame() {
+		g.SetTag(record.TagResult, strconv.Itoa(total))
+	}
+	return g, nil
+}
+
+// Parse returns the policy named by spec:
+//   - "optimal": maximizes expected final score
+//   - "greedy": maximizes the score of the current round only
+//   - "heuristic": rules of thumb (see Heuristic)
+//   - "softmax:T": picks moves at random with probability proportional
+//     to exp(EV/T), e.g. "softmax:2"
+//   - "target:N": maximizes the chance of finishing with at least N
+//   - "risk:A": risk-averse, with exponential utility of risk aversion A,
+//     e.g. "risk:0.05"
+//
+// Policies that need expected values use table.
+func Parse(spec string, table *ev.Table) (Policy, error) {
+	name, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	switch name {
+	case "optimal", "greedy", "heuristic":
+		if hasArg {
+			return nil, fmt.Errorf("policy %q takes no parameter", name)
+		}
+		switch name {
+		case "greedy":
+			return Greedy(), nil
+		case "heuristic":
+			return Heuristic(), nil
+		}
+		return Optimal(table), nil
+	case "softmax", "risk":
+		x, err := strconv.ParseFloat(arg, 64)
+		if !hasArg || err != nil || x <= 0 || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("policy %q: want %s:X with X > 0", spec, name)
+		}
+		if name == "risk" {
+			return RiskAverse(table, x), nil
+		}
+		return Softmax(table, x), nil
+	case "target":
+		n, err := strconv.Atoi(arg)
+		if !hasArg || err != nil || n <= 0 {
+			return nil, fmt.Errorf("policy %q: want target:N with a score N > 0", spec)
+		}
+		return TargetScore(table, n), nil
+	}
+	return nil, fmt.Errorf("unknown policy %q", spec)
+}
+
+// ParseList parses a comma-separated list of policy specs.
+func ParseList(specs string, table *ev.Table) ([]Policy, error) {
+	var policies []Policy
+	for _, spec := range strings.Split(specs, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		p, err := Parse(spec, table)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
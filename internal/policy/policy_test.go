@@ -0,0 +1,284 @@
+package policy
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/record"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+var (
+	testTableOnce sync.Once
+	testTable     *ev.Table
+)
+
+// computedTable returns a freshly computed EV table, shared across tests.
+func computedTable() *ev.Table {
+	testTableOnce.Do(func() {
+		testTable = ev.Compute(nil)
+	})
+	return testTable
+}
+
+// dice returns a PCG dice source for game i.
+func dice(i uint64) game.DiceSource {
+	return game.NewRandSource(rand.New(rand.NewPCG(1, i)))
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "optimal", want: "optimal"},
+		{spec: " Greedy ", want: "greedy"},
+		{spec: "softmax:2", want: "softmax:2"},
+		{spec: "softmax:0.5", want: "softmax:0.5"},
+		{spec: "heuristic", want: "heuristic"},
+		{spec: "target:150", want: "target:150"},
+		{spec: "risk:0.05", want: "risk:0.05"},
+		{spec: "softmax", wantErr: true},
+		{spec: "target:x", wantErr: true},
+		{spec: "target:0", wantErr: true},
+		{spec: "risk:-1", wantErr: true},
+		{spec: "softmax:0", wantErr: true},
+		{spec: "softmax:x", wantErr: true},
+		{spec: "optimal:1", wantErr: true},
+		{spec: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.spec, table)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) succeeded, want error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if p.Name() != tt.want {
+			t.Errorf("Parse(%q).Name() = %q, want %q", tt.spec, p.Name(), tt.want)
+		}
+	}
+
+	list, err := ParseList("optimal, greedy,,softmax:1", table)
+	if err != nil || len(list) != 3 {
+		t.Errorf("ParseList() = %d policies, %v; want 3, nil", len(list), err)
+	}
+}
+
+func TestPlayValidGames(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	for _, p := range []Policy{Optimal(table), Greedy(), Softmax(table, 1), Heuristic(), TargetScore(table, 140), RiskAverse(table, 0.1)} {
+		for i := range uint64(5) {
+			g, err := Play(dice(i), p.NewPlayer(rand.New(rand.NewPCG(2, i))), game.NewGame())
+			if err != nil {
+				t.Fatalf("%s: Play() error = %v", p.Name(), err)
+			}
+			if err := g.Validate(); err != nil {
+				t.Errorf("%s: game %d invalid: %v", p.Name(), i, err)
+			}
+			if !g.Finished() {
+				t.Errorf("%s: game %d has %d rounds, want finished", p.Name(), i, len(g.Rounds))
+			}
+			if got := g.Tag(record.TagResult); got != strconv.Itoa(g.Total()) {
+				t.Errorf("%s: Result tag = %q, want %d", p.Name(), got, g.Total())
+			}
+		}
+	}
+}
+
+func TestPlayFromMidRound(t *testing.T) {
+	t.Parallel()
+
+	start := game.GameState{
+		CurrentDice:    game.Dice{0, 0, 0, 4, 1},
+		RollsLeft:      1,
+		CategoriesLeft: game.CategorySet(0).Add(game.CatBasketOfFive).Add(game.CatMixedBasket),
+		Score:          90,
+	}
+	g, err := Play(dice(0), Optimal(computedTable()).NewPlayer(nil), start)
+	if err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if len(g.Rounds) != 2 {
+		t.Fatalf("played %d rounds, want 2", len(g.Rounds))
+	}
+	if first := g.Rounds[0]; first.Rolls[0] != game.RollFromDice(start.CurrentDice) || len(first.Keeps) > 1 {
+		t.Errorf("first round = %+v, want it to continue from %v with at most one reroll", first, start.CurrentDice)
+	}
+	if g.Tag(record.TagResult) != "" {
+		t.Errorf("mid-game record has Result tag %q", g.Tag(record.TagResult))
+	}
+}
+
+func TestSoftmaxLowTemperatureIsOptimal(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	cold := Softmax(table, 1e-9).NewPlayer(rand.New(rand.NewPCG(3, 0)))
+	opt := Optimal(table).NewPlayer(nil)
+
+	rng := rand.New(rand.NewPCG(4, 0))
+	all := game.AllDice()
+	for range 50 {
+		st := game.GameState{
+			CurrentDice:    all[rng.IntN(len(all))],
+			RollsLeft:      uint8(rng.IntN(game.RollsPerRound)),
+			CategoriesLeft: game.CategorySet(1 + rng.IntN(int(game.AllCategories))),
+		}
+		// Compare values, since ties may be broken either way.
+		rs := solver.NewRoundSolver(st.CategoriesLeft, table)
+		value := func(d Decision) float64 {
+			a := solver.Action{Type: solver.ScoreAction, Category: d.Category}
+			if d.Reroll {
+				a = solver.Action{Type: solver.RerollAction, Keep: d.Keep}
+			}
+			return rs.ActionEV(st.CurrentDice, int(st.RollsLeft), a)
+		}
+		if got, want := cold.Decide(st), opt.Decide(st); math.Abs(value(got)-value(want)) > 1e-9 {
+			t.Errorf("Decide(%v): softmax %+v, optimal %+v", st, got, want)
+		}
+	}
+}
+
+func TestOptimalBeatsGreedy(t *testing.T) {
+	t.Parallel()
+
+	// Same dice streams for both; optimal play should win on average.
+	table := computedTable()
+	var optimal, greedy int
+	for i := range uint64(200) {
+		og, err := Play(dice(i), Optimal(table).NewPlayer(nil), game.NewGame())
+		if err != nil {
+			t.Fatal(err)
+		}
+		gg, err := Play(dice(i), Greedy().NewPlayer(nil), game.NewGame())
+		if err != nil {
+			t.Fatal(err)
+		}
+		optimal += og.Total()
+		greedy += gg.Total()
+	}
+	if optimal <= greedy {
+		t.Errorf("optimal total %d <= greedy total %d over 200 games", optimal, greedy)
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	t.Parallel()
+
+	all := game.AllCategories
+	noMix := all.Remove(game.CatMixedBasket)
+	tests := []struct {
+		name string
+		st   game.GameState
+		want Decision
+	}{
+		{
+			name: "hold most common berry",
+			st:   game.GameState{CurrentDice: game.Dice{2, 0, 0, 1, 2}, RollsLeft: 2, CategoriesLeft: all},
+			want: Decision{Reroll: true, Keep: game.Dice{2, 0, 0, 0, 0}},
+		},
+		{
+			name: "ties go to the more valuable berry",
+			st:   game.GameState{CurrentDice: game.Dice{2, 0, 2, 0, 1}, RollsLeft: 1, CategoriesLeft: all},
+			want: Decision{Reroll: true, Keep: game.Dice{0, 0, 2, 0, 0}},
+		},
+		{
+			name: "chase mixed basket",
+			st:   game.GameState{CurrentDice: game.Dice{2, 1, 0, 1, 1}, RollsLeft: 2, CategoriesLeft: all},
+			want: Decision{Reroll: true, Keep: game.Dice{1, 1, 0, 1, 0}},
+		},
+		{
+			name: "score a made mixed basket",
+			st:   game.GameState{CurrentDice: game.Dice{2, 1, 1, 1, 0}, RollsLeft: 2, CategoriesLeft: all},
+			want: Decision{Category: game.CatMixedBasket},
+		},
+		{
+			name: "no mixed basket when used",
+			st:   game.GameState{CurrentDice: game.Dice{2, 1, 0, 1, 1}, RollsLeft: 2, CategoriesLeft: noMix},
+			want: Decision{Reroll: true, Keep: game.Dice{2, 0, 0, 0, 0}},
+		},
+		{
+			name: "stop on five of a kind",
+			st:   game.GameState{CurrentDice: game.Dice{0, 0, 0, 5, 0}, RollsLeft: 2, CategoriesLeft: all},
+			want: Decision{Category: game.CatMoonberry},
+		},
+		{
+			name: "score the most points",
+			st:   game.GameState{CurrentDice: game.Dice{1, 1, 1, 2, 0}, CategoriesLeft: all},
+			want: Decision{Category: game.CatMixedBasket},
+		},
+		{
+			name: "scratch by preference",
+			st:   game.GameState{CurrentDice: game.Dice{0, 0, 0, 0, 5}, CategoriesLeft: noMix},
+			want: Decision{Category: game.CatBasketOfFive},
+		},
+	}
+	for _, tt := range tests {
+		if got := Heuristic().NewPlayer(nil).Decide(tt.st); got != tt.want {
+			t.Errorf("%s: Decide() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTargetScoreLastRound(t *testing.T) {
+	t.Parallel()
+
+	// Last round, Free Roll left, needing 25: 4M+S scores 30 now, but a
+	// target of 35 (exactly 5M) is worth chasing.
+	st := game.GameState{
+		CurrentDice:    game.Dice{0, 1, 0, 4, 0},
+		RollsLeft:      1,
+		CategoriesLeft: game.CategorySet(0).Add(game.CatFreeRoll),
+		Score:          100,
+	}
+	table := computedTable()
+	if got := TargetScore(table, 125).NewPlayer(nil).Decide(st); got.Reroll {
+		t.Errorf("target 125: Decide() = %+v, want to score", got)
+	}
+	want := Decision{Reroll: true, Keep: game.Dice{0, 0, 0, 4, 0}}
+	if got := TargetScore(table, 135).NewPlayer(nil).Decide(st); got != want {
+		t.Errorf("target 135: Decide() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRiskAverseNarrowsSpread(t *testing.T) {
+	t.Parallel()
+
+	// A strongly risk-averse player should have a smaller spread of
+	// final scores than optimal play on the same dice.
+	table := computedTable()
+	spread := func(p Policy) float64 {
+		var sum, sumSq float64
+		for i := range uint64(200) {
+			g, err := Play(dice(i), p.NewPlayer(nil), game.NewGame())
+			if err != nil {
+				t.Fatal(err)
+			}
+			x := float64(g.Total())
+			sum += x
+			sumSq += x * x
+		}
+		mean := sum / 200
+		return sumSq/200 - mean*mean
+	}
+	if opt, averse := spread(Optimal(table)), spread(RiskAverse(table, 0.3)); averse >= opt {
+		t.Errorf("risk-averse variance %.1f >= optimal variance %.1f", averse, opt)
+	}
+}
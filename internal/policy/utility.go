@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// utilityFunc values ending a round with points banked and the categories
+// left after it, given the score before the round.
+type utilityFunc func(score, points int, left game.CategorySet) float64
+
+// utilityPolicy plays each round to maximize the expected utility of how
+// the round ends. Unlike Optimal it can trade expected points for a better
+// chance of some outcome.
+type utilityPolicy struct {
+	name    string
+	model   *game.DiceModel
+	utility utilityFunc
+}
+
+// futureModel approximates the points still to come from a set of open
+// categories as normally distributed. The mean is the EV table's; the
+// variance sums, over the open categories, the variance of the category's
+// score when it is the only one left. The approximation ignores how
+// categories compete for the same dice.
+type futureModel struct {
+	table    *ev.Table
+	variance [game.NumCategories]float64
+}
+
+func newFutureModel(table *ev.Table) *futureModel {
+	m := &futureModel{table: table}
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		rs := solver.NewRoundSolver(game.CategorySet(0).Add(c), table)
+		out := rs.Outcomes(game.Dice{}, game.RollsPerRound)
+		var sumSq float64
+		for _, sp := range out.ScoreDist {
+			sumSq += sp.Prob * float64(sp.Score) * float64(sp.Score)
+		}
+		m.variance[c] = max(sumSq-out.ExpectedScore*out.ExpectedScore, 0)
+	}
+	return m
+}
+
+// moments returns the mean and variance of the points to come from cs.
+func (m *futureModel) moments(cs game.CategorySet) (mean, variance float64) {
+	cs.ForEach(func(c game.Category) {
+		variance += m.variance[c]
+	})
+	return m.table.EV(cs), variance
+}
+
+// TargetScore returns the policy that maximizes the chance of finishing
+// with at least target points, estimating the chance from a normal
+// approximation of the points still to come.
+func TargetScore(table *ev.Table, target int) Policy {
+	m := newFutureModel(table)
+	return &utilityPolicy{
+		name:  "target:" + strconv.Itoa(target),
+		model: table.Model(),
+		utility: func(score, points int, left game.CategorySet) float64 {
+			need := float64(target - score - points)
+			mean, variance := m.moments(left)
+			if variance == 0 {
+				if mean >= need {
+					return 1
+				}
+				return 0
+			}
+			// P(future >= need), with a continuity correction.
+			z := (need - 0.5 - mean) / math.Sqrt(variance)
+			return 0.5 * math.Erfc(z/math.Sqrt2)
+		},
+	}
+}
+
+// RiskAverse returns the policy that maximizes expected exponential
+// utility -exp(-a*final) with risk aversion a > 0: it gives up expected
+// points to narrow the spread of final scores, more so as a grows. The
+// points still to come are approximated as normally distributed.
+func RiskAverse(table *ev.Table, a float64) Policy {
+	m := newFutureModel(table)
+	return &utilityPolicy{
+		name:  "risk:" + strconv.FormatFloat(a, 'g', -1, 64),
+		model: table.Model(),
+		utility: func(_, points int, left game.CategorySet) float64 {
+			// The score so far only scales every utility by the same
+			// factor, so it is left out.
+			mean, variance := m.moments(left)
+			return -math.Exp(-a*(float64(points)+mean) + a*a*variance/2)
+		},
+	}
+}
+
+func (p *utilityPolicy) Name() string {
+	return p.name
+}
+
+func (p *utilityPolicy) NewPlayer(*rand.Rand) Player {
+	return &utilityPlayer{p: p}
+}
+
+type utilityPlayer struct {
+	p *utilityPolicy
+
+	// Value layers of the current round, built on its first decision.
+	cs     game.CategorySet
+	score  uint16
+	layers [game.RollsPerRound][]float64 // layers[r][diceIdx] with r rerolls left
+	built  bool
+}
+
+// round builds the value layers for a round with cs open after score
+// points, unless they are already built.
+func (pl *utilityPlayer) round(cs game.CategorySet, score uint16) {
+	if pl.built && pl.cs == cs && pl.score == score {
+		return
+	}
+	pl.cs, pl.score, pl.built = cs, score, true
+
+	allDice := game.AllDice()
+	v0 := make([]float64, len(allDice))
+	for i, d := range allDice {
+		_, v0[i] = pl.bestCategory(d)
+	}
+	pl.layers[0] = v0
+	for r := 1; r < game.RollsPerRound; r++ {
+		pl.layers[r] = make([]float64, len(allDice))
+		ev.ComputeRerollLayerWith(pl.p.model, allDice, pl.layers[r-1], pl.layers[r])
+	}
+}
+
+// bestCategory returns the open category with the highest utility for
+// scoring d, and that utility.
+func (pl *utilityPlayer) bestCategory(d game.Dice) (game.Category, float64) {
+	best, bestCat := math.Inf(-1), game.Category(0)
+	pl.cs.ForEach(func(c game.Category) {
+		if u := pl.p.utility(int(pl.score), game.Score(d, c), pl.cs.Remove(c)); u > best {
+			best, bestCat = u, c
+		}
+	})
+	return bestCat, best
+}
+
+func (pl *utilityPlayer) Decide(st game.GameState) Decision {
+	pl.round(st.CategoriesLeft, st.Score)
+	cat, scoreNow := pl.bestCategory(st.CurrentDice)
+	if st.RollsLeft == 0 {
+		return Decision{Category: cat}
+	}
+
+	next := pl.layers[st.RollsLeft-1]
+	bestKeep, bestValue := game.Dice{}, math.Inf(-1)
+	ev.EnumerateKeeps(st.CurrentDice, func(keep game.Dice, numKept int) {
+		if numKept == game.NumDice {
+			return
+		}
+		var value float64
+		for _, ro := range pl.p.model.Rerolls(game.NumDice - numKept) {
+			value += ro.Prob * next[game.DiceIndex(game.AddDice(keep, ro.Dice))]
+		}
+		if value > bestValue {
+			bestKeep, bestValue = keep, value
+		}
+	})
+	if bestValue > scoreNow {
+		return Decision{Reroll: true, Keep: bestKeep}
+	}
+	return Decision{Category: cat}
+}
@@ -0,0 +1,55 @@
+// This file provides JSON export for generated puzzles.
+package puzzle
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// PuzzleJSON is the JSON-friendly representation of a Puzzle.
+type PuzzleJSON struct {
+	ID          string            `json:"id"`
+	Kind        Kind              `json:"kind"`
+	Dice        string            `json:"dice"`
+	RollsLeft   int               `json:"rolls_left"`
+	Categories  []string          `json:"categories"`
+	Solution    solver.ActionJSON `json:"solution"`
+	Intuitive   solver.ActionJSON `json:"intuitive"`
+	EVGap       float64           `json:"ev_gap"`
+	Surprise    float64           `json:"surprise"`
+	Explanation string            `json:"explanation"`
+}
+
+// ToJSON converts a Puzzle to its JSON-friendly form.
+func ToJSON(p Puzzle) PuzzleJSON {
+	var names []string
+	p.Categories.ForEach(func(cat game.Category) {
+		names = append(names, cat.String())
+	})
+	return PuzzleJSON{
+		ID:          p.ID,
+		Kind:        p.Kind,
+		Dice:        solver.FormatKeep(p.Dice),
+		RollsLeft:   p.RollsLeft,
+		Categories:  names,
+		Solution:    solver.ActionToJSON(p.Solution),
+		Intuitive:   solver.ActionToJSON(p.Intuitive),
+		EVGap:       p.EVGap,
+		Surprise:    p.Surprise,
+		Explanation: p.Explanation(),
+	}
+}
+
+// WriteJSON writes puzzles to w as an indented JSON array.
+func WriteJSON(w io.Writer, puzzles []Puzzle) error {
+	out := make([]PuzzleJSON, 0, len(puzzles))
+	for _, p := range puzzles {
+		out = append(out, ToJSON(p))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
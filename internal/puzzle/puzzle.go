@@ -0,0 +1,224 @@
+// Package puzzle searches the game's state space for counter-intuitive
+// positions: states where the solver's optimal action differs from the
+// points-now choice most players would make by a meaningful EV margin.
+package puzzle
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// Kind classifies how the optimal action departs from the intuitive one.
+type Kind string
+
+const (
+	KindScratch   Kind = "scratch"    // optimal scores 0 while points were available
+	KindBreakHand Kind = "break-hand" // optimal rerolls a hand the intuitive player would score
+	KindStopEarly Kind = "stop-early" // optimal scores while the intuitive player would reroll
+	KindSacrifice Kind = "sacrifice"  // both score, but in different categories
+	KindKeep      Kind = "keep"       // both reroll, but keep different dice
+)
+
+// Puzzle is one counter-intuitive position together with its solution.
+type Puzzle struct {
+	ID         string
+	Kind       Kind
+	Dice       game.Dice
+	RollsLeft  int
+	Categories game.CategorySet
+	Solution   solver.Action // the optimal action
+	Intuitive  solver.Action // the points-now action, EV evaluated under optimal play
+	EVGap      float64       // Solution.EV - Intuitive.EV
+	Surprise   float64       // expected points this round the solution gives up
+}
+
+// Options controls which positions Generate searches and keeps.
+type Options struct {
+	MinGap        float64 // minimum EV gap for a position to count as a puzzle
+	MinCategories int     // skip category sets smaller than this
+	Limit         int     // maximum number of puzzles returned (0 = no limit)
+}
+
+// DefaultOptions returns the options used by cmd/puzzle when no flags are set.
+func DefaultOptions() Options {
+	return Options{
+		MinGap:        0.5,
+		MinCategories: 1,
+	}
+}
+
+// tieEpsilon treats myopic values this close as equally tempting.
+const tieEpsilon = 1e-9
+
+// Generate searches every category set, dice outcome and rolls-left value
+// for puzzles, ranked by descending surprise and then EV gap.
+func Generate(table *ev.Table, opts Options) []Puzzle {
+	myopic := ev.NewTable() // all-zero table: only this round's score counts
+	var puzzles []Puzzle
+
+	for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+		if cs.Count() < opts.MinCategories {
+			continue
+		}
+		rs := solver.NewRoundSolver(cs, table)
+		greedy := solver.NewRoundSolver(cs, myopic)
+
+		for _, dice := range game.AllDice() {
+			for rollsLeft := 0; rollsLeft <= 2; rollsLeft++ {
+				p, ok := evaluate(dice, rollsLeft, cs, rs, greedy)
+				if ok && p.EVGap >= opts.MinGap {
+					puzzles = append(puzzles, p)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(puzzles, func(i, j int) bool {
+		if puzzles[i].Surprise != puzzles[j].Surprise {
+			return puzzles[i].Surprise > puzzles[j].Surprise
+		}
+		return puzzles[i].EVGap > puzzles[j].EVGap
+	})
+
+	if opts.Limit > 0 && len(puzzles) > opts.Limit {
+		puzzles = puzzles[:opts.Limit]
+	}
+	return puzzles
+}
+
+// evaluate compares the optimal action for one state with the intuitive
+// action. It reports false if the two agree.
+func evaluate(dice game.Dice, rollsLeft int, cs game.CategorySet, rs, greedy *solver.RoundSolver) (Puzzle, bool) {
+	best := rs.Solve(dice, rollsLeft).BestAction
+
+	// Every action is a candidate for the intuitive choice, valued by the
+	// points it is expected to bank this round.
+	type candidate struct {
+		action solver.Action
+		myopic float64
+	}
+	var candidates []candidate
+	cs.ForEach(func(cat game.Category) {
+		candidates = append(candidates, candidate{
+			action: solver.Action{Type: solver.ScoreAction, Category: cat},
+			myopic: float64(game.Score(dice, cat)),
+		})
+	})
+	if rollsLeft > 0 {
+		for _, opt := range greedy.RerollOptions(dice, rollsLeft) {
+			candidates = append(candidates, candidate{
+				action: solver.Action{Type: solver.RerollAction, Keep: opt.Keep},
+				myopic: opt.EV,
+			})
+		}
+	}
+
+	bestMyopic := math.Inf(-1)
+	for _, c := range candidates {
+		bestMyopic = math.Max(bestMyopic, c.myopic)
+	}
+
+	// The intuitive player maximizes this round's points, breaking ties
+	// the way the solver would.
+	var intuitive solver.Action
+	intuitiveEV := math.Inf(-1)
+	solutionMyopic := 0.0
+	for _, c := range candidates {
+		if c.action.SameMove(best) {
+			solutionMyopic = c.myopic
+		}
+		if c.myopic < bestMyopic-tieEpsilon {
+			continue
+		}
+		if v := rs.ActionEV(dice, rollsLeft, c.action); v > intuitiveEV {
+			intuitiveEV = v
+			intuitive = c.action
+		}
+	}
+	intuitive.EV = intuitiveEV
+
+	if intuitive.SameMove(best) {
+		return Puzzle{}, false
+	}
+
+	p := Puzzle{
+		ID:         fmt.Sprintf("%03x-%s-%d", uint16(cs), diceLetters(dice), rollsLeft),
+		Dice:       dice,
+		RollsLeft:  rollsLeft,
+		Categories: cs,
+		Solution:   best,
+		Intuitive:  intuitive,
+		EVGap:      best.EV - intuitiveEV,
+		Surprise:   bestMyopic - solutionMyopic,
+	}
+	p.Kind = classify(p)
+	return p, true
+}
+
+func classify(p Puzzle) Kind {
+	sol, in := p.Solution, p.Intuitive
+	switch {
+	case sol.Type == solver.RerollAction && in.Type == solver.RerollAction:
+		return KindKeep
+	case sol.Type == solver.RerollAction:
+		return KindBreakHand
+	case in.Type == solver.RerollAction:
+		return KindStopEarly
+	case game.Score(p.Dice, sol.Category) == 0:
+		return KindScratch
+	default:
+		return KindSacrifice
+	}
+}
+
+// Explanation returns a short sentence describing why the solution beats
+// the intuitive action.
+func (p Puzzle) Explanation() string {
+	sol, in := p.Solution, p.Intuitive
+	switch p.Kind {
+	case KindScratch, KindSacrifice:
+		return fmt.Sprintf("Scoring %d in %s looks natural, but scoring %d in %s keeps %s open for later rounds and is worth %.2f more.",
+			game.Score(p.Dice, in.Category), in.Category,
+			game.Score(p.Dice, sol.Category), sol.Category, in.Category, p.EVGap)
+	case KindBreakHand:
+		return fmt.Sprintf("Banking %d in %s is tempting, but keeping %s and rerolling is worth %.2f more.",
+			game.Score(p.Dice, in.Category), in.Category, solver.FormatKeep(sol.Keep), p.EVGap)
+	case KindStopEarly:
+		return fmt.Sprintf("Keeping %s and rerolling for more points looks natural, but scoring %d in %s now is worth %.2f more.",
+			solver.FormatKeep(in.Keep), game.Score(p.Dice, sol.Category), sol.Category, p.EVGap)
+	default:
+		return fmt.Sprintf("Keeping %s gives the best score this round, but keeping %s is worth %.2f more over the game.",
+			solver.FormatKeep(in.Keep), solver.FormatKeep(sol.Keep), p.EVGap)
+	}
+}
+
+// OfTheDay deterministically picks one puzzle for the given date, so every
+// player sees the same puzzle on the same day. It reports false if
+// puzzles is empty.
+func OfTheDay(puzzles []Puzzle, date time.Time) (Puzzle, bool) {
+	if len(puzzles) == 0 {
+		return Puzzle{}, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(date.Format(time.DateOnly)))
+	return puzzles[h.Sum64()%uint64(len(puzzles))], true
+}
+
+// diceLetters returns the dice as a sorted letter sequence, e.g. "JJSPM".
+func diceLetters(d game.Dice) string {
+	letters := [game.NumBerryTypes]byte{'J', 'S', 'P', 'M', 'X'}
+	var out []byte
+	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+		for range d[b] {
+			out = append(out, letters[b])
+		}
+	}
+	return string(out)
+}
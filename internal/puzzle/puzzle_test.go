@@ -0,0 +1,175 @@
+package puzzle
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	table := ev.Compute(nil)
+	opts := Options{MinGap: 0.25, MinCategories: 8}
+	puzzles := Generate(table, opts)
+	if len(puzzles) == 0 {
+		t.Fatal("Generate() returned no puzzles")
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range puzzles {
+		if p.EVGap < opts.MinGap {
+			t.Errorf("puzzle %s: EVGap = %v, want >= %v", p.ID, p.EVGap, opts.MinGap)
+		}
+		if p.Categories.Count() < opts.MinCategories {
+			t.Errorf("puzzle %s: %d categories, want >= %d", p.ID, p.Categories.Count(), opts.MinCategories)
+		}
+		if p.Solution.SameMove(p.Intuitive) {
+			t.Errorf("puzzle %s: solution and intuitive action are the same", p.ID)
+		}
+		if seen[p.ID] {
+			t.Errorf("duplicate puzzle ID %s", p.ID)
+		}
+		seen[p.ID] = true
+
+		if i > 0 && puzzles[i-1].Surprise < p.Surprise {
+			t.Errorf("puzzles not sorted by surprise at index %d", i)
+		}
+
+		// The solution must match what the solver recommends.
+		rec := solver.Solve(p.Dice, p.RollsLeft, p.Categories, table)
+		if !rec.BestAction.SameMove(p.Solution) {
+			t.Errorf("puzzle %s: solution %+v, solver says %+v", p.ID, p.Solution, rec.BestAction)
+		}
+	}
+
+	limited := Generate(table, Options{MinGap: 0.25, MinCategories: 8, Limit: 3})
+	if len(limited) != 3 {
+		t.Errorf("Generate() with Limit 3 returned %d puzzles", len(limited))
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	score := func(c game.Category) solver.Action {
+		return solver.Action{Type: solver.ScoreAction, Category: c}
+	}
+	reroll := func(k game.Dice) solver.Action {
+		return solver.Action{Type: solver.RerollAction, Keep: k}
+	}
+
+	tests := []struct {
+		name      string
+		dice      game.Dice
+		solution  solver.Action
+		intuitive solver.Action
+		want      Kind
+	}{
+		{
+			name:      "scratch",
+			dice:      game.Dice{1, 1, 1, 1, 1},
+			solution:  score(game.CatBasketOfFive),
+			intuitive: score(game.CatFreeRoll),
+			want:      KindScratch,
+		},
+		{
+			name:      "sacrifice",
+			dice:      game.Dice{3, 1, 1, 0, 0},
+			solution:  score(game.CatJumbleberry),
+			intuitive: score(game.CatFreeRoll),
+			want:      KindSacrifice,
+		},
+		{
+			name:      "break hand",
+			dice:      game.Dice{1, 1, 1, 1, 1},
+			solution:  reroll(game.Dice{0, 0, 0, 1, 0}),
+			intuitive: score(game.CatMixedBasket),
+			want:      KindBreakHand,
+		},
+		{
+			name:      "stop early",
+			dice:      game.Dice{0, 0, 0, 3, 2},
+			solution:  score(game.CatBasketOfThree),
+			intuitive: reroll(game.Dice{0, 0, 0, 3, 0}),
+			want:      KindStopEarly,
+		},
+		{
+			name:      "keep",
+			dice:      game.Dice{2, 1, 1, 1, 0},
+			solution:  reroll(game.Dice{0, 0, 0, 1, 0}),
+			intuitive: reroll(game.Dice{2, 0, 0, 0, 0}),
+			want:      KindKeep,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := Puzzle{Dice: tt.dice, Solution: tt.solution, Intuitive: tt.intuitive}
+			if got := classify(p); got != tt.want {
+				t.Errorf("classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOfTheDay(t *testing.T) {
+	t.Parallel()
+
+	puzzles := []Puzzle{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	day := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	first, ok := OfTheDay(puzzles, day)
+	if !ok {
+		t.Fatal("OfTheDay() reported no puzzle")
+	}
+	// Any time on the same date must pick the same puzzle.
+	again, _ := OfTheDay(puzzles, day.Add(12*time.Hour))
+	if first.ID != again.ID {
+		t.Errorf("OfTheDay() not stable within a day: %q vs %q", first.ID, again.ID)
+	}
+
+	if _, ok := OfTheDay(nil, day); ok {
+		t.Error("OfTheDay(nil) reported a puzzle")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	p := Puzzle{
+		ID:         "1ff-JJSPM-0",
+		Kind:       KindScratch,
+		Dice:       game.Dice{2, 1, 1, 1, 0},
+		Categories: game.AllCategories,
+		Solution:   solver.Action{Type: solver.ScoreAction, Category: game.CatBasketOfFive, EV: 110},
+		Intuitive:  solver.Action{Type: solver.ScoreAction, Category: game.CatFreeRoll, EV: 109},
+		EVGap:      1,
+		Surprise:   17,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, []Puzzle{p}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var got []PuzzleJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d puzzles, want 1", len(got))
+	}
+	if got[0].Solution.Category != "Basket of Five" || got[0].Explanation == "" {
+		t.Errorf("unexpected puzzle JSON: %+v", got[0])
+	}
+	if len(got[0].Categories) != int(game.NumCategories) {
+		t.Errorf("got %d categories, want %d", len(got[0].Categories), game.NumCategories)
+	}
+}
@@ -0,0 +1,101 @@
+// Package rating maintains Elo ratings for players of multi-player games
+// decided by score.
+package rating
+
+import (
+	"math"
+	"slices"
+)
+
+// InitialRating is the rating of a player before their first game.
+const InitialRating = 1500
+
+// DefaultK is the usual Elo update factor.
+const DefaultK = 16
+
+// Elo holds the Elo ratings of named players.
+type Elo struct {
+	k       float64
+	ratings map[string]float64
+	games   map[string]int
+}
+
+// NewElo returns an empty rating pool with update factor k.
+func NewElo(k float64) *Elo {
+	return &Elo{k: k, ratings: make(map[string]float64), games: make(map[string]int)}
+}
+
+// Rating returns the rating of player, or InitialRating if they have not
+// played.
+func (e *Elo) Rating(player string) float64 {
+	if r, ok := e.ratings[player]; ok {
+		return r
+	}
+	return InitialRating
+}
+
+// Games returns the number of games player has been rated in.
+func (e *Elo) Games(player string) int {
+	return e.games[player]
+}
+
+// Expected returns the expected result of a against b: the chance a wins,
+// counting a tie as half a win.
+func (e *Elo) Expected(a, b string) float64 {
+	return 1 / (1 + math.Pow(10, (e.Rating(b)-e.Rating(a))/400))
+}
+
+// Update rates one game in which players[i] scored scores[i]. Every pair
+// of players counts as a game between the two, won by the higher score,
+// with the update factor divided by the number of opponents so a game
+// moves a rating as much as a two-player game would. All changes are
+// computed from the ratings before the game.
+func (e *Elo) Update(players []string, scores []int) {
+	if len(players) < 2 {
+		return
+	}
+	k := e.k / float64(len(players)-1)
+	delta := make([]float64, len(players))
+	for a := range players {
+		for b := a + 1; b < len(players); b++ {
+			result := 0.5
+			switch {
+			case scores[a] > scores[b]:
+				result = 1
+			case scores[a] < scores[b]:
+				result = 0
+			}
+			d := k * (result - e.Expected(players[a], players[b]))
+			delta[a] += d
+			delta[b] -= d
+		}
+	}
+	for i, p := range players {
+		e.ratings[p] = e.Rating(p) + delta[i]
+		e.games[p]++
+	}
+}
+
+// Players returns the rated players, highest rating first, ties by name.
+func (e *Elo) Players() []string {
+	players := make([]string, 0, len(e.ratings))
+	for p := range e.ratings {
+		players = append(players, p)
+	}
+	slices.SortFunc(players, func(a, b string) int {
+		if ra, rb := e.ratings[a], e.ratings[b]; ra != rb {
+			if ra > rb {
+				return -1
+			}
+			return 1
+		}
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	})
+	return players
+}
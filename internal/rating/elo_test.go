@@ -0,0 +1,71 @@
+package rating
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestEloExpected(t *testing.T) {
+	t.Parallel()
+
+	e := NewElo(DefaultK)
+	if got := e.Expected("a", "b"); got != 0.5 {
+		t.Errorf("Expected() between new players = %v, want 0.5", got)
+	}
+	e.ratings["a"] = 1900
+	if got := e.Expected("a", "b"); math.Abs(got-10.0/11) > 1e-12 {
+		t.Errorf("Expected() 400 points up = %v, want 10/11", got)
+	}
+	if sum := e.Expected("a", "b") + e.Expected("b", "a"); math.Abs(sum-1) > 1e-12 {
+		t.Errorf("Expected(a, b) + Expected(b, a) = %v, want 1", sum)
+	}
+}
+
+func TestEloUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		players []string
+		scores  []int
+		want    []float64
+	}{
+		{"win", []string{"a", "b"}, []int{130, 120}, []float64{1508, 1492}},
+		{"tie", []string{"a", "b"}, []int{120, 120}, []float64{1500, 1500}},
+		{"three players", []string{"a", "b", "c"}, []int{100, 140, 120}, []float64{1492, 1508, 1500}},
+	}
+	for _, tt := range tests {
+		e := NewElo(DefaultK)
+		e.Update(tt.players, tt.scores)
+		for i, p := range tt.players {
+			if got := e.Rating(p); math.Abs(got-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: Rating(%s) = %v, want %v", tt.name, p, got, tt.want[i])
+			}
+			if e.Games(p) != 1 {
+				t.Errorf("%s: Games(%s) = %d, want 1", tt.name, p, e.Games(p))
+			}
+		}
+	}
+}
+
+func TestEloConservesRating(t *testing.T) {
+	t.Parallel()
+
+	e := NewElo(DefaultK)
+	games := [][]int{{120, 130, 110}, {100, 140, 90}, {125, 150, 110}, {100, 140, 95}}
+	players := []string{"x", "y", "z"}
+	for _, scores := range games {
+		e.Update(players, scores)
+	}
+	var total float64
+	for _, p := range players {
+		total += e.Rating(p)
+	}
+	if math.Abs(total-3*InitialRating) > 1e-9 {
+		t.Errorf("total rating = %v, want %v", total, 3*InitialRating)
+	}
+	if got, want := e.Players(), []string{"y", "x", "z"}; !slices.Equal(got, want) {
+		t.Errorf("Players() = %v, want %v", got, want)
+	}
+}
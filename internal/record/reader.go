@@ -0,0 +1,446 @@
+package record
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// Mode selects how strictly a Reader checks the notation.
+type Mode int
+
+const (
+	// Strict accepts only the notation Writer produces: numbered rounds
+	// in order, upper-case faces, quoted tag values, and recorded points
+	// that match the dice. A Result tag must match the final score.
+	Strict Mode = iota
+
+	// Lenient accepts hand-written records: faces, keeps and category
+	// codes in any case, missing or out-of-order round numbers, unquoted
+	// tag values, and missing points ("3k" or "3k=?"). Points are always
+	// recomputed from the dice and the Result tag is not checked. Rule
+	// violations such as keeping dice that were not rolled are still
+	// errors.
+	Lenient
+)
+
+// ParseError reports a problem at a line of the input.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// line is one non-blank input line with its 1-based number.
+type line struct {
+	num  int
+	text string
+}
+
+// Reader reads games in record notation.
+type Reader struct {
+	sc      *bufio.Scanner
+	mode    Mode
+	lineNum int
+	pending []line // a block read ahead but not yet consumed
+}
+
+// NewReader returns a Reader that reads from r in the given mode.
+func NewReader(r io.Reader, mode Mode) *Reader {
+	return &Reader{sc: bufio.NewScanner(r), mode: mode}
+}
+
+// Read reads the next game. It returns io.EOF when there are no more.
+func (r *Reader) Read() (*Game, error) {
+	block, err := r.nextBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game{}
+	i := 0
+	for ; i < len(block) && strings.HasPrefix(block[i].text, "["); i++ {
+		tag, err := r.parseTag(block[i].text)
+		if err != nil {
+			return nil, &ParseError{Line: block[i].num, Err: err}
+		}
+		g.Tags = append(g.Tags, tag)
+	}
+	movetext := block[i:]
+
+	// The movetext normally follows the tags after a blank line.
+	if len(movetext) == 0 && len(g.Tags) > 0 {
+		next, err := r.nextBlock()
+		switch {
+		case err == io.EOF:
+		case err != nil:
+			return nil, err
+		case strings.HasPrefix(next[0].text, "["):
+			r.pending = next // tags of the next game
+		default:
+			movetext = next
+		}
+	}
+
+	if err := r.parseMovetext(g, movetext); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ReadAll reads all remaining games.
+func (r *Reader) ReadAll() ([]*Game, error) {
+	var games []*Game
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, g)
+	}
+}
+
+// Parse parses a single game from s.
+func Parse(s string, mode Mode) (*Game, error) {
+	g, err := NewReader(strings.NewReader(s), mode).Read()
+	if err == io.EOF {
+		return nil, errors.New("no game in input")
+	}
+	return g, err
+}
+
+// nextBlock returns the next run of non-blank lines, or io.EOF.
+func (r *Reader) nextBlock() ([]line, error) {
+	if r.pending != nil {
+		block := r.pending
+		r.pending = nil
+		return block, nil
+	}
+
+	var block []line
+	for r.sc.Scan() {
+		r.lineNum++
+		text := strings.TrimSpace(r.sc.Text())
+		if text == "" {
+			if len(block) > 0 {
+				return block, nil
+			}
+			continue
+		}
+		block = append(block, line{num: r.lineNum, text: text})
+	}
+	if err := r.sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(block) == 0 {
+		return nil, io.EOF
+	}
+	return block, nil
+}
+
+// parseTag parses a tag line like [Seed "42"].
+func (r *Reader) parseTag(text string) (Tag, error) {
+	if !strings.HasSuffix(text, "]") {
+		return Tag{}, fmt.Errorf("tag %q is missing ']'", text)
+	}
+	name, value, _ := strings.Cut(strings.TrimSpace(text[1:len(text)-1]), " ")
+	if !validTagName(name) {
+		return Tag{}, fmt.Errorf("invalid tag name %q", name)
+	}
+	value = strings.TrimSpace(value)
+
+	unquoted, err := strconv.Unquote(value)
+	switch {
+	case err == nil && strings.HasPrefix(value, `"`):
+		value = unquoted
+	case r.mode == Strict:
+		return Tag{}, fmt.Errorf("tag %s value %s is not a quoted string", name, value)
+	}
+	return Tag{Name: name, Value: value}, nil
+}
+
+// token is one movetext token: a round number, roll, keep, score or
+// comment.
+type token struct {
+	line int
+	text string
+}
+
+// tokenize splits movetext into tokens. A comment in braces is a single
+// token and may span lines.
+func tokenize(lines []line) ([]token, error) {
+	var toks []token
+	var comment *token
+	for _, l := range lines {
+		text := l.text
+		for len(text) > 0 {
+			if comment != nil {
+				end := strings.IndexByte(text, '}')
+				if end < 0 {
+					comment.text += " " + text
+					break
+				}
+				comment.text += " " + text[:end+1]
+				toks = append(toks, *comment)
+				comment = nil
+				text = text[end+1:]
+				continue
+			}
+
+			text = strings.TrimLeftFunc(text, unicode.IsSpace)
+			if text == "" {
+				break
+			}
+			if text[0] == '{' {
+				end := strings.IndexByte(text, '}')
+				if end < 0 {
+					comment = &token{line: l.num, text: text}
+					break
+				}
+				toks = append(toks, token{line: l.num, text: text[:end+1]})
+				text = text[end+1:]
+				continue
+			}
+			end := strings.IndexFunc(text, func(c rune) bool { return unicode.IsSpace(c) || c == '{' })
+			if end < 0 {
+				end = len(text)
+			}
+			toks = append(toks, token{line: l.num, text: text[:end]})
+			text = text[end:]
+		}
+	}
+	if comment != nil {
+		return nil, &ParseError{Line: comment.line, Err: errors.New("unterminated comment")}
+	}
+	return toks, nil
+}
+
+// parseMovetext parses the rounds of g from the movetext lines and checks
+// them against the rules.
+func (r *Reader) parseMovetext(g *Game, lines []line) error {
+	toks, err := tokenize(lines)
+	if err != nil {
+		return err
+	}
+
+	var (
+		roundLines []int // line where each round starts
+		cur        *Round
+		keep       *game.Dice // held dice awaiting the roll that follows
+		numbered   bool       // a round number has been read for the next round
+	)
+	for _, tok := range toks {
+		fail := func(format string, args ...any) error {
+			return &ParseError{Line: tok.line, Err: fmt.Errorf(format, args...)}
+		}
+		text := tok.text
+
+		switch {
+		case text[0] == '{':
+			if cur == nil {
+				return fail("comment before the first round")
+			}
+			c := strings.TrimSpace(text[1 : len(text)-1])
+			if cur.Comment != "" {
+				c = cur.Comment + " " + c
+			}
+			cur.Comment = c
+
+		case text[len(text)-1] == '.' && isDigits(text[:len(text)-1]):
+			if cur != nil && (!cur.Scored || keep != nil) {
+				return fail("round %d is not finished", len(g.Rounds))
+			}
+			n, _ := strconv.Atoi(text[:len(text)-1])
+			if r.mode == Strict && n != len(g.Rounds)+1 {
+				return fail("round number %d, want %d", n, len(g.Rounds)+1)
+			}
+			if numbered {
+				return fail("round number %d has no roll", n)
+			}
+			numbered = true
+
+		case len(text) >= 2 && strings.EqualFold(text[:2], "k:"):
+			if cur == nil || cur.Scored || numbered {
+				return fail("keep %q outside a round", text)
+			}
+			if keep != nil {
+				return fail("keep %q follows another keep", text)
+			}
+			k, err := r.parseKeep(text[2:])
+			if err != nil {
+				return fail("%v", err)
+			}
+			keep = &k
+
+		case strings.Contains(text, "=") || r.mode == Lenient && isCategoryCode(text):
+			if cur == nil || cur.Scored || numbered {
+				return fail("score %q outside a round", text)
+			}
+			if keep != nil {
+				return fail("score %q follows a keep", text)
+			}
+			cat, points, err := r.parseScore(text)
+			if err != nil {
+				return fail("%v", err)
+			}
+			cur.Scored, cur.Category, cur.Points = true, cat, points
+
+		default:
+			roll, err := r.parseRoll(text)
+			if err != nil {
+				return fail("%v", err)
+			}
+			if keep != nil {
+				cur.Keeps = append(cur.Keeps, *keep)
+				cur.Rolls = append(cur.Rolls, roll)
+				keep = nil
+				continue
+			}
+			if cur != nil && !cur.Scored {
+				return fail("roll %s without a keep", text)
+			}
+			if r.mode == Strict && !numbered {
+				return fail("roll %s is missing its round number", text)
+			}
+			g.Rounds = append(g.Rounds, Round{Rolls: []game.Roll{roll}})
+			roundLines = append(roundLines, tok.line)
+			cur = &g.Rounds[len(g.Rounds)-1]
+			numbered = false
+		}
+	}
+	if keep != nil {
+		return &ParseError{Line: roundLines[len(roundLines)-1], Err: errors.New("keep without the roll that followed")}
+	}
+	if numbered {
+		return &ParseError{Line: toks[len(toks)-1].line, Err: errors.New("round number without a roll")}
+	}
+
+	if r.mode == Lenient {
+		for i := range g.Rounds {
+			if rd := &g.Rounds[i]; rd.Scored && rd.Category < game.NumCategories {
+				rd.Points = game.Score(rd.Final().Dice(), rd.Category)
+			}
+		}
+	}
+
+	if err := g.Validate(); err != nil {
+		var re *RoundError
+		if errors.As(err, &re) {
+			return &ParseError{Line: roundLines[re.Round-1], Err: err}
+		}
+		return err
+	}
+
+	if r.mode == Strict && g.Finished() {
+		if result := g.Tag(TagResult); result != "" && result != strconv.Itoa(g.Total()) {
+			return &ParseError{Line: lines[0].num, Err: fmt.Errorf("result %s does not match the total %d", result, g.Total())}
+		}
+	}
+	return nil
+}
+
+// parseRoll parses five face letters.
+func (r *Reader) parseRoll(text string) (game.Roll, error) {
+	if len(text) != game.NumDice {
+		return game.Roll{}, fmt.Errorf("unrecognized token %q", text)
+	}
+	var roll game.Roll
+	for i := 0; i < len(text); i++ {
+		b, ok := r.parseFace(text[i])
+		if !ok {
+			return game.Roll{}, fmt.Errorf("unknown face %q in roll %s", text[i], text)
+		}
+		roll[i] = b
+	}
+	return roll, nil
+}
+
+// parseKeep parses the held dice after "k:". Lenient mode also accepts
+// an empty keep for holding nothing.
+func (r *Reader) parseKeep(text string) (game.Dice, error) {
+	var keep game.Dice
+	if text == "-" || text == "" && r.mode == Lenient {
+		return keep, nil
+	}
+	if text == "" {
+		return keep, errors.New(`empty keep (write "k:-" to hold nothing)`)
+	}
+	if len(text) > game.NumDice {
+		return keep, fmt.Errorf("keep %q holds more than %d dice", text, game.NumDice)
+	}
+	for i := 0; i < len(text); i++ {
+		b, ok := r.parseFace(text[i])
+		if !ok {
+			return game.Dice{}, fmt.Errorf("unknown face %q in keep %s", text[i], text)
+		}
+		keep[b]++
+	}
+	return keep, nil
+}
+
+// parseFace parses one face letter, case-insensitively in lenient mode.
+func (r *Reader) parseFace(c byte) (game.Berry, bool) {
+	if r.mode == Strict && c >= 'a' && c <= 'z' {
+		return 0, false
+	}
+	return game.ParseBerry(c)
+}
+
+// parseScore parses "code=points". In lenient mode the points may be
+// missing or "?"; they are recomputed from the dice later.
+func (r *Reader) parseScore(text string) (game.Category, int, error) {
+	code, points, hasPoints := strings.Cut(text, "=")
+	if r.mode == Lenient {
+		code = strings.ToLower(code)
+	}
+	cat, ok := game.ParseCategoryCode(code)
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown category %q", code)
+	}
+
+	switch {
+	case r.mode == Lenient && (!hasPoints || points == "" || points == "?"):
+		return cat, 0, nil
+	case points == "-":
+		return cat, 0, nil
+	case isDigits(points):
+		n, err := strconv.Atoi(points)
+		return cat, n, err
+	}
+	return 0, 0, fmt.Errorf("invalid points %q for %s", points, code)
+}
+
+// isCategoryCode reports whether text is a category code in any case.
+func isCategoryCode(text string) bool {
+	_, ok := game.ParseCategoryCode(strings.ToLower(text))
+	return ok
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
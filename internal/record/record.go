@@ -0,0 +1,203 @@
+// Package record reads and writes played games in a portable text
+// notation, so the simulator, CLI and analysis tools can exchange games.
+//
+// A record is an optional tag section followed by the movetext, separated
+// by a blank line. Several records in one file are separated by blank
+// lines:
+//
+//	[Date "2026-10-18"]
+//	[Seed "42"]
+//	[Result "131"]
+//
+//	1. JJSPM k:M SXJPM k:PM MMPSP 3k=23
+//	2. MMMMX 4k=28 {stopped early}
+//
+// Each tag is a name and a quoted string value. Conventional names are
+// listed as Tag* constants; any other name is allowed.
+//
+// Each round starts with its number and a period, then the initial roll as
+// five face letters (J, S, P, M, X) in table order. A reroll is written as
+// "k:" followed by the letters of the held dice ("k:-" holds nothing) and
+// the full roll that resulted. The round ends with the scored category
+// code and its points, "-" for a scratch: "mix=-". A comment in braces may
+// follow any token of a round and is attached to that round. Only the last
+// round of an unfinished game may be left unscored.
+package record
+
+import (
+	"fmt"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// Conventional tag names.
+const (
+	TagEvent   = "Event"
+	TagDate    = "Date"   // YYYY-MM-DD
+	TagPlayer  = "Player" // player or strategy name
+	TagSeed    = "Seed"   // dice source seed, for simulated games
+	TagRuleset = "Ruleset"
+	TagResult  = "Result" // final score of a finished game
+)
+
+// Tag is one header entry of a record.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is one played game: its tags in file order and its rounds.
+type Game struct {
+	Tags   []Tag
+	Rounds []Round
+}
+
+// Round is one round of play.
+type Round struct {
+	Rolls    []game.Roll // the initial roll, then the roll after each reroll
+	Keeps    []game.Dice // Keeps[i] is held from Rolls[i] to make Rolls[i+1]
+	Scored   bool        // false only for the unfinished last round of a game
+	Category game.Category
+	Points   int
+	Comment  string
+}
+
+// Final returns the last roll of the round.
+func (r Round) Final() game.Roll {
+	return r.Rolls[len(r.Rolls)-1]
+}
+
+// Tag returns the value of the named tag, or "" if it is not set.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// SetTag sets the named tag, replacing an existing value or appending a
+// new tag.
+func (g *Game) SetTag(name, value string) {
+	for i, t := range g.Tags {
+		if t.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// Total returns the sum of the points scored in every round.
+func (g *Game) Total() int {
+	total := 0
+	for _, r := range g.Rounds {
+		if r.Scored {
+			total += r.Points
+		}
+	}
+	return total
+}
+
+// Finished reports whether every category has been scored.
+func (g *Game) Finished() bool {
+	return len(g.Rounds) == int(game.NumCategories) && g.Rounds[len(g.Rounds)-1].Scored
+}
+
+// Scorecard returns the scorecard after the scored rounds.
+func (g *Game) Scorecard() (game.Scorecard, error) {
+	var sc game.Scorecard
+	for i, r := range g.Rounds {
+		if !r.Scored {
+			continue
+		}
+		var err error
+		if sc, err = sc.Record(r.Category, r.Points); err != nil {
+			return game.Scorecard{}, fmt.Errorf("round %d: %w", i+1, err)
+		}
+	}
+	return sc, nil
+}
+
+// RoundError reports a rule violation in one round of a game.
+type RoundError struct {
+	Round int // 1-based
+	Err   error
+}
+
+func (e *RoundError) Error() string {
+	return fmt.Sprintf("round %d: %v", e.Round, e.Err)
+}
+
+func (e *RoundError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that the game follows the rules: at most two rerolls
+// per round, every reroll keeps dice that were showing, each category is
+// scored once with the points its dice earn, and only the last round may
+// be unscored. Violations in a round are reported as a *RoundError.
+func (g *Game) Validate() error {
+	used := game.CategorySet(0)
+	for i, r := range g.Rounds {
+		if i == int(game.NumCategories) {
+			return &RoundError{Round: i + 1, Err: fmt.Errorf("a game has only %d rounds", game.NumCategories)}
+		}
+		if err := r.validate(); err != nil {
+			return &RoundError{Round: i + 1, Err: err}
+		}
+		if !r.Scored {
+			if i != len(g.Rounds)-1 {
+				return &RoundError{Round: i + 1, Err: fmt.Errorf("not scored")}
+			}
+			continue
+		}
+		if used.Has(r.Category) {
+			return &RoundError{Round: i + 1, Err: &game.CategoryError{Category: r.Category, Used: true}}
+		}
+		used = used.Add(r.Category)
+	}
+	return nil
+}
+
+// validate checks a single round against the rules.
+func (r Round) validate() error {
+	if len(r.Rolls) == 0 {
+		return fmt.Errorf("no roll")
+	}
+	if len(r.Rolls) > game.RollsPerRound {
+		return fmt.Errorf("%d rerolls, at most %d allowed", len(r.Rolls)-1, game.RollsPerRound-1)
+	}
+	if len(r.Keeps) != len(r.Rolls)-1 {
+		return fmt.Errorf("%d keeps for %d rerolls", len(r.Keeps), len(r.Rolls)-1)
+	}
+	for i, keep := range r.Keeps {
+		if !contains(r.Rolls[i].Dice(), keep) {
+			return &game.KeepError{Keep: keep, Dice: r.Rolls[i].Dice()}
+		}
+		if !contains(r.Rolls[i+1].Dice(), keep) {
+			return fmt.Errorf("roll %s after keeping %s lost held dice", r.Rolls[i+1], formatKeep(keep))
+		}
+	}
+	if !r.Scored {
+		return nil
+	}
+	if r.Category >= game.NumCategories {
+		return &game.CategoryError{Category: r.Category}
+	}
+	if want := game.Score(r.Final().Dice(), r.Category); r.Points != want {
+		return fmt.Errorf("%s scores %d in %s, recorded %d", r.Final(), want, r.Category, r.Points)
+	}
+	return nil
+}
+
+// contains reports whether every die in sub is also in d.
+func contains(d, sub game.Dice) bool {
+	for b := range d {
+		if sub[b] > d[b] {
+			return false
+		}
+	}
+	return true
+}
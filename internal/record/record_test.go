@@ -0,0 +1,245 @@
+package record
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+const sampleGame = `[Date "2026-10-18"]
+[Seed "42"]
+[Player "optimal \"v2\""]
+
+1. JJSPM k:M SXJPM k:PM MMPSP 3k=-
+2. MMMMX 4k=28 {stopped early}
+3. JSPMX k:JSPM JSPMS mix=17
+`
+
+func TestParseStrict(t *testing.T) {
+	t.Parallel()
+
+	g, err := Parse(sampleGame, Strict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := g.Tag(TagSeed); got != "42" {
+		t.Errorf("Seed tag = %q, want %q", got, "42")
+	}
+	if got := g.Tag(TagPlayer); got != `optimal "v2"` {
+		t.Errorf("Player tag = %q", got)
+	}
+	if len(g.Rounds) != 3 {
+		t.Fatalf("%d rounds, want 3", len(g.Rounds))
+	}
+
+	r := g.Rounds[0]
+	if len(r.Rolls) != 3 || len(r.Keeps) != 2 {
+		t.Fatalf("round 1 has %d rolls, %d keeps", len(r.Rolls), len(r.Keeps))
+	}
+	if r.Rolls[1].String() != "SXJPM" || r.Keeps[1] != (game.Dice{0, 0, 1, 1, 0}) {
+		t.Errorf("round 1 = %+v", r)
+	}
+	if !r.Scored || r.Category != game.CatBasketOfThree || r.Points != 0 {
+		t.Errorf("round 1 score = %v %d", r.Category, r.Points)
+	}
+	if g.Rounds[1].Comment != "stopped early" {
+		t.Errorf("round 2 comment = %q", g.Rounds[1].Comment)
+	}
+	if g.Total() != 45 || g.Finished() {
+		t.Errorf("Total() = %d, Finished() = %v", g.Total(), g.Finished())
+	}
+
+	sc, err := g.Scorecard()
+	if err != nil {
+		t.Fatalf("Scorecard() error = %v", err)
+	}
+	if sc.Total() != 45 || !sc.Scratched(game.CatBasketOfThree) {
+		t.Errorf("Scorecard() = %+v", sc)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	g, err := Parse(sampleGame, Strict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	out, err := Format(g)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if out != sampleGame {
+		t.Errorf("Format() =\n%s\nwant\n%s", out, sampleGame)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	games := []*Game{
+		{Rounds: []Round{{Rolls: []game.Roll{{0, 0, 1, 2, 3}}}}},
+		{Tags: []Tag{{TagResult, "4"}}},
+		{Tags: []Tag{{TagEvent, "test"}}, Rounds: []Round{{
+			Rolls: []game.Roll{{3, 3, 3, 3, 3}}, Scored: true, Category: game.CatBasketOfFive, Points: 35,
+		}}},
+	}
+	for _, g := range games {
+		if err := w.Write(g); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	got, err := NewReader(strings.NewReader(sb.String()), Strict).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v\n%s", err, sb.String())
+	}
+	if len(got) != len(games) {
+		t.Fatalf("ReadAll() returned %d games, want %d:\n%s", len(got), len(games), sb.String())
+	}
+	if len(got[0].Tags) != 0 || len(got[0].Rounds) != 1 || got[0].Rounds[0].Scored {
+		t.Errorf("game 1 = %+v", got[0])
+	}
+	if got[1].Tag(TagResult) != "4" || len(got[1].Rounds) != 0 {
+		t.Errorf("game 2 = %+v", got[1])
+	}
+	if got[2].Tag(TagEvent) != "test" || got[2].Total() != 35 {
+		t.Errorf("game 3 = %+v", got[2])
+	}
+
+	if _, err := NewReader(strings.NewReader("\n\n"), Strict).Read(); err != io.EOF {
+		t.Errorf("Read() of empty input error = %v, want io.EOF", err)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	t.Parallel()
+
+	input := `[Seed 42]
+jjspm K:m sxjpm k:pm MMPSP 3K
+2. mmmmx 4k=99 {hand-typed
+  over two lines}
+7. JSPMX k: JSPMX mix=?`
+
+	if _, err := Parse(input, Strict); err == nil {
+		t.Fatal("Parse(Strict) accepted lenient notation")
+	}
+
+	g, err := Parse(input, Lenient)
+	if err != nil {
+		t.Fatalf("Parse(Lenient) error = %v", err)
+	}
+	if g.Tag(TagSeed) != "42" {
+		t.Errorf("Seed tag = %q", g.Tag(TagSeed))
+	}
+	want := []int{0, 28, 15}
+	for i, r := range g.Rounds {
+		if r.Points != want[i] {
+			t.Errorf("round %d points = %d, want %d (recomputed)", i+1, r.Points, want[i])
+		}
+	}
+	if g.Rounds[1].Comment != "hand-typed over two lines" {
+		t.Errorf("round 2 comment = %q", g.Rounds[1].Comment)
+	}
+	if g.Rounds[2].Keeps[0] != (game.Dice{}) {
+		t.Errorf("empty keep = %v", g.Rounds[2].Keeps[0])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		mode    Mode
+		wantErr string
+		line    int
+	}{
+		{"wrong points", "1. MMMMX 4k=27", Strict, "scores 28", 1},
+		{"round number", "1. MMMMX 4k=28\n3. JJJJJ j=10", Strict, "round number 3", 2},
+		{"missing number", "MMMMX 4k=28", Strict, "missing its round number", 1},
+		{"lowercase face", "1. mmmmx 4k=28", Strict, "unknown face", 1},
+		{"unquoted tag", "[Seed 42]\n\n1. MMMMX 4k=28", Strict, "not a quoted string", 1},
+		{"result mismatch", "[Result \"1\"]\n\n" + fullGame, Strict, "does not match", 3},
+		{"keep not rolled", "1. JJSPM k:MM MMSPM m=14", Lenient, "cannot keep", 1},
+		{"keep lost", "1. JJSPM k:M JJSPS s=4", Lenient, "lost held dice", 1},
+		{"three rerolls", "1. JJSPM k:M JJSPM k:M JJSPM k:M JJSPM m=7", Lenient, "at most 2", 1},
+		{"category reused", "1. JJSPM m=7\n2. JJSPM m=7", Lenient, "already been used", 2},
+		{"unscored middle round", "1. JJSPM\n2. JJSPM m=7", Lenient, "not finished", 2},
+		{"keep without roll", "1. JJSPM k:M", Lenient, "keep without", 1},
+		{"roll without keep", "1. JJSPM JJSPM", Lenient, "without a keep", 1},
+		{"unknown category", "1. JJSPM q=7", Lenient, "unknown category", 1},
+		{"unterminated comment", "1. JJSPM m=7 {oops", Lenient, "unterminated", 1},
+		{"junk", "1. JJSP m=7", Lenient, "unrecognized", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse(tt.input, tt.mode)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want containing %q", err, tt.wantErr)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != tt.line {
+				t.Errorf("Parse() error = %#v, want *ParseError at line %d", err, tt.line)
+			}
+		})
+	}
+}
+
+// fullGame is a finished game totalling 89.
+const fullGame = `1. JJJJJ j=10
+2. SSSSS 5k=10
+3. JSPMX mix=15
+4. MMJJJ m=14
+5. JJJJS 4k=10
+6. SSSJJ 3k=10
+7. JSJSJ fr=10
+8. JSPMX k:JSPM JSPMX s=2
+9. PPXXX p=8
+`
+
+func TestFinishedGame(t *testing.T) {
+	t.Parallel()
+
+	g, err := Parse(fullGame, Strict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !g.Finished() {
+		t.Error("Finished() = false for a full game")
+	}
+	g.SetTag(TagResult, "89")
+	out, err := Format(g)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if _, err := Parse(out, Strict); err != nil {
+		t.Errorf("Parse() of game with correct result error = %v", err)
+	}
+}
+
+func TestWriterRejects(t *testing.T) {
+	t.Parallel()
+
+	bad := []*Game{
+		{Tags: []Tag{{"Bad Name", "x"}}},
+		{Rounds: []Round{{Rolls: []game.Roll{{}}, Comment: "a } b"}}},
+	}
+	for _, g := range bad {
+		if _, err := Format(g); err == nil {
+			t.Errorf("Format(%+v) succeeded, want error", g)
+		}
+	}
+}
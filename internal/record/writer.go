@@ -0,0 +1,118 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// Writer writes games in record notation. Games are separated by a blank
+// line. Output is buffered; call Flush when done.
+type Writer struct {
+	w       *bufio.Writer
+	written bool
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes one game. It returns an error without writing anything if
+// a tag name is not a plain word or a comment contains a closing brace.
+//
+// A reader takes the movetext after a tag section as belonging to it, so
+// a game with tags but no rounds must not be followed by a game without
+// tags.
+func (w *Writer) Write(g *Game) error {
+	for _, t := range g.Tags {
+		if !validTagName(t.Name) {
+			return fmt.Errorf("invalid tag name %q", t.Name)
+		}
+	}
+	for i, r := range g.Rounds {
+		if strings.ContainsRune(r.Comment, '}') {
+			return fmt.Errorf("round %d: comment contains '}'", i+1)
+		}
+	}
+
+	if w.written {
+		w.w.WriteByte('\n')
+	}
+	w.written = true
+
+	for _, t := range g.Tags {
+		fmt.Fprintf(w.w, "[%s %s]\n", t.Name, strconv.Quote(t.Value))
+	}
+	if len(g.Tags) > 0 {
+		w.w.WriteByte('\n')
+	}
+	for i, r := range g.Rounds {
+		fmt.Fprintf(w.w, "%d. %s\n", i+1, formatRound(r))
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Format returns the game in record notation.
+func Format(g *Game) (string, error) {
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	if err := w.Write(g); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// formatRound returns the movetext of a round without its number.
+func formatRound(r Round) string {
+	parts := []string{r.Rolls[0].String()}
+	for i, keep := range r.Keeps {
+		parts = append(parts, "k:"+formatKeep(keep), r.Rolls[i+1].String())
+	}
+	if r.Scored {
+		points := "-"
+		if r.Points > 0 {
+			points = strconv.Itoa(r.Points)
+		}
+		parts = append(parts, r.Category.Code()+"="+points)
+	}
+	if r.Comment != "" {
+		parts = append(parts, "{"+r.Comment+"}")
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatKeep returns the letters of the held dice in face order, or "-"
+// if nothing is held.
+func formatKeep(keep game.Dice) string {
+	if keep.Total() == 0 {
+		return "-"
+	}
+	return game.RollFromDice(keep).String()[:keep.Total()]
+}
+
+// validTagName reports whether name is a non-empty run of letters, digits
+// and underscores.
+func validTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+// Package robust solves Jumbleberry Fields for dice that cannot be
+// trusted. Instead of one set of face probabilities it takes a Set of
+// them, such as an L1 ball or per-face intervals around the standard
+// dice, and finds the play that maximizes the worst-case expected score
+// over the set. It also values that play with the nominal dice, so the
+// caller can see how much expected score robustness costs when the dice
+// turn out to be fair.
+//
+// The expectation over a reroll in ev.ComputeRerollLayer becomes a
+// minimum over distributions. The dice of a roll are taken one at a time
+// and an adversary picks the face probabilities of each die from the set,
+// knowing the faces already rolled. Each step is then a linear
+// minimization that Set.Worst solves in closed form, and the worst-case
+// EV is a guarantee for any dice in the set, even dice whose bias changes
+// from roll to roll. Against dice with one fixed bias it is conservative.
+package robust
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// numHands is the size of the base-6 packing of dice counts, which
+// indexes hands of any size from 0 to 5 dice.
+const numHands = 7776
+
+// pack encodes a hand of up to 5 dice as an index below numHands.
+func pack(d game.Dice) int {
+	return int(d[0]) + int(d[1])*6 + int(d[2])*36 + int(d[3])*216 + int(d[4])*1296
+}
+
+// Table holds, for every set of categories left, the worst-case EV of
+// robust play over the rest of the game and the EV of the same play with
+// the nominal dice.
+type Table struct {
+	set     Set
+	model   *game.DiceModel
+	worst   [512]float64
+	nominal [512]float64
+}
+
+// Compute builds the table for dice whose face probabilities may be
+// anything in set. nominal is the model the dice are believed to follow,
+// which must lie in set. It takes well under a second: the adversarial
+// reroll step handles one die at a time instead of every multiset of
+// faces.
+func Compute(set Set, nominal *game.DiceModel) (*Table, error) {
+	if !set.Contains(nominal.FaceProb()) {
+		return nil, fmt.Errorf("nominal dice %s lie outside the %s", nominal, set)
+	}
+	t := &Table{set: set, model: nominal}
+	// Every proper subset of cs is smaller than cs, so counting up fills
+	// in the subsets first.
+	for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+		r := newRound(cs, t)
+		t.worst[cs] = r.worstRoll()
+		t.nominal[cs] = r.nominalRoll()
+	}
+	return t, nil
+}
+
+// Set returns the uncertainty set the table was computed for.
+func (t *Table) Set() Set {
+	return t.set
+}
+
+// Model returns the nominal dice model.
+func (t *Table) Model() *game.DiceModel {
+	return t.model
+}
+
+// EV returns the worst-case expected total score of the remaining rounds
+// with categories cs left, under robust play, before any rolls.
+func (t *Table) EV(cs game.CategorySet) float64 {
+	return t.worst[cs]
+}
+
+// NominalEV returns the expected total score of robust play over the
+// remaining rounds if the dice follow the nominal model.
+func (t *Table) NominalEV(cs game.CategorySet) float64 {
+	return t.nominal[cs]
+}
+
+// RoundSolver answers robust queries for one round with a fixed set of
+// categories left.
+type RoundSolver struct {
+	*round
+}
+
+// NewRoundSolver returns a RoundSolver for the category set cs.
+func NewRoundSolver(cs game.CategorySet, t *Table) *RoundSolver {
+	return &RoundSolver{newRound(cs, t)}
+}
+
+// round holds the value layers of one round under robust play.
+type round struct {
+	cs game.CategorySet
+	t  *Table
+
+	// worst[r] and nominal[r] hold the worst-case and nominal values of
+	// each 5-dice hand with r rerolls left, indexed like game.AllDice.
+	worst, nominal [game.RollsPerRound][]float64
+
+	// keeps[r] holds the worst-case value of holding a hand, indexed by
+	// pack, and rolling the other dice with r rolls left before the roll.
+	keeps [game.RollsPerRound + 1]*[numHands]float64
+}
+
+// newRound builds every layer of the round, the scoring layer first.
+func newRound(cs game.CategorySet, t *Table) *round {
+	r := &round{cs: cs, t: t}
+	allDice := game.AllDice()
+	n := len(allDice)
+
+	r.worst[0], r.nominal[0] = make([]float64, n), make([]float64, n)
+	for i, d := range allDice {
+		cat := r.bestCategory(d)
+		score := float64(game.Score(d, cat))
+		r.worst[0][i] = score + t.worst[cs.Remove(cat)]
+		r.nominal[0][i] = score + t.nominal[cs.Remove(cat)]
+	}
+
+	for rolls := 1; rolls <= game.RollsPerRound; rolls++ {
+		r.keeps[rolls] = rollValues(t.set, r.worst[rolls-1])
+		if rolls == game.RollsPerRound {
+			break
+		}
+		r.worst[rolls], r.nominal[rolls] = make([]float64, n), make([]float64, n)
+		for i, d := range allDice {
+			keep := r.bestKeep(d, rolls)
+			r.worst[rolls][i] = r.keeps[rolls][pack(keep)]
+			r.nominal[rolls][i] = r.nominalKeep(keep, rolls)
+		}
+	}
+	return r
+}
+
+// rollValues returns the worst-case value of every hand of up to 5 dice
+// when the missing dice are rolled and the full hand is worth v. It works
+// down from full hands, adding one adversarial die at a time.
+func rollValues(set Set, v []float64) *[numHands]float64 {
+	vals := new([numHands]float64)
+	for _, d := range game.AllDice() {
+		vals[pack(d)] = v[game.DiceIndex(d)]
+	}
+	for size := game.NumDice - 1; size >= 0; size-- {
+		for _, o := range game.Rerolls(size) {
+			h := o.Dice
+			var next [game.NumBerryTypes]float64
+			for b := range next {
+				h[b]++
+				next[b] = vals[pack(h)]
+				h[b]--
+			}
+			p := set.Worst(next)
+			val := 0.0
+			for b := range next {
+				val += p[b] * next[b]
+			}
+			vals[pack(h)] = val
+		}
+	}
+	return vals
+}
+
+// bestCategory returns the category robust play scores d in, breaking
+// ties in category order as the solver does.
+func (r *round) bestCategory(d game.Dice) game.Category {
+	best, bestVal := game.Category(0), math.Inf(-1)
+	r.cs.ForEach(func(cat game.Category) {
+		if val := float64(game.Score(d, cat)) + r.t.worst[r.cs.Remove(cat)]; val > bestVal {
+			best, bestVal = cat, val
+		}
+	})
+	return best
+}
+
+// bestKeep returns the dice robust play holds from d with rolls rerolls
+// left; holding all five means scoring, which wins ties as in the solver.
+func (r *round) bestKeep(d game.Dice, rolls int) game.Dice {
+	best, bestVal := d, math.Inf(-1)
+	ev.EnumerateKeeps(d, func(keep game.Dice, numKept int) {
+		if numKept == game.NumDice {
+			return
+		}
+		if val := r.keeps[rolls][pack(keep)]; val > bestVal {
+			best, bestVal = keep, val
+		}
+	})
+	if r.keeps[rolls][pack(d)] >= bestVal {
+		return d
+	}
+	return best
+}
+
+// nominalKeep returns the nominal EV of holding keep and rolling the
+// other dice with rolls rolls left, playing robustly afterwards.
+func (r *round) nominalKeep(keep game.Dice, rolls int) float64 {
+	if keep.Total() == game.NumDice {
+		return r.nominal[rolls-1][game.DiceIndex(keep)]
+	}
+	val := 0.0
+	for _, o := range r.t.model.Rerolls(game.NumDice - keep.Total()) {
+		val += o.Prob * r.nominal[rolls-1][game.DiceIndex(game.AddDice(keep, o.Dice))]
+	}
+	return val
+}
+
+// worstRoll returns the worst-case value of the round before any dice are
+// rolled.
+func (r *round) worstRoll() float64 {
+	return r.keeps[game.RollsPerRound][pack(game.Dice{})]
+}
+
+// nominalRoll returns the nominal value of the round before any dice are
+// rolled.
+func (r *round) nominalRoll() float64 {
+	val := 0.0
+	for i := range game.AllDice() {
+		val += r.t.model.FirstRollProb(i) * r.nominal[game.RollsPerRound-1][i]
+	}
+	return val
+}
+
+// Best returns the action that maximizes the worst-case EV, with that EV.
+// rollsLeft is as in solver.Solve; with game.RollsPerRound dice is
+// ignored.
+func (rs *RoundSolver) Best(dice game.Dice, rollsLeft int) solver.Action {
+	switch {
+	case rollsLeft == game.RollsPerRound:
+		return solver.Action{Type: solver.RollAction, EV: rs.worstRoll()}
+	case rollsLeft == 0:
+		cat := rs.bestCategory(dice)
+		return solver.Action{Type: solver.ScoreAction, Category: cat, EV: rs.worst[0][game.DiceIndex(dice)]}
+	}
+	keep := rs.bestKeep(dice, rollsLeft)
+	if keep == dice {
+		cat := rs.bestCategory(dice)
+		return solver.Action{Type: solver.ScoreAction, Category: cat, EV: rs.WorstEV(dice, rollsLeft, solver.Action{Type: solver.ScoreAction, Category: cat})}
+	}
+	return solver.Action{Type: solver.RerollAction, Keep: keep, EV: rs.keeps[rollsLeft][pack(keep)]}
+}
+
+// WorstEV returns the worst-case EV of taking action a, whether or not it
+// is the robust choice, and playing robustly afterwards.
+func (rs *RoundSolver) WorstEV(dice game.Dice, rollsLeft int, a solver.Action) float64 {
+	switch a.Type {
+	case solver.ScoreAction:
+		return float64(game.Score(dice, a.Category)) + rs.t.worst[rs.cs.Remove(a.Category)]
+	case solver.RerollAction:
+		return rs.keeps[rollsLeft][pack(a.Keep)]
+	default:
+		return rs.worstRoll()
+	}
+}
+
+// NominalEV returns the EV of taking action a and playing robustly
+// afterwards, if the dice follow the nominal model.
+func (rs *RoundSolver) NominalEV(dice game.Dice, rollsLeft int, a solver.Action) float64 {
+	switch a.Type {
+	case solver.ScoreAction:
+		return float64(game.Score(dice, a.Category)) + rs.t.nominal[rs.cs.Remove(a.Category)]
+	case solver.RerollAction:
+		return rs.nominalKeep(a.Keep, rollsLeft)
+	default:
+		return rs.nominalRoll()
+	}
+}
+
+// Advice compares robust play in a state with play that trusts the
+// nominal dice.
+type Advice struct {
+	Robust  solver.Action // maximizes the worst-case EV, which is its EV
+	Nominal solver.Action // maximizes the nominal EV, which is its EV
+
+	// Differs reports whether Robust and Nominal are different moves.
+	Differs bool
+
+	// RobustNominal is the nominal EV of robust play from here on, and
+	// NominalWorst the worst-case EV of the nominal move followed by
+	// robust play.
+	RobustNominal float64
+	NominalWorst  float64
+}
+
+// Cost returns the nominal EV given up by playing robustly from here on:
+// what robustness costs if the dice are as believed.
+func (a Advice) Cost() float64 {
+	return a.Nominal.EV - a.RobustNominal
+}
+
+// Protection returns how much the robust move raises the worst-case EV
+// over the nominal move.
+func (a Advice) Protection() float64 {
+	return a.Robust.EV - a.NominalWorst
+}
+
+// Advise solves a state robustly and compares the result with the
+// nominal solver. nominal must be the EV table of t's nominal model.
+func Advise(dice game.Dice, rollsLeft int, cs game.CategorySet, t *Table, nominal *ev.Table) Advice {
+	rs := NewRoundSolver(cs, t)
+	adv := Advice{
+		Robust:  rs.Best(dice, rollsLeft),
+		Nominal: solver.Solve(dice, rollsLeft, cs, nominal).BestAction,
+	}
+	adv.Differs = !adv.Robust.SameMove(adv.Nominal)
+	adv.RobustNominal = rs.NominalEV(dice, rollsLeft, adv.Robust)
+	adv.NominalWorst = rs.WorstEV(dice, rollsLeft, adv.Nominal)
+	return adv
+}
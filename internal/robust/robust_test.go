@@ -0,0 +1,135 @@
+package robust
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+func TestComputeMatchesEV(t *testing.T) {
+	t.Parallel()
+
+	// With nothing to choose, the adversary rolls the nominal dice.
+	nominal := ev.Compute(nil)
+	for _, set := range []Set{
+		L1Ball{Center: game.FaceProb},
+		Intervals{Lo: game.FaceProb, Hi: game.FaceProb},
+	} {
+		tb, err := Compute(set, game.DefaultModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+			want := nominal.EV(cs)
+			if got := tb.EV(cs); math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%v: EV(%v) = %v, want %v", set, cs, got, want)
+			}
+			if got := tb.NominalEV(cs); math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%v: NominalEV(%v) = %v, want %v", set, cs, got, want)
+			}
+		}
+	}
+}
+
+func TestComputeBounds(t *testing.T) {
+	t.Parallel()
+
+	nominal := ev.Compute(nil)
+	prev := nominal
+	var prevTable *Table
+	for _, r := range []float64{0.05, 0.1, 0.2} {
+		tb, err := Compute(L1Ball{Center: game.FaceProb, Radius: r}, game.DefaultModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+			// Worst case <= nominal EV of robust play <= optimal nominal
+			// EV, and a larger ball has a worse worst case.
+			worst, nom, opt := tb.EV(cs), tb.NominalEV(cs), nominal.EV(cs)
+			if worst > nom+1e-9 || nom > opt+1e-9 {
+				t.Fatalf("radius %v, %v: worst %v, nominal %v, optimal %v out of order", r, cs, worst, nom, opt)
+			}
+			if prevTable != nil && worst > prevTable.EV(cs)+1e-9 {
+				t.Fatalf("radius %v, %v: worst case %v above %v for a smaller ball", r, cs, worst, prevTable.EV(cs))
+			}
+		}
+		if got := tb.EV(game.AllCategories); got >= prev.EV(game.AllCategories) {
+			t.Errorf("radius %v: worst case %v not below %v", r, got, prev.EV(game.AllCategories))
+		}
+		prevTable = tb
+	}
+
+	outside := L1Ball{Center: [game.NumBerryTypes]float64{0.2, 0.2, 0.2, 0.2, 0.2}, Radius: 0.1}
+	if _, err := Compute(outside, game.DefaultModel); err == nil {
+		t.Error("Compute with nominal dice outside the set succeeded")
+	}
+}
+
+func TestAdvise(t *testing.T) {
+	t.Parallel()
+
+	nominal := ev.Compute(nil)
+	tb, err := Compute(L1Ball{Center: game.FaceProb, Radius: 0.1}, game.DefaultModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := game.CategorySet(0).Add(game.CatJumbleberry).Add(game.CatMoonberry)
+
+	tests := []struct {
+		name        string
+		dice        string
+		rollsLeft   int
+		wantRobust  solver.Action
+		wantDiffers bool
+	}{
+		{
+			// Three Jumbleberries are safe only if Jumbleberries keep
+			// coming; the adversary cuts them off, so robust play chases
+			// the Moonberries, which score more if they come at all.
+			name:        "robust play chases Moonberries",
+			dice:        "JJJMX",
+			rollsLeft:   1,
+			wantRobust:  solver.Action{Type: solver.RerollAction, Keep: game.Dice{0, 0, 0, 1, 0}},
+			wantDiffers: true,
+		},
+		{
+			name:       "both score a full Moonberry hand",
+			dice:       "MMMMM",
+			rollsLeft:  1,
+			wantRobust: solver.Action{Type: solver.ScoreAction, Category: game.CatMoonberry},
+		},
+		{
+			name:       "both roll",
+			rollsLeft:  game.RollsPerRound,
+			wantRobust: solver.Action{Type: solver.RollAction},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var dice game.Dice
+			if tt.dice != "" {
+				var err error
+				if dice, err = solver.ParseDice(tt.dice); err != nil {
+					t.Fatal(err)
+				}
+			}
+			adv := Advise(dice, tt.rollsLeft, cs, tb, nominal)
+			if !adv.Robust.SameMove(tt.wantRobust) {
+				t.Errorf("Robust = %s, want %s", solver.FormatAction(adv.Robust), solver.FormatAction(tt.wantRobust))
+			}
+			if adv.Differs != tt.wantDiffers {
+				t.Errorf("Differs = %v, want %v (nominal %s)", adv.Differs, tt.wantDiffers, solver.FormatAction(adv.Nominal))
+			}
+			if adv.Cost() < -1e-9 || adv.Protection() < -1e-9 {
+				t.Errorf("Cost() = %v, Protection() = %v, want both >= 0", adv.Cost(), adv.Protection())
+			}
+			if tt.wantDiffers && (adv.Cost() <= 0 || adv.Protection() <= 0) {
+				t.Errorf("Cost() = %v, Protection() = %v, want both > 0 when the moves differ", adv.Cost(), adv.Protection())
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package robust
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// probTolerance is how far probabilities may stray outside a set through
+// rounding and still count as inside it.
+const probTolerance = 1e-9
+
+// Set is a set of face probability vectors the dice may have.
+type Set interface {
+	// Worst returns the face probabilities in the set that minimize the
+	// expected value of a die whose face b is worth values[b].
+	Worst(values [game.NumBerryTypes]float64) [game.NumBerryTypes]float64
+
+	// Contains reports whether the set holds the face probabilities p.
+	Contains(p [game.NumBerryTypes]float64) bool
+
+	String() string
+}
+
+// L1Ball is the set of face probabilities within L1 distance Radius of
+// Center. Moving a probability mass m from one face to another is a
+// distance of 2m, so a radius of 0.1 lets the dice shift 5 points of
+// probability between faces.
+type L1Ball struct {
+	Center [game.NumBerryTypes]float64
+	Radius float64
+}
+
+// NewL1Ball returns the L1 ball of radius around the face probabilities
+// of center. radius must be non-negative.
+func NewL1Ball(center *game.DiceModel, radius float64) (L1Ball, error) {
+	if !(radius >= 0) || math.IsInf(radius, 1) {
+		return L1Ball{}, fmt.Errorf("L1 radius must be non-negative and finite, got %v", radius)
+	}
+	return L1Ball{Center: center.FaceProb(), Radius: radius}, nil
+}
+
+// Worst moves as much probability as the radius allows onto the least
+// valuable face, taking it from the most valuable faces first.
+func (s L1Ball) Worst(values [game.NumBerryTypes]float64) [game.NumBerryTypes]float64 {
+	p := s.Center
+	order := byValue(values)
+	low := order[0]
+	mass := math.Min(s.Radius/2, 1-p[low])
+	p[low] += mass
+	for i := len(order) - 1; i > 0 && mass > 0; i-- {
+		b := order[i]
+		take := math.Min(mass, p[b])
+		p[b] -= take
+		mass -= take
+	}
+	return p
+}
+
+// Contains reports whether p is a probability vector within the ball.
+func (s L1Ball) Contains(p [game.NumBerryTypes]float64) bool {
+	if !isProb(p) {
+		return false
+	}
+	d := 0.0
+	for b := range p {
+		d += math.Abs(p[b] - s.Center[b])
+	}
+	return d <= s.Radius+probTolerance
+}
+
+// String returns e.g. "L1 ball of radius 0.1 around J 30% S 30% ...".
+func (s L1Ball) String() string {
+	return fmt.Sprintf("L1 ball of radius %s around %s", strconv.FormatFloat(s.Radius, 'g', -1, 64), game.FormatFaceProbs(s.Center))
+}
+
+// Intervals is the set of face probabilities with the probability of each
+// face b between Lo[b] and Hi[b].
+type Intervals struct {
+	Lo, Hi [game.NumBerryTypes]float64
+}
+
+// NewIntervals returns the set of face probabilities between lo and hi.
+// The bounds must lie in [0, 1], and some probability vector must fit
+// between them: lo must sum to at most 1 and hi to at least 1.
+func NewIntervals(lo, hi [game.NumBerryTypes]float64) (Intervals, error) {
+	sumLo, sumHi := 0.0, 0.0
+	for b := range lo {
+		if !(lo[b] >= 0 && lo[b] <= hi[b] && hi[b] <= 1) {
+			return Intervals{}, fmt.Errorf("interval for %s is [%v, %v], want 0 <= low <= high <= 1", game.Berry(b), lo[b], hi[b])
+		}
+		sumLo += lo[b]
+		sumHi += hi[b]
+	}
+	if sumLo > 1+probTolerance || sumHi < 1-probTolerance {
+		return Intervals{}, fmt.Errorf("no face probabilities fit the intervals: lower bounds sum to %v, upper bounds to %v", sumLo, sumHi)
+	}
+	return Intervals{Lo: lo, Hi: hi}, nil
+}
+
+// Worst starts every face at its lower bound and hands the remaining
+// probability to the least valuable faces first, each up to its upper
+// bound.
+func (s Intervals) Worst(values [game.NumBerryTypes]float64) [game.NumBerryTypes]float64 {
+	p := s.Lo
+	mass := 1.0
+	for _, q := range p {
+		mass -= q
+	}
+	for _, b := range byValue(values) {
+		if mass <= 0 {
+			break
+		}
+		add := math.Min(mass, s.Hi[b]-s.Lo[b])
+		p[b] += add
+		mass -= add
+	}
+	return p
+}
+
+// Contains reports whether p is a probability vector within the
+// intervals.
+func (s Intervals) Contains(p [game.NumBerryTypes]float64) bool {
+	if !isProb(p) {
+		return false
+	}
+	for b := range p {
+		if p[b] < s.Lo[b]-probTolerance || p[b] > s.Hi[b]+probTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns e.g. "intervals J 25-35% S 25-35% ...".
+func (s Intervals) String() string {
+	parts := make([]string, game.NumBerryTypes)
+	for b := range s.Lo {
+		parts[b] = fmt.Sprintf("%c %s-%s%%", game.Berry(b).Letter(), game.FormatPercent(s.Lo[b]), game.FormatPercent(s.Hi[b]))
+	}
+	return "intervals " + strings.Join(parts, " ")
+}
+
+// ParseSet parses an uncertainty set around the face probabilities of
+// nominal:
+//
+//	l1:0.1                  the L1 ball of radius 0.1
+//	interval:0.05           every face within 0.05 of nominal
+//	interval:M=0.05-0.15    the named faces within the given bounds, the
+//	                        others fixed at nominal (comma-separated)
+func ParseSet(input string, nominal *game.DiceModel) (Set, error) {
+	kind, arg, ok := strings.Cut(strings.TrimSpace(input), ":")
+	if !ok {
+		return nil, fmt.Errorf("uncertainty set %q: want \"l1:RADIUS\" or \"interval:WIDTH\"", input)
+	}
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "l1":
+		r, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("L1 radius %q is not a number", arg)
+		}
+		return NewL1Ball(nominal, r)
+	case "interval", "intervals":
+		return parseIntervals(arg, nominal)
+	default:
+		return nil, fmt.Errorf("unknown uncertainty set %q: want \"l1\" or \"interval\"", kind)
+	}
+}
+
+// parseIntervals parses a width or a list of per-face bounds.
+func parseIntervals(arg string, nominal *game.DiceModel) (Set, error) {
+	p := nominal.FaceProb()
+	lo, hi := p, p
+	if w, err := strconv.ParseFloat(arg, 64); err == nil {
+		if !(w >= 0) {
+			return nil, fmt.Errorf("interval width must be non-negative, got %v", w)
+		}
+		for b := range p {
+			lo[b] = math.Max(0, p[b]-w)
+			hi[b] = math.Min(1, p[b]+w)
+		}
+		return NewIntervals(lo, hi)
+	}
+
+	for _, field := range strings.Split(arg, ",") {
+		name, bounds, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok || len(strings.TrimSpace(name)) != 1 {
+			return nil, fmt.Errorf("interval %q: want FACE=LOW-HIGH, e.g. M=0.05-0.15", field)
+		}
+		b, ok := game.ParseBerry(strings.TrimSpace(name)[0])
+		if !ok {
+			return nil, fmt.Errorf("interval %q: unknown face %q", field, name)
+		}
+		l, h, ok := strings.Cut(bounds, "-")
+		if !ok {
+			return nil, fmt.Errorf("interval %q: want FACE=LOW-HIGH, e.g. M=0.05-0.15", field)
+		}
+		var err error
+		if lo[b], err = strconv.ParseFloat(strings.TrimSpace(l), 64); err != nil {
+			return nil, fmt.Errorf("interval %q: low bound is not a number", field)
+		}
+		if hi[b], err = strconv.ParseFloat(strings.TrimSpace(h), 64); err != nil {
+			return nil, fmt.Errorf("interval %q: high bound is not a number", field)
+		}
+	}
+	return NewIntervals(lo, hi)
+}
+
+// byValue returns the faces in ascending order of values.
+func byValue(values [game.NumBerryTypes]float64) [game.NumBerryTypes]game.Berry {
+	var order [game.NumBerryTypes]game.Berry
+	for b := range order {
+		order[b] = game.Berry(b)
+	}
+	sort.SliceStable(order[:], func(i, j int) bool {
+		return values[order[i]] < values[order[j]]
+	})
+	return order
+}
+
+// isProb reports whether p is non-negative and sums to 1.
+func isProb(p [game.NumBerryTypes]float64) bool {
+	sum := 0.0
+	for _, q := range p {
+		if q < -probTolerance {
+			return false
+		}
+		sum += q
+	}
+	return math.Abs(sum-1) <= probTolerance
+}
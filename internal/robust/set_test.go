@@ -0,0 +1,163 @@
+package robust
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestWorst(t *testing.T) {
+	t.Parallel()
+
+	ball, err := NewL1Ball(game.DefaultModel, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := NewIntervals(
+		[game.NumBerryTypes]float64{0.2, 0.2, 0.1, 0.05, 0.05},
+		[game.NumBerryTypes]float64{0.4, 0.4, 0.3, 0.15, 0.15},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		set    Set
+		values [game.NumBerryTypes]float64
+		want   [game.NumBerryTypes]float64
+	}{
+		{
+			// 0.1 moves to the Pest, from the Moonberry first.
+			name:   "L1 takes the best face first",
+			set:    ball,
+			values: [game.NumBerryTypes]float64{2, 2, 4, 7, 0},
+			want:   [game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0, 0.2},
+		},
+		{
+			// Taking 0.1 needs both the Moonberry and the Pickleberry.
+			name:   "L1 spills to the next face",
+			set:    ball,
+			values: [game.NumBerryTypes]float64{1, 2, 6, 7, 3},
+			want:   [game.NumBerryTypes]float64{0.4, 0.3, 0.2, 0, 0.1},
+		},
+		{
+			name:   "L1 radius 0 is the center",
+			set:    L1Ball{Center: game.FaceProb},
+			values: [game.NumBerryTypes]float64{2, 2, 4, 7, 0},
+			want:   game.FaceProb,
+		},
+		{
+			// Lower bounds take 0.6; the rest fills X, then J, then S.
+			name:   "intervals fill the worst faces",
+			set:    box,
+			values: [game.NumBerryTypes]float64{2, 3, 4, 7, 0},
+			want:   [game.NumBerryTypes]float64{0.4, 0.3, 0.1, 0.05, 0.15},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.set.Worst(tt.values)
+			for b := range got {
+				if math.Abs(got[b]-tt.want[b]) > 1e-12 {
+					t.Fatalf("Worst(%v) = %v, want %v", tt.values, got, tt.want)
+				}
+			}
+			if !tt.set.Contains(got) {
+				t.Errorf("Worst(%v) = %v is outside the set", tt.values, got)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	t.Parallel()
+
+	ball := L1Ball{Center: game.FaceProb, Radius: 0.1}
+	if !ball.Contains([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.05, 0.15}) {
+		t.Error("ball does not contain a point at distance 0.1")
+	}
+	if ball.Contains([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0, 0.2}) {
+		t.Error("ball contains a point at distance 0.2")
+	}
+	if ball.Contains([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.1, 0.15}) {
+		t.Error("ball contains a vector summing to 1.05")
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    Set
+		wantErr bool
+	}{
+		{input: "l1:0.1", want: L1Ball{Center: game.FaceProb, Radius: 0.1}},
+		{input: " L1 : 0 ", want: L1Ball{Center: game.FaceProb}},
+		{
+			input: "interval:0.15",
+			want: Intervals{
+				Lo: [game.NumBerryTypes]float64{0.15, 0.15, 0.05, 0, 0},
+				Hi: [game.NumBerryTypes]float64{0.45, 0.45, 0.35, 0.25, 0.25},
+			},
+		},
+		{
+			input: "interval:m=0.05-0.15, X=0.05-0.15",
+			want: Intervals{
+				Lo: [game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.05, 0.05},
+				Hi: [game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.15, 0.15},
+			},
+		},
+		{input: "0.1", wantErr: true},
+		{input: "l2:0.1", wantErr: true},
+		{input: "l1:-0.1", wantErr: true},
+		{input: "l1:wide", wantErr: true},
+		{input: "interval:-0.1", wantErr: true},
+		{input: "interval:Q=0.1-0.2", wantErr: true},
+		{input: "interval:M=0.2", wantErr: true},
+		{input: "interval:M=0.2-0.1", wantErr: true},
+		{input: "interval:M=0.3-0.4", wantErr: true}, // lower bounds sum past 1
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseSet(tt.input, game.DefaultModel)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSet(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSet(%q): %v", tt.input, err)
+			}
+			if !sameSet(got, tt.want) {
+				t.Errorf("ParseSet(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// sameSet compares sets up to rounding in their bounds.
+func sameSet(a, b Set) bool {
+	close := func(x, y [game.NumBerryTypes]float64) bool {
+		for i := range x {
+			if math.Abs(x[i]-y[i]) > 1e-12 {
+				return false
+			}
+		}
+		return true
+	}
+	switch a := a.(type) {
+	case L1Ball:
+		b, ok := b.(L1Ball)
+		return ok && a.Radius == b.Radius && close(a.Center, b.Center)
+	case Intervals:
+		b, ok := b.(Intervals)
+		return ok && close(a.Lo, b.Lo) && close(a.Hi, b.Hi)
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package sensitivity
+
+import (
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// maxSteps bounds the tables FindFlip computes before and after it
+// brackets a flip.
+const maxSteps = 12
+
+// Flip is where the best action in a state changes as one parameter moves
+// away from its value in the rules.
+type Flip struct {
+	Param Param
+	Up    bool // whether the parameter increases
+
+	// Found reports whether the best action changes within the searched
+	// range. If so, To is best past Threshold; otherwise From stays best
+	// up to Limit. From.EV is at the rules' value, To.EV just past
+	// Threshold.
+	Found     bool
+	Threshold float64
+	Limit     float64
+	From, To  solver.Action
+}
+
+// tolerance returns how precisely FindFlip locates thresholds of p.
+func tolerance(p Param) float64 {
+	if p.IsProb() {
+		return 1e-4
+	}
+	return 1e-3
+}
+
+// FindFlip finds the nearest value of p, above its value in base's rules
+// if up is set and below it otherwise, at which the best action in the
+// state changes. base must cover cs. Each step computes a table for cs,
+// so states with few categories left are much faster.
+//
+// The search follows the gradients: it moves p to where the best action
+// would lose its lead if values were linear in p, checks, and repeats;
+// once the best action has changed it narrows the bracket by linear
+// interpolation.
+func FindFlip(base *Table, dice game.Dice, rollsLeft int, cs game.CategorySet, p Param, up bool) (Flip, error) {
+	dir := 1.0
+	lo, hi := base.rules.Range(p)
+	bound := lo
+	if up {
+		bound = hi
+	}
+	if !up {
+		dir = -1
+	}
+	tol := tolerance(p)
+
+	acts := base.Actions(dice, rollsLeft, cs)
+	from := acts[0]
+	f := Flip{Param: p, Up: up, From: from.Action, Limit: bound}
+
+	theta := base.rules.Value(p)
+	for range maxSteps {
+		if (bound-theta)*dir <= tol {
+			return f, nil
+		}
+		next := bound
+		if step, ok := crossing(acts, p, dir); ok {
+			next = theta + dir*(step+tol)
+			if (next-bound)*dir > 0 {
+				next = bound
+			}
+		}
+		t, err := Compute(base.rules.With(p, next), cs)
+		if err != nil {
+			return f, err
+		}
+		at := t.Actions(dice, rollsLeft, cs)
+		if !at[0].Action.SameMove(from.Action) {
+			return refine(f, base.rules, dice, rollsLeft, cs, theta, acts, next, at)
+		}
+		theta, acts = next, at
+	}
+	f.Limit = theta // gave up: From is best at least this far
+	return f, nil
+}
+
+// crossing returns how far p must move in direction dir, if values were
+// linear in p, for another action to catch up with the first of acts.
+func crossing(acts []ActionValue, p Param, dir float64) (float64, bool) {
+	best := acts[0]
+	step, found := math.Inf(1), false
+	for _, a := range acts[1:] {
+		gap := best.Action.EV - a.Action.EV
+		closing := dir * (a.Gradient[p] - best.Gradient[p])
+		if closing <= 0 {
+			continue
+		}
+		if s := gap / closing; s < step {
+			step, found = s, true
+		}
+	}
+	return step, found
+}
+
+// refine narrows the bracket [a, b] of p, with f.From best at a (actions
+// actsA) and another action best at b (actsB), to the tolerance.
+func refine(f Flip, rules Rules, dice game.Dice, rollsLeft int, cs game.CategorySet, a float64, actsA []ActionValue, b float64, actsB []ActionValue) (Flip, error) {
+	tol := tolerance(f.Param)
+	f.Found = true
+	f.To = actsB[0].Action
+	for range maxSteps {
+		if math.Abs(b-a) <= tol {
+			break
+		}
+		// h is the lead of From over the best other action: positive at
+		// a, negative at b.
+		ha := lead(actsA, f.From)
+		hb := lead(actsB, f.From)
+		x := (a + b) / 2
+		if ha-hb > 0 {
+			x = a + (b-a)*ha/(ha-hb)
+		}
+		// Keep probing inside the bracket, at least half a tolerance
+		// from either end, so it shrinks even when x is exact.
+		lo, hi := math.Min(a, b)+tol/2, math.Max(a, b)-tol/2
+		x = math.Min(math.Max(x, lo), hi)
+
+		t, err := Compute(rules.With(f.Param, x), cs)
+		if err != nil {
+			return f, err
+		}
+		at := t.Actions(dice, rollsLeft, cs)
+		if at[0].Action.SameMove(f.From) {
+			a, actsA = x, at
+		} else {
+			b, actsB = x, at
+			f.To = at[0].Action
+		}
+	}
+	f.Threshold = (a + b) / 2
+	f.To.EV = evOf(actsB, f.To)
+	return f, nil
+}
+
+// lead returns how much action's EV exceeds the best other action in
+// acts; it is negative if another action is best.
+func lead(acts []ActionValue, action solver.Action) float64 {
+	own, other := math.Inf(-1), math.Inf(-1)
+	for _, a := range acts {
+		if a.Action.SameMove(action) {
+			own = a.Action.EV
+		} else if a.Action.EV > other {
+			other = a.Action.EV
+		}
+	}
+	return own - other
+}
+
+// evOf returns the EV of action in acts.
+func evOf(acts []ActionValue, action solver.Action) float64 {
+	for _, a := range acts {
+		if a.Action.SameMove(action) {
+			return a.Action.EV
+		}
+	}
+	return math.NaN()
+}
@@ -0,0 +1,68 @@
+package sensitivity
+
+import (
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestFindFlip(t *testing.T) {
+	t.Parallel()
+
+	// Three Jumbleberries and two Moonberries with Jumbleberry and
+	// Moonberry left: standard dice keep the Moonberries, but dice that
+	// roll Moonberries often enough keep the Jumbleberries and reroll for
+	// more Moonberries.
+	rules := StandardRules()
+	cs := game.CategorySet(0).Add(game.CatJumbleberry).Add(game.CatMoonberry)
+	dice := game.Dice{3, 0, 0, 2, 0}
+	base, err := Compute(rules, cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		param Param
+		up    bool
+		found bool
+	}{
+		{ProbParam(game.Moonberry), true, true},
+		{PointsParam(game.Jumbleberry), true, true},
+		{PointsParam(game.Pest), true, false}, // Pests never score here
+	}
+	for _, tt := range tests {
+		f, err := FindFlip(base, dice, 1, cs, tt.param, tt.up)
+		if err != nil {
+			t.Fatalf("FindFlip(%v) error: %v", tt.param, err)
+		}
+		if f.Found != tt.found {
+			t.Errorf("FindFlip(%v, up %v).Found = %v, want %v (%+v)", tt.param, tt.up, f.Found, tt.found, f)
+			continue
+		}
+		if !f.Found {
+			continue
+		}
+		if (f.Threshold > rules.Value(tt.param)) != tt.up {
+			t.Errorf("FindFlip(%v, up %v).Threshold = %v on the wrong side of %v", tt.param, tt.up, f.Threshold, rules.Value(tt.param))
+		}
+
+		// Just before the threshold From is best, just after To is.
+		tol := tolerance(tt.param)
+		for _, c := range []struct {
+			at   float64
+			want bool // From is best
+		}{
+			{f.Threshold - tol, true},
+			{f.Threshold + tol, false},
+		} {
+			tab, err := Compute(rules.With(tt.param, c.at), cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			best := tab.Actions(dice, 1, cs)[0].Action
+			if best.SameMove(f.From) != c.want {
+				t.Errorf("%v = %v: best %+v, flip %+v", tt.param, c.at, best, f)
+			}
+		}
+	}
+}
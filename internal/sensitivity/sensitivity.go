@@ -0,0 +1,485 @@
+// Package sensitivity measures how the expected score and the optimal
+// decisions depend on the rules: the probability of each face and the
+// points of each berry. Compute runs the EV dynamic program with the
+// gradient of every value carried alongside it, and FindFlip searches for
+// the parameter value at which a decision changes, for weighing house
+// rules before trying them at the table.
+//
+// Gradients follow the envelope theorem: at each decision the gradient of
+// the best action is taken, as if the policy were fixed. Where two
+// actions tie the value has a kink and the gradient is one-sided.
+package sensitivity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+// NumParams is the number of rule parameters: a probability and a point
+// value for each face.
+const NumParams = 2 * game.NumBerryTypes
+
+// Param is a rule parameter. The first game.NumBerryTypes are the face
+// probabilities, the rest the point values.
+type Param int
+
+// ProbParam returns the parameter of the probability of face b. Moving it
+// scales the other faces to keep the probabilities summing to 1.
+func ProbParam(b game.Berry) Param {
+	return Param(b)
+}
+
+// PointsParam returns the parameter of the point value of berry b.
+func PointsParam(b game.Berry) Param {
+	return Param(game.NumBerryTypes) + Param(b)
+}
+
+// AllParams returns every parameter, probabilities first.
+func AllParams() []Param {
+	params := make([]Param, NumParams)
+	for i := range params {
+		params[i] = Param(i)
+	}
+	return params
+}
+
+// IsProb reports whether p is a face probability.
+func (p Param) IsProb() bool {
+	return p < Param(game.NumBerryTypes)
+}
+
+// Berry returns the face p belongs to.
+func (p Param) Berry() game.Berry {
+	return game.Berry(p % Param(game.NumBerryTypes))
+}
+
+// String returns the parameter as "P(Moonberry)" or "points(Moonberry)".
+func (p Param) String() string {
+	if p.IsProb() {
+		return fmt.Sprintf("P(%s)", p.Berry())
+	}
+	return fmt.Sprintf("points(%s)", p.Berry())
+}
+
+// ParseParam parses a parameter: "p:M" or "P(Moonberry)" for a face
+// probability, "pts:M" or "points(Moonberry)" for a point value. Faces
+// may be letters or names, in any case.
+func ParseParam(s string) (Param, error) {
+	s = strings.TrimSpace(s)
+	kind, face, ok := strings.Cut(s, ":")
+	if !ok {
+		var found bool
+		kind, face, found = strings.Cut(strings.TrimSuffix(s, ")"), "(")
+		if !found || !strings.HasSuffix(s, ")") {
+			return 0, fmt.Errorf("invalid parameter %q (use p:M or pts:M)", s)
+		}
+	}
+	b, err := game.ParseFace(strings.TrimSpace(face))
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "p", "prob":
+		return ProbParam(b), nil
+	case "pts", "points":
+		return PointsParam(b), nil
+	}
+	return 0, fmt.Errorf("invalid parameter kind %q in %q (use p or pts)", kind, s)
+}
+
+// ParsePoints parses berry point values: five numbers in J, S, P, M, X
+// order ("2,2,4,7,0"), or named values ("M=8 X=-1") that change the
+// standard points. Empty input gives the standard points.
+func ParsePoints(s string) ([game.NumBerryTypes]float64, error) {
+	points := StandardRules().Points
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(fields) == 0 {
+		return points, nil
+	}
+	if !strings.Contains(s, "=") {
+		if len(fields) != int(game.NumBerryTypes) {
+			return points, fmt.Errorf("need 5 point values (J, S, P, M, X), got %d", len(fields))
+		}
+		for b, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return points, fmt.Errorf("invalid point value %q", f)
+			}
+			points[b] = v
+		}
+		return points, nil
+	}
+	for _, f := range fields {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return points, fmt.Errorf("invalid point value %q (use M=8)", f)
+		}
+		b, err := game.ParseFace(name)
+		if err != nil {
+			return points, err
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return points, fmt.Errorf("invalid point value %q", f)
+		}
+		points[b] = v
+	}
+	return points, nil
+}
+
+// maxPoints is the largest point value FindFlip searches, unless the
+// current value is over half of it.
+const maxPoints = 30
+
+// probMargin keeps FindFlip off probabilities of 0 and 1, where a face
+// never or always shows and many actions tie.
+const probMargin = 0.001
+
+// Rules are the values of the parameters.
+type Rules struct {
+	FaceProb [game.NumBerryTypes]float64
+	Points   [game.NumBerryTypes]float64
+}
+
+// StandardRules returns game.FaceProb and game.BerryPoints.
+func StandardRules() Rules {
+	r := Rules{FaceProb: game.FaceProb}
+	for b, p := range game.BerryPoints {
+		r.Points[b] = float64(p)
+	}
+	return r
+}
+
+// Value returns the value of p.
+func (r Rules) Value(p Param) float64 {
+	if p.IsProb() {
+		return r.FaceProb[p.Berry()]
+	}
+	return r.Points[p.Berry()]
+}
+
+// With returns the rules with p set to v. Setting a probability scales
+// the other faces in proportion, so they keep summing to 1.
+func (r Rules) With(p Param, v float64) Rules {
+	b := p.Berry()
+	if !p.IsProb() {
+		r.Points[b] = v
+		return r
+	}
+	rest := 1 - r.FaceProb[b]
+	for c := range r.FaceProb {
+		switch {
+		case game.Berry(c) == b:
+			r.FaceProb[c] = v
+		case rest > 0:
+			r.FaceProb[c] *= (1 - v) / rest
+		default:
+			r.FaceProb[c] = (1 - v) / float64(game.NumBerryTypes-1)
+		}
+	}
+	return r
+}
+
+// Range returns the values of p searched for decision flips: [0.001,
+// 0.999] for a probability, [0, 30] for points (wider if the value is
+// outside it).
+func (r Rules) Range(p Param) (lo, hi float64) {
+	if p.IsProb() {
+		return probMargin, 1 - probMargin
+	}
+	v := r.Value(p)
+	return math.Min(0, v), math.Max(maxPoints, 2*v)
+}
+
+// direction returns the change of each face probability per unit change
+// of the probability of face b, with the others scaled to compensate.
+func (r Rules) direction(b game.Berry) [game.NumBerryTypes]float64 {
+	var u [game.NumBerryTypes]float64
+	rest := 1 - r.FaceProb[b]
+	for c := range u {
+		switch {
+		case game.Berry(c) == b:
+			u[c] = 1
+		case rest > 0:
+			u[c] = -r.FaceProb[c] / rest
+		default:
+			u[c] = -1 / float64(game.NumBerryTypes-1)
+		}
+	}
+	return u
+}
+
+// Gradient holds the derivative of a value with respect to each Param.
+type Gradient [NumParams]float64
+
+// add adds s·g to d.
+func (d *Gradient) add(s float64, g *Gradient) {
+	for i := range d {
+		d[i] += s * g[i]
+	}
+}
+
+// Table holds the expected score of the category sets within a set, under
+// some rules, with its gradient.
+type Table struct {
+	rules  Rules
+	model  *game.DiceModel
+	within game.CategorySet
+	ev     [512]float64
+	grad   [512]Gradient
+
+	score     [game.NumCategories][]float64  // score[cat][diceIdx]
+	scoreGrad [game.NumCategories][]Gradient // gradient of score, points only
+
+	rerollGrad [game.NumDice + 1][]Gradient // gradient of model.Rerolls(n)[i].Prob, probabilities only
+	firstGrad  []Gradient                   // gradient of model.FirstRollProb(diceIdx)
+}
+
+// Compute returns the table for rules over the category sets within
+// within; game.AllCategories computes every set, as ev.Compute does, in
+// about a second.
+func Compute(rules Rules, within game.CategorySet) (*Table, error) {
+	model, err := game.NewDiceModel(rules.FaceProb)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{rules: rules, model: model, within: within}
+	t.prepare()
+
+	// Every proper subset of a set is numerically smaller, so counting
+	// up computes subsets first.
+	for cs := game.CategorySet(1); cs <= within; cs++ {
+		if cs&^within != 0 {
+			continue
+		}
+		v2 := t.rerollLayer(t.rerollLayer(t.scoreLayer(cs)))
+		var g Gradient
+		e := 0.0
+		for i := range v2.v {
+			p := model.FirstRollProb(i)
+			e += p * v2.v[i]
+			g.add(p, &v2.g[i])
+			g.add(v2.v[i], &t.firstGrad[i])
+		}
+		t.ev[cs], t.grad[cs] = e, g
+	}
+	return t, nil
+}
+
+// prepare fills the score and outcome probability tables.
+func (t *Table) prepare() {
+	allDice := game.AllDice()
+	for cat := game.Category(0); cat < game.NumCategories; cat++ {
+		t.score[cat] = make([]float64, len(allDice))
+		t.scoreGrad[cat] = make([]Gradient, len(allDice))
+		for i, d := range allDice {
+			t.score[cat][i] = game.ScoreWithPoints(d, cat, t.rules.Points)
+			for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+				var unit [game.NumBerryTypes]float64
+				unit[b] = 1
+				t.scoreGrad[cat][i][PointsParam(b)] = game.ScoreWithPoints(d, cat, unit)
+			}
+		}
+	}
+
+	for n := 0; n <= game.NumDice; n++ {
+		outcomes := t.model.Rerolls(n)
+		t.rerollGrad[n] = make([]Gradient, len(outcomes))
+		for i, ro := range outcomes {
+			t.rerollGrad[n][i] = t.probGrad(ro.Dice, n)
+		}
+	}
+	t.firstGrad = make([]Gradient, len(allDice))
+	for i, d := range allDice {
+		t.firstGrad[i] = t.probGrad(d, game.NumDice)
+	}
+}
+
+// probGrad returns the gradient of the probability of rolling d with n
+// dice.
+func (t *Table) probGrad(d game.Dice, n int) Gradient {
+	p := t.rules.FaceProb
+	coef := factorial(n)
+	for _, k := range d {
+		coef /= factorial(int(k))
+	}
+	// partial[c] = ∂P/∂p_c = coef · k_c p_c^(k_c-1) · Π_{e≠c} p_e^k_e.
+	var partial [game.NumBerryTypes]float64
+	for c, kc := range d {
+		if kc == 0 {
+			continue
+		}
+		v := coef * float64(kc) * math.Pow(p[c], float64(kc-1))
+		for e, ke := range d {
+			if e != c {
+				v *= math.Pow(p[e], float64(ke))
+			}
+		}
+		partial[c] = v
+	}
+
+	var g Gradient
+	for b := game.Berry(0); b < game.NumBerryTypes; b++ {
+		u := t.rules.direction(b)
+		for c := range partial {
+			g[ProbParam(b)] += u[c] * partial[c]
+		}
+	}
+	return g
+}
+
+// factorial returns n! for small n.
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+// Rules returns the rules the table was computed for.
+func (t *Table) Rules() Rules {
+	return t.rules
+}
+
+// EV returns the expected score of cs, which must be within the set the
+// table was computed for.
+func (t *Table) EV(cs game.CategorySet) float64 {
+	return t.ev[cs]
+}
+
+// Gradient returns the gradient of EV(cs).
+func (t *Table) Gradient(cs game.CategorySet) Gradient {
+	return t.grad[cs]
+}
+
+// layer holds the value of every dice outcome with some rolls left, and
+// its gradient.
+type layer struct {
+	v []float64
+	g []Gradient
+}
+
+func newLayer() layer {
+	n := game.NumAllDice()
+	return layer{v: make([]float64, n), g: make([]Gradient, n)}
+}
+
+// scoreLayer returns the layer with no rolls left: the best category for
+// each outcome.
+func (t *Table) scoreLayer(cs game.CategorySet) layer {
+	l := newLayer()
+	for i := range l.v {
+		best := math.Inf(-1)
+		var bestCat game.Category
+		cs.ForEach(func(cat game.Category) {
+			if v := t.score[cat][i] + t.ev[cs.Remove(cat)]; v > best {
+				best, bestCat = v, cat
+			}
+		})
+		l.v[i] = best
+		l.g[i] = t.scoreGrad[bestCat][i]
+		l.g[i].add(1, &t.grad[cs.Remove(bestCat)])
+	}
+	return l
+}
+
+// rerollLayer returns the layer with one more roll left than prev.
+func (t *Table) rerollLayer(prev layer) layer {
+	l := newLayer()
+	for i, d := range game.AllDice() {
+		best, bestKeep := prev.v[i], d
+		ev.EnumerateKeeps(d, func(keep game.Dice, numKept int) {
+			if numKept == game.NumDice {
+				return
+			}
+			if v := t.keepValue(keep, prev); v > best {
+				best, bestKeep = v, keep
+			}
+		})
+		l.v[i] = best
+		if bestKeep == d {
+			l.g[i] = prev.g[i]
+		} else {
+			l.g[i] = t.keepGrad(bestKeep, prev)
+		}
+	}
+	return l
+}
+
+// keepValue returns the expected value of prev after holding keep and
+// rerolling the other dice.
+func (t *Table) keepValue(keep game.Dice, prev layer) float64 {
+	v := 0.0
+	for _, ro := range t.model.Rerolls(game.NumDice - keep.Total()) {
+		v += ro.Prob * prev.v[game.DiceIndex(game.AddDice(keep, ro.Dice))]
+	}
+	return v
+}
+
+// keepGrad returns the gradient of keepValue.
+func (t *Table) keepGrad(keep game.Dice, prev layer) Gradient {
+	n := game.NumDice - keep.Total()
+	var g Gradient
+	for j, ro := range t.model.Rerolls(n) {
+		idx := game.DiceIndex(game.AddDice(keep, ro.Dice))
+		g.add(ro.Prob, &prev.g[idx])
+		g.add(prev.v[idx], &t.rerollGrad[n][j])
+	}
+	return g
+}
+
+// ActionValue is the expected final score of an action, with its
+// gradient.
+type ActionValue struct {
+	Action   solver.Action // Action.EV is the expected score
+	Gradient Gradient
+}
+
+// Actions returns every action in the state, best first. As in the
+// solver, scoring wins ties with rerolling. cs must be within the set the
+// table was computed for.
+func (t *Table) Actions(dice game.Dice, rollsLeft int, cs game.CategorySet) []ActionValue {
+	if rollsLeft == game.RollsPerRound {
+		return []ActionValue{{Action: solver.Action{Type: solver.RollAction, EV: t.ev[cs]}, Gradient: t.grad[cs]}}
+	}
+
+	var actions []ActionValue
+	i := game.DiceIndex(dice)
+	cs.ForEach(func(cat game.Category) {
+		a := ActionValue{
+			Action:   solver.Action{Type: solver.ScoreAction, Category: cat, EV: t.score[cat][i] + t.ev[cs.Remove(cat)]},
+			Gradient: t.scoreGrad[cat][i],
+		}
+		a.Gradient.add(1, &t.grad[cs.Remove(cat)])
+		actions = append(actions, a)
+	})
+
+	if rollsLeft > 0 {
+		prev := t.scoreLayer(cs)
+		for range rollsLeft - 1 {
+			prev = t.rerollLayer(prev)
+		}
+		ev.EnumerateKeeps(dice, func(keep game.Dice, numKept int) {
+			if numKept == game.NumDice {
+				return
+			}
+			actions = append(actions, ActionValue{
+				Action:   solver.Action{Type: solver.RerollAction, Keep: keep, EV: t.keepValue(keep, prev)},
+				Gradient: t.keepGrad(keep, prev),
+			})
+		})
+	}
+
+	sort.SliceStable(actions, func(a, b int) bool {
+		return actions[a].Action.EV > actions[b].Action.EV
+	})
+	return actions
+}
@@ -0,0 +1,165 @@
+package sensitivity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+	"github.com/iadams749/JBFieldsSolver/internal/solver"
+)
+
+func TestComputeMatchesEV(t *testing.T) {
+	t.Parallel()
+
+	got, err := Compute(StandardRules(), game.AllCategories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ev.Compute(nil)
+	for cs := game.CategorySet(1); cs <= game.AllCategories; cs++ {
+		if d := got.EV(cs) - want.EV(cs); math.Abs(d) > 1e-9 {
+			t.Fatalf("EV(%v) = %v, want %v", cs, got.EV(cs), want.EV(cs))
+		}
+	}
+
+	// The best actions agree with the solver.
+	cs := game.AllCategories.Remove(game.CatFreeRoll)
+	for _, tt := range []struct {
+		dice      game.Dice
+		rollsLeft int
+	}{
+		{game.Dice{2, 1, 1, 1, 0}, 2},
+		{game.Dice{0, 0, 1, 3, 1}, 1},
+		{game.Dice{1, 1, 1, 1, 1}, 0},
+	} {
+		best := got.Actions(tt.dice, tt.rollsLeft, cs)[0].Action
+		want := solver.Solve(tt.dice, tt.rollsLeft, cs, want).BestAction
+		if !best.SameMove(want) || math.Abs(best.EV-want.EV) > 1e-9 {
+			t.Errorf("best action for %v with %d rolls = %+v, want %+v", tt.dice, tt.rollsLeft, best, want)
+		}
+	}
+}
+
+func TestGradient(t *testing.T) {
+	t.Parallel()
+
+	// Jumbleberries and Sugarberries are interchangeable under the
+	// standard rules, which ties actions and puts kinks in the values;
+	// different points break the ties.
+	rules := StandardRules().With(PointsParam(game.Sugarberry), 2.5)
+	cs := game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatBasketOfThree).Add(game.CatMixedBasket)
+	base, err := Compute(rules, cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dice := game.Dice{1, 1, 0, 2, 1}
+	baseActs := base.Actions(dice, 2, cs)
+
+	// Values are smooth away from decision ties, so central differences
+	// match the gradient.
+	for _, p := range AllParams() {
+		h := 1e-6
+		if !p.IsProb() {
+			h = 1e-4
+		}
+		v := rules.Value(p)
+		up, err := Compute(rules.With(p, v+h), cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		down, err := Compute(rules.With(p, v-h), cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd := (up.EV(cs) - down.EV(cs)) / (2 * h)
+		if g := base.Gradient(cs)[p]; math.Abs(g-fd) > 1e-4*math.Max(1, math.Abs(fd)) {
+			t.Errorf("d EV / d %v = %v, finite difference %v", p, g, fd)
+		}
+
+		best := baseActs[0]
+		fdAct := (evOf(up.Actions(dice, 2, cs), best.Action) - evOf(down.Actions(dice, 2, cs), best.Action)) / (2 * h)
+		if math.Abs(best.Gradient[p]-fdAct) > 1e-4*math.Max(1, math.Abs(fdAct)) {
+			t.Errorf("d EV(%+v) / d %v = %v, finite difference %v", best.Action, p, best.Gradient[p], fdAct)
+		}
+	}
+
+	// The points gradient of EV is the expected number of each berry
+	// scored, so the points part reproduces the EV.
+	sum := 0.0
+	for b, pts := range rules.Points {
+		sum += pts * base.Gradient(cs)[PointsParam(game.Berry(b))]
+	}
+	if math.Abs(sum-base.EV(cs)) > 1e-9 {
+		t.Errorf("points · gradient = %v, want EV %v", sum, base.EV(cs))
+	}
+}
+
+func TestWith(t *testing.T) {
+	t.Parallel()
+
+	r := StandardRules().With(ProbParam(game.Moonberry), 0.2)
+	want := [game.NumBerryTypes]float64{0.3 * 8 / 9, 0.3 * 8 / 9, 0.2 * 8 / 9, 0.2, 0.1 * 8 / 9}
+	for b := range want {
+		if math.Abs(r.FaceProb[b]-want[b]) > 1e-12 {
+			t.Errorf("With(P(M), 0.2).FaceProb = %v, want %v", r.FaceProb, want)
+			break
+		}
+	}
+	if r := StandardRules().With(PointsParam(game.Pest), -2); r.Points[game.Pest] != -2 || r.FaceProb != game.FaceProb {
+		t.Errorf("With(points(X), -2) = %+v", r)
+	}
+}
+
+func TestParseParam(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    Param
+		wantErr bool
+	}{
+		{"p:M", ProbParam(game.Moonberry), false},
+		{"prob:jumbleberry", ProbParam(game.Jumbleberry), false},
+		{"P(Pest)", ProbParam(game.Pest), false},
+		{"pts:x", PointsParam(game.Pest), false},
+		{"points(Moonberry)", PointsParam(game.Moonberry), false},
+		{"q:M", 0, true},
+		{"p:Q", 0, true},
+		{"M", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseParam(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParseParam(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+	for _, p := range AllParams() {
+		if got, err := ParseParam(p.String()); err != nil || got != p {
+			t.Errorf("ParseParam(%q) = %v, %v; want %v", p.String(), got, err, p)
+		}
+	}
+}
+
+func TestParsePoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    [game.NumBerryTypes]float64
+		wantErr bool
+	}{
+		{"", [game.NumBerryTypes]float64{2, 2, 4, 7, 0}, false},
+		{"1,2,3,4,5", [game.NumBerryTypes]float64{1, 2, 3, 4, 5}, false},
+		{"M=8 X=-1", [game.NumBerryTypes]float64{2, 2, 4, 8, -1}, false},
+		{"1,2,3", [game.NumBerryTypes]float64{}, true},
+		{"M=lots", [game.NumBerryTypes]float64{}, true},
+		{"Q=1", [game.NumBerryTypes]float64{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePoints(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParsePoints(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
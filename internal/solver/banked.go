@@ -0,0 +1,137 @@
+package solver
+
+import (
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// BankedSolver answers queries for one round of the banked-rerolls
+// variant, in which rerolls not used in a round are saved for later
+// rounds up to a cap (see ev.BankedTable). A state has the round's own
+// rolls left, as in Solve, and the rerolls saved from earlier rounds. Only
+// their total matters: any of them can be spent, and the ones left when
+// the round is scored go to the bank.
+type BankedSolver struct {
+	cs     game.CategorySet
+	table  *ev.BankedTable
+	layers [][]float64 // layers[r][diceIdx] = value with r rerolls available
+	round  [][]float64 // round[r][diceIdx] = expected score banked this round, built lazily
+}
+
+// NewBankedSolver returns a BankedSolver for the category set cs.
+func NewBankedSolver(cs game.CategorySet, table *ev.BankedTable) *BankedSolver {
+	return &BankedSolver{cs: cs, table: table, layers: table.Layers(cs)}
+}
+
+// BankedRerolls returns the rerolls available in a state: the round's
+// own, if the dice have been rolled, plus the bank.
+func BankedRerolls(rollsLeft, bank int) int {
+	if rollsLeft == game.RollsPerRound {
+		return game.RollsPerRound - 1 + bank
+	}
+	return rollsLeft + bank
+}
+
+// Solve computes the optimal action with rollsLeft of the round's rolls
+// left (3 before the first roll, when dice is ignored) and bank rerolls
+// saved. Category options value the remaining categories with the
+// rerolls that scoring now would save. TopRerollOptions have no outcome
+// profiles.
+func (bs *BankedSolver) Solve(dice game.Dice, rollsLeft, bank int) Recommendation {
+	if rollsLeft == game.RollsPerRound {
+		return Recommendation{
+			BestAction:     Action{Type: RollAction, EV: bs.table.EV(bs.cs, bank)},
+			RoundEV:        bs.expectOver(game.Dice{}, bs.roundScore(BankedRerolls(rollsLeft, bank))),
+			TheoreticalMax: sumMaxScores(bs.cs),
+		}
+	}
+	rerolls := BankedRerolls(rollsLeft, bank)
+
+	score := solveScoring(dice, bs.cs, bankedEV{bs.table, bs.table.BankAfter(rerolls)})
+	if rerolls == 0 {
+		return score
+	}
+	options := bs.RerollOptions(dice, rerolls)
+	if score.BestAction.EV >= options[0].EV {
+		score.TheoreticalMax = theoreticalMax(dice, rerolls, bs.cs)
+		return score
+	}
+	return Recommendation{
+		BestAction:       Action{Type: RerollAction, Keep: options[0].Keep, EV: options[0].EV},
+		RoundEV:          bs.expectOver(options[0].Keep, bs.roundScore(rerolls-1)),
+		TheoreticalMax:   theoreticalMax(dice, rerolls, bs.cs),
+		TopRerollOptions: options[:min(len(options), 10)],
+	}
+}
+
+// KeepEV returns the expected value of holding keep and rerolling the
+// other dice with rerolls available (so rerolls-1 after this one).
+func (bs *BankedSolver) KeepEV(keep game.Dice, rerolls int) float64 {
+	return bs.expectOver(keep, bs.layers[rerolls-1])
+}
+
+// expectOver returns the mean of layer over the dice reached by holding
+// keep and rerolling the rest.
+func (bs *BankedSolver) expectOver(keep game.Dice, layer []float64) float64 {
+	val := 0.0
+	for _, ro := range bs.table.Model().Rerolls(game.NumDice - keep.Total()) {
+		val += ro.Prob * layer[game.DiceIndex(game.AddDice(keep, ro.Dice))]
+	}
+	return val
+}
+
+// roundScore returns the expected score banked this round for every dice
+// outcome with r rerolls available under the policy of Solve, building
+// it and every layer below it on first use.
+func (bs *BankedSolver) roundScore(r int) []float64 {
+	allDice := game.AllDice()
+	for len(bs.round) <= r {
+		n := len(bs.round)
+		cur := make([]float64, len(allDice))
+		for i, d := range allDice {
+			score := solveScoring(d, bs.cs, bankedEV{bs.table, bs.table.BankAfter(n)})
+			cur[i] = score.RoundEV
+			if n == 0 {
+				continue
+			}
+			if best := bs.RerollOptions(d, n)[0]; best.EV > score.BestAction.EV {
+				cur[i] = bs.expectOver(best.Keep, bs.round[n-1])
+			}
+		}
+		bs.round = append(bs.round, cur)
+	}
+	return bs.round[r]
+}
+
+// RerollOptions returns every keep that rerolls at least one die, sorted
+// by descending EV.
+func (bs *BankedSolver) RerollOptions(dice game.Dice, rerolls int) []RerollOption {
+	var options []RerollOption
+	ev.EnumerateKeeps(dice, func(keep game.Dice, numKept int) {
+		if numKept == game.NumDice {
+			return
+		}
+		options = append(options, RerollOption{
+			Keep:        keep,
+			NumRerolled: game.NumDice - numKept,
+			EV:          bs.KeepEV(keep, rerolls),
+		})
+	})
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].EV > options[j].EV
+	})
+	return options
+}
+
+// bankedEV values category sets with a fixed bank, so solveScoring
+// values the categories left with the rerolls scoring now would save.
+type bankedEV struct {
+	table *ev.BankedTable
+	bank  int
+}
+
+func (b bankedEV) EV(cs game.CategorySet) float64 {
+	return b.table.EV(cs, b.bank)
+}
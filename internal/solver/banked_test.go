@@ -0,0 +1,84 @@
+package solver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestBankedSolverCapZero(t *testing.T) {
+	t.Parallel()
+
+	// With a cap of 0 nothing carries over and the advice is Solve's.
+	banked, err := ev.ComputeBanked(game.DefaultModel, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := ev.Compute(nil)
+	for _, cs := range []game.CategorySet{
+		game.AllCategories,
+		game.CategorySet(0).Add(game.CatSugarberry).Add(game.CatBasketOfFour).Add(game.CatMixedBasket),
+	} {
+		bs, rs := NewBankedSolver(cs, banked), NewRoundSolver(cs, table)
+		for _, d := range game.AllDice() {
+			for rollsLeft := 0; rollsLeft <= game.RollsPerRound; rollsLeft++ {
+				got, want := bs.Solve(d, rollsLeft, 0), rs.Solve(d, rollsLeft)
+				if got.BestAction.Type != want.BestAction.Type || math.Abs(got.BestAction.EV-want.BestAction.EV) > 1e-9 ||
+					math.Abs(got.RoundEV-want.RoundEV) > 1e-9 {
+					t.Fatalf("%v, %s, %d rolls left: %s (EV %.4f, round %.4f), want %s (EV %.4f, round %.4f)", cs, FormatKeep(d), rollsLeft,
+						FormatAction(got.BestAction), got.BestAction.EV, got.RoundEV, FormatAction(want.BestAction), want.BestAction.EV, want.RoundEV)
+				}
+			}
+		}
+	}
+}
+
+func TestBankedSolver(t *testing.T) {
+	t.Parallel()
+
+	banked, err := ev.ComputeBanked(game.DefaultModel, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := NewBankedSolver(game.AllCategories, banked)
+
+	// Four Moonberries with two rolls left: the standard game chases the
+	// fifth, but scoring now banks both rerolls for later rounds.
+	d := game.Dice{game.Pickleberry: 1, game.Moonberry: 4}
+	if rec := Solve(d, 2, game.AllCategories, ev.Compute(nil)); rec.BestAction.Type != RerollAction {
+		t.Fatalf("standard game: %s, want a reroll", FormatAction(rec.BestAction))
+	}
+	rec := bs.Solve(d, 2, 0)
+	if rec.BestAction.Type != ScoreAction || rec.BestAction.Category != game.CatBasketOfFour {
+		t.Fatalf("banked game: %s, want to score Basket of Four", FormatAction(rec.BestAction))
+	}
+	opt := rec.CategoryOptions[0]
+	if want := banked.EV(game.AllCategories.Remove(game.CatBasketOfFour), 2); opt.FutureEV != want {
+		t.Errorf("FutureEV = %v, want the EV with 2 rerolls banked (%v)", opt.FutureEV, want)
+	}
+
+	// Saved rerolls can be spent when the round's own are gone.
+	pests := game.Dice{game.Pest: 5}
+	if rec := bs.Solve(pests, 0, 0); rec.BestAction.Type != ScoreAction {
+		t.Errorf("no rerolls: %s, want a score", FormatAction(rec.BestAction))
+	}
+	rec = bs.Solve(pests, 0, 1)
+	if rec.BestAction.Type != RerollAction || len(rec.TopRerollOptions) == 0 {
+		t.Errorf("one reroll banked: %s, want a reroll", FormatAction(rec.BestAction))
+	}
+	if got, want := rec.BestAction.EV, bs.KeepEV(game.Dice{}, 1); got != want {
+		t.Errorf("rerolling every Pest with one reroll = %v, want %v", got, want)
+	}
+
+	// Before the first roll, the round's value grows with the bank.
+	for bank := 1; bank <= 2; bank++ {
+		if bs.Solve(game.Dice{}, game.RollsPerRound, bank).BestAction.EV <= bs.Solve(game.Dice{}, game.RollsPerRound, bank-1).BestAction.EV {
+			t.Errorf("pre-roll EV with a bank of %d is not above a bank of %d", bank, bank-1)
+		}
+	}
+	if got := BankedRerolls(game.RollsPerRound, 2); got != 4 {
+		t.Errorf("BankedRerolls(pre-roll, bank 2) = %d, want 4", got)
+	}
+}
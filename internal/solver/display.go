@@ -14,8 +14,15 @@ import (
 func FormatRecommendation(w io.Writer, rec Recommendation, dice game.Dice, rollsLeft int, cs game.CategorySet) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "=== Solver Recommendation ===")
+	diceText := dice.String()
+	if rec.Roll != nil {
+		diceText = rec.Roll.String() + "  " + diceText
+	}
+	if rollsLeft == game.RollsPerRound {
+		diceText = "(not rolled)"
+	}
 	fmt.Fprintf(w, "Dice: %s  |  Rolls left: %d  |  Categories: %d remaining\n",
-		dice, rollsLeft, cs.Count())
+		diceText, rollsLeft, cs.Count())
 	fmt.Fprintln(w)
 
 	switch rec.BestAction.Type {
@@ -23,8 +30,23 @@ func FormatRecommendation(w io.Writer, rec Recommendation, dice game.Dice, rolls
 		formatScoreRecommendation(w, rec)
 	case RerollAction:
 		formatRerollRecommendation(w, rec)
+	case RollAction:
+		formatRollRecommendation(w, rec)
+	}
+
+	if len(rec.Reasons) > 0 {
+		fmt.Fprintln(w, "Why:")
+		for _, r := range rec.Reasons {
+			fmt.Fprintf(w, "  - %s\n", r.Text)
+		}
+		fmt.Fprintln(w)
 	}
 
+	if sc := rec.Scorecard; sc != nil {
+		fmt.Fprintf(w, "Scorecard: %s\n", FormatScorecard(*sc))
+		fmt.Fprintf(w, "Score so far: %d  |  Expected final score: %.2f\n",
+			sc.Total(), float64(sc.Total())+rec.BestAction.EV)
+	}
 	fmt.Fprintf(w, "Theoretical max: %.0f\n", rec.TheoreticalMax)
 	fmt.Fprintln(w, "=============================")
 }
@@ -44,21 +66,132 @@ func formatScoreRecommendation(w io.Writer, rec Recommendation) {
 	fmt.Fprintln(w)
 }
 
+func formatRollRecommendation(w io.Writer, rec Recommendation) {
+	fmt.Fprintln(w, "Best action: ROLL all dice")
+	fmt.Fprintf(w, "  Expected score this round: %.2f\n", rec.RoundEV)
+	fmt.Fprintf(w, "  Expected value (rest of game): %.2f\n", rec.BestAction.EV)
+	fmt.Fprintln(w)
+}
+
 func formatRerollRecommendation(w io.Writer, rec Recommendation) {
 	best := rec.TopRerollOptions[0]
 	fmt.Fprintf(w, "Best action: REROLL\n")
 	fmt.Fprintf(w, "  Keep %s  (reroll %d)\n", FormatKeep(best.Keep), best.NumRerolled)
-	fmt.Fprintf(w, "  Expected value: %.2f\n", best.EV)
+	if m, ok := rec.Hold(best.Keep); ok {
+		fmt.Fprintf(w, "  %s\n", FormatHold(m))
+	}
+	fmt.Fprintf(w, "  Expected value: %.2f  (%.2f this round)\n", best.EV, rec.RoundEV)
 	fmt.Fprintln(w)
 
+	if best.Outcomes != nil {
+		formatOutcomeProfile(w, *best.Outcomes)
+	}
+
 	fmt.Fprintln(w, "Top reroll options:")
 	for i, opt := range rec.TopRerollOptions {
 		fmt.Fprintf(w, "  #%d  Keep %-24s  reroll %d  EV: %7.2f\n",
 			i+1, FormatKeep(opt.Keep), opt.NumRerolled, opt.EV)
+		if o := opt.Outcomes; o != nil {
+			fmt.Fprintf(w, "        likely %s (%.0f%%), expected score %.1f\n",
+				o.LikelyCategory, o.CategoryProb[o.LikelyCategory]*100, o.ExpectedScore)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// categoryShort holds compact category labels for tabular output.
+var categoryShort = [game.NumCategories]string{
+	game.CatJumbleberry:   "J",
+	game.CatSugarberry:    "S",
+	game.CatPickleberry:   "P",
+	game.CatMoonberry:     "M",
+	game.CatBasketOfThree: "3K",
+	game.CatBasketOfFour:  "4K",
+	game.CatBasketOfFive:  "5K",
+	game.CatMixedBasket:   "Mix",
+	game.CatFreeRoll:      "FR",
+}
+
+// formatOutcomeProfile writes the end-of-round outcome of the best keep:
+// which categories the final dice qualify for, which one gets scored,
+// and the distribution of the score banked.
+func formatOutcomeProfile(w io.Writer, o OutcomeProfile) {
+	fmt.Fprintln(w, "  Outcome after remaining rerolls (optimal play):")
+
+	var made, scored []string
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		if o.QualifyProb[c] > 0 {
+			made = append(made, fmt.Sprintf("%s %.0f%%", categoryShort[c], o.QualifyProb[c]*100))
+		}
+		if o.CategoryProb[c] > 0 {
+			scored = append(scored, fmt.Sprintf("%s %.0f%%", categoryShort[c], o.CategoryProb[c]*100))
+		}
+	}
+	fmt.Fprintf(w, "    Qualifies: %s\n", strings.Join(made, "  "))
+	fmt.Fprintf(w, "    Scored in: %s\n", strings.Join(scored, "  "))
+
+	// Bucket the score distribution by 5 points to keep it to one line.
+	const bucketSize = 5
+	var buckets []string
+	for i := 0; i < len(o.ScoreDist); {
+		lo := o.ScoreDist[i].Score / bucketSize * bucketSize
+		p := 0.0
+		for ; i < len(o.ScoreDist) && o.ScoreDist[i].Score < lo+bucketSize; i++ {
+			p += o.ScoreDist[i].Prob
+		}
+		buckets = append(buckets, fmt.Sprintf("%d-%d %.0f%%", lo, lo+bucketSize-1, p*100))
 	}
+	fmt.Fprintf(w, "    Score:     %s  (mean %.1f)\n", strings.Join(buckets, "  "), o.ExpectedScore)
 	fmt.Fprintln(w)
 }
 
+// FormatScorecard returns the scorecard in the compact notation read by
+// ParseScorecard, e.g. "j=6 s=4 3k=23 mix=-", or "new" if it is empty.
+func FormatScorecard(sc game.Scorecard) string {
+	var parts []string
+	sc.Used().ForEach(func(c game.Category) {
+		value := "-"
+		if p, _ := sc.Points(c); p > 0 {
+			value = fmt.Sprint(p)
+		}
+		parts = append(parts, c.Code()+"="+value)
+	})
+	if len(parts) == 0 {
+		return "new"
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatHold returns a human-readable string for held die positions,
+// e.g. "Hold dice 2, 4, 5", "Hold die 3" or "Hold no dice".
+func FormatHold(m game.HoldMask) string {
+	pos := m.Positions()
+	switch len(pos) {
+	case 0:
+		return "Hold no dice"
+	case 1:
+		return fmt.Sprintf("Hold die %d", pos[0])
+	}
+	parts := make([]string, len(pos))
+	for i, p := range pos {
+		parts[i] = fmt.Sprint(p)
+	}
+	return "Hold dice " + strings.Join(parts, ", ")
+}
+
+// FormatAction returns a short description of an action, e.g. "keep 2M 1P",
+// "score Moonberry" or "roll all dice".
+func FormatAction(a Action) string {
+	switch a.Type {
+	case ScoreAction:
+		return "score " + a.Category.String()
+	case RerollAction:
+		return "keep " + FormatKeep(a.Keep)
+	default:
+		return "roll all dice"
+	}
+}
+
 // FormatKeep returns a human-readable string for a keep decision.
 // e.g., "2M 1P" or "nothing" if keeping 0 dice.
 func FormatKeep(keep game.Dice) string {
@@ -86,3 +219,63 @@ func FormatKeep(keep game.Dice) string {
 	}
 	return strings.Join(parts, " ")
 }
+
+// FormatDieHold is FormatHold naming the held dice of a die set that
+// have a name, e.g. "Hold die 5 (golden)" or "Hold dice 2, 5 (die 5
+// golden)".
+func FormatDieHold(m game.HoldMask, dice *game.DieSet) string {
+	pos := m.Positions()
+	var names []string
+	for _, p := range pos {
+		if name := dice.Name(p - 1); name != "" {
+			names = append(names, fmt.Sprintf("die %d %s", p, name))
+		}
+	}
+	switch {
+	case len(names) == 0:
+		return FormatHold(m)
+	case len(pos) == 1:
+		return fmt.Sprintf("%s (%s)", FormatHold(m), dice.Name(pos[0]-1))
+	}
+	return fmt.Sprintf("%s (%s)", FormatHold(m), strings.Join(names, ", "))
+}
+
+// FormatMixedRecommendation writes a recommendation for a die set whose
+// dice differ, as FormatRecommendation does for identical dice.
+func FormatMixedRecommendation(w io.Writer, rec MixedRecommendation, rollsLeft int, cs game.CategorySet) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "=== Solver Recommendation ===")
+	diceText := rec.Roll.String() + "  " + rec.Roll.Dice().String()
+	if rollsLeft == game.RollsPerRound {
+		diceText = "(not rolled)"
+	}
+	fmt.Fprintf(w, "Dice: %s  |  Rolls left: %d  |  Categories: %d remaining\n",
+		diceText, rollsLeft, cs.Count())
+	fmt.Fprintln(w)
+
+	switch rec.BestAction.Type {
+	case ScoreAction:
+		formatScoreRecommendation(w, Recommendation{CategoryOptions: rec.CategoryOptions})
+	case RollAction:
+		formatRollRecommendation(w, Recommendation{BestAction: rec.BestAction, RoundEV: rec.RoundEV})
+	case RerollAction:
+		best := rec.HoldOptions[0]
+		fmt.Fprintln(w, "Best action: REROLL")
+		fmt.Fprintf(w, "  %s: keep %s  (reroll %d)\n", FormatDieHold(best.Hold, rec.Dice), FormatKeep(best.Keep), game.NumDice-best.Hold.Count())
+		fmt.Fprintf(w, "  Expected value: %.2f\n", best.EV)
+		fmt.Fprintln(w)
+	}
+
+	if len(rec.HoldOptions) > 0 {
+		n := min(len(rec.HoldOptions), 10)
+		fmt.Fprintln(w, "Top hold options:")
+		for i, opt := range rec.HoldOptions[:n] {
+			fmt.Fprintf(w, "  #%-2d %-36s  keep %-14s  EV: %7.2f\n",
+				i+1, FormatDieHold(opt.Hold, rec.Dice), FormatKeep(opt.Keep), opt.EV)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Theoretical max: %.0f\n", rec.TheoreticalMax)
+	fmt.Fprintln(w, "=============================")
+}
@@ -0,0 +1,101 @@
+// This file computes the exact distribution of the final score under
+// optimal play by propagating state probabilities forward through the
+// solver's decisions.
+package solver
+
+import (
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// roundEnd is one way a round can end: points scored in a category.
+type roundEnd struct {
+	cat    game.Category
+	points int
+}
+
+// roundEnds returns the probability of each way a round with cs open can
+// end when every decision is Solve's best action, starting from dist, the
+// probability of each dice outcome (indexed like game.AllDice) showing
+// with rollsLeft rolls left.
+func roundEnds(rs *RoundSolver, dist []float64, rollsLeft int) map[roundEnd]float64 {
+	allDice := game.AllDice()
+	ends := make(map[roundEnd]float64)
+	for r := rollsLeft; ; r-- {
+		next := make([]float64, len(allDice))
+		for i, p := range dist {
+			if p == 0 {
+				continue
+			}
+			d := allDice[i]
+			best := rs.Solve(d, r).BestAction
+			if best.Type == ScoreAction {
+				ends[roundEnd{best.Category, game.Score(d, best.Category)}] += p
+				continue
+			}
+			for _, ro := range rs.table.Model().Rerolls(game.NumDice - best.Keep.Total()) {
+				next[game.DiceIndex(game.AddDice(best.Keep, ro.Dice))] += p * ro.Prob
+			}
+		}
+		if r == 0 {
+			return ends
+		}
+		dist = next
+	}
+}
+
+// FinalScoreDist returns the exact distribution of the final score when
+// play continues from start with every decision being Solve's best
+// action, as the simulator plays. The result is ascending by score and
+// omits impossible scores.
+func FinalScoreDist(start game.GameState, table *ev.Table) []ScoreProb {
+	allDice := game.AllDice()
+
+	// The first round may be in progress; later rounds start with a roll
+	// of all dice.
+	firstRoll := make([]float64, len(allDice))
+	for i := range allDice {
+		firstRoll[i] = table.Model().FirstRollProb(i)
+	}
+	dist, rollsLeft := firstRoll, game.RollsPerRound-1
+	if start.RollsLeft < game.RollsPerRound {
+		dist = make([]float64, len(allDice))
+		dist[game.DiceIndex(start.CurrentDice)] = 1
+		rollsLeft = int(start.RollsLeft)
+	}
+
+	// states[cs][score] is the probability of reaching the start of a
+	// round with cs open and score points.
+	states := map[game.CategorySet]map[int]float64{
+		start.CategoriesLeft: {int(start.Score): 1},
+	}
+	for range start.CategoriesLeft.Count() {
+		next := make(map[game.CategorySet]map[int]float64)
+		for cs, scores := range states {
+			ends := roundEnds(NewRoundSolver(cs, table), dist, rollsLeft)
+			for end, pe := range ends {
+				left := cs.Remove(end.cat)
+				if next[left] == nil {
+					next[left] = make(map[int]float64)
+				}
+				for score, ps := range scores {
+					next[left][score+end.points] += ps * pe
+				}
+			}
+		}
+		states = next
+		dist, rollsLeft = firstRoll, game.RollsPerRound-1
+	}
+
+	final := states[0]
+	out := make([]ScoreProb, 0, len(final))
+	for score, p := range final {
+		out = append(out, ScoreProb{Score: score, Prob: p})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Score < out[j].Score
+	})
+	return out
+}
@@ -0,0 +1,167 @@
+// This file attaches structured, human-readable reasons to a
+// Recommendation, explaining why the best action beats the alternatives.
+package solver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// ReasonKind identifies what a Reason explains.
+type ReasonKind string
+
+const (
+	ReasonTarget          ReasonKind = "target"           // a category the recommended keep is aiming for
+	ReasonOpportunityCost ReasonKind = "opportunity_cost" // future EV given up by using a category now
+	ReasonRunnerUp        ReasonKind = "runner_up"        // why the second-best action is worse
+)
+
+// Reason is one structured explanation for a recommendation.
+type Reason struct {
+	Kind        ReasonKind
+	Category    game.Category // target or opportunity-cost category
+	Alternative Action        // runner-up: the second-best action
+	Prob        float64       // target: probability of making the category
+	Value       float64       // opportunity cost, or EV margin over the runner-up
+	Text        string        // short sentence for display
+}
+
+// minTargetProb is the smallest chance of scoring a category for it to be
+// reported as something the keep is aiming for.
+const minTargetProb = 0.15
+
+// maxTargets caps the number of target reasons per recommendation.
+const maxTargets = 3
+
+// Explain attaches reasons to rec, which must be the recommendation Solve
+// returned for the same state.
+func Explain(rec *Recommendation, dice game.Dice, rollsLeft int, cs game.CategorySet, table *ev.Table) {
+	NewRoundSolver(cs, table).Explain(rec, dice, rollsLeft)
+}
+
+// Explain attaches reasons to rec, which must be the recommendation Solve
+// returned for the same dice and rolls left.
+func (rs *RoundSolver) Explain(rec *Recommendation, dice game.Dice, rollsLeft int) {
+	rec.Reasons = nil
+	if rs.cs == 0 {
+		return // no categories left: nothing to choose between
+	}
+	switch rec.BestAction.Type {
+	case ScoreAction:
+		rs.explainScore(rec, dice, rollsLeft)
+	case RerollAction:
+		rs.explainReroll(rec, dice, rollsLeft)
+	case RollAction:
+		rs.addTargets(rec, rs.Outcomes(game.Dice{}, game.RollsPerRound))
+	}
+}
+
+func (rs *RoundSolver) explainScore(rec *Recommendation, dice game.Dice, rollsLeft int) {
+	if len(rec.CategoryOptions) == 0 {
+		return
+	}
+	best := rec.CategoryOptions[0]
+	cost := rs.table.EV(rs.cs) - best.FutureEV
+
+	verb := "Using " + best.Category.String()
+	if best.ImmediateScore == 0 {
+		verb = "Scratching " + best.Category.String()
+	}
+	rec.Reasons = append(rec.Reasons, Reason{
+		Kind:     ReasonOpportunityCost,
+		Category: best.Category,
+		Value:    cost,
+		Text: fmt.Sprintf("%s now gives up %.2f of future EV (%.2f with it open, %.2f without).",
+			verb, cost, rs.table.EV(rs.cs), best.FutureEV),
+	})
+
+	// The runner-up is the better of the second category and, if rerolls
+	// remain, the best reroll.
+	var runnerUp *Reason
+	if len(rec.CategoryOptions) > 1 {
+		alt := rec.CategoryOptions[1]
+		margin := best.TotalValue - alt.TotalValue
+		runnerUp = &Reason{
+			Kind:        ReasonRunnerUp,
+			Alternative: Action{Type: ScoreAction, Category: alt.Category, EV: alt.TotalValue},
+			Value:       margin,
+			Text: fmt.Sprintf("Scoring %d in %s instead is %.2f worse: %+d points now, %+.2f future EV.",
+				alt.ImmediateScore, alt.Category, margin,
+				alt.ImmediateScore-best.ImmediateScore, alt.FutureEV-best.FutureEV),
+		}
+	}
+	if rollsLeft > 0 {
+		alt := rs.RerollOptions(dice, rollsLeft)[0]
+		margin := best.TotalValue - alt.EV
+		if runnerUp == nil || margin < runnerUp.Value {
+			runnerUp = &Reason{
+				Kind:        ReasonRunnerUp,
+				Alternative: Action{Type: RerollAction, Keep: alt.Keep, EV: alt.EV},
+				Value:       margin,
+				Text: fmt.Sprintf("Keeping %s and rerolling %d instead is %.2f worse.",
+					FormatKeep(alt.Keep), alt.NumRerolled, margin),
+			}
+		}
+	}
+	if runnerUp != nil {
+		rec.Reasons = append(rec.Reasons, *runnerUp)
+	}
+}
+
+func (rs *RoundSolver) explainReroll(rec *Recommendation, dice game.Dice, rollsLeft int) {
+	rs.addTargets(rec, rs.Outcomes(rec.BestAction.Keep, rollsLeft))
+
+	// The runner-up is the better of the second keep and scoring now.
+	scoreRec := solveScoring(dice, rs.cs, rs.table)
+	alt := scoreRec.CategoryOptions[0]
+	margin := rec.BestAction.EV - alt.TotalValue
+	runnerUp := Reason{
+		Kind:        ReasonRunnerUp,
+		Alternative: Action{Type: ScoreAction, Category: alt.Category, EV: alt.TotalValue},
+		Value:       margin,
+		Text: fmt.Sprintf("Scoring %d in %s now instead is %.2f worse.",
+			alt.ImmediateScore, alt.Category, margin),
+	}
+	if len(rec.TopRerollOptions) > 1 {
+		opt := rec.TopRerollOptions[1]
+		if m := rec.BestAction.EV - opt.EV; m < margin {
+			runnerUp = Reason{
+				Kind:        ReasonRunnerUp,
+				Alternative: Action{Type: RerollAction, Keep: opt.Keep, EV: opt.EV},
+				Value:       m,
+				Text: fmt.Sprintf("Keeping %s and rerolling %d instead is %.2f worse.",
+					FormatKeep(opt.Keep), opt.NumRerolled, m),
+			}
+		}
+	}
+	rec.Reasons = append(rec.Reasons, runnerUp)
+}
+
+// addTargets appends a target reason for each category the profile is
+// likely to end up scoring, most likely first.
+func (rs *RoundSolver) addTargets(rec *Recommendation, profile OutcomeProfile) {
+	var targets []game.Category
+	rs.cs.ForEach(func(cat game.Category) {
+		if profile.CategoryProb[cat] >= minTargetProb {
+			targets = append(targets, cat)
+		}
+	})
+	sort.SliceStable(targets, func(i, j int) bool {
+		return profile.CategoryProb[targets[i]] > profile.CategoryProb[targets[j]]
+	})
+	if len(targets) > maxTargets {
+		targets = targets[:maxTargets]
+	}
+	for _, cat := range targets {
+		rec.Reasons = append(rec.Reasons, Reason{
+			Kind:     ReasonTarget,
+			Category: cat,
+			Prob:     profile.QualifyProb[cat],
+			Text: fmt.Sprintf("Aiming for %s: %.0f%% chance to make it, scored there %.0f%% of the time.",
+				cat, profile.QualifyProb[cat]*100, profile.CategoryProb[cat]*100),
+		})
+	}
+}
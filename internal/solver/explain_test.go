@@ -0,0 +1,144 @@
+package solver
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+var (
+	testTableOnce sync.Once
+	testTable     *ev.Table
+)
+
+// computedTable returns a freshly computed EV table, shared across tests.
+func computedTable() *ev.Table {
+	testTableOnce.Do(func() {
+		testTable = ev.Compute(nil)
+	})
+	return testTable
+}
+
+func TestFinalDistSumsToOne(t *testing.T) {
+	t.Parallel()
+
+	rs := NewRoundSolver(game.AllCategories, computedTable())
+	for rollsLeft := 1; rollsLeft <= 2; rollsLeft++ {
+		for _, keep := range []game.Dice{{}, {0, 0, 0, 1, 0}, {2, 1, 1, 0, 0}} {
+			sum := 0.0
+			for _, p := range rs.finalDist(keep, rollsLeft) {
+				sum += p
+			}
+			if math.Abs(sum-1.0) > 1e-9 {
+				t.Errorf("finalDist(%v, %d) sums to %v, want 1", keep, rollsLeft, sum)
+			}
+		}
+	}
+}
+
+func TestFinalDistMatchesKeepEV(t *testing.T) {
+	t.Parallel()
+
+	// Scoring the final dice optimally must reproduce the keep's EV.
+	table := computedTable()
+	cs := game.AllCategories.Remove(game.CatFreeRoll)
+	rs := NewRoundSolver(cs, table)
+	keep := game.Dice{0, 0, 1, 1, 0}
+
+	got := 0.0
+	for i, p := range rs.finalDist(keep, 2) {
+		d := game.AllDice()[i]
+		cat := rs.bestCategory(d)
+		got += p * (float64(game.Score(d, cat)) + table.EV(cs.Remove(cat)))
+	}
+	if want := rs.KeepEV(keep, 2); math.Abs(got-want) > 1e-9 {
+		t.Errorf("value of final distribution = %v, want KeepEV %v", got, want)
+	}
+}
+
+func TestExplainReroll(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	dice := game.Dice{2, 1, 1, 1, 0}
+	rec := Solve(dice, 2, game.AllCategories, table)
+	if rec.BestAction.Type != RerollAction {
+		t.Fatalf("expected a reroll recommendation, got %+v", rec.BestAction)
+	}
+	Explain(&rec, dice, 2, game.AllCategories, table)
+
+	var targets, runnerUps int
+	for _, r := range rec.Reasons {
+		if r.Text == "" {
+			t.Errorf("reason %+v has no text", r)
+		}
+		switch r.Kind {
+		case ReasonTarget:
+			targets++
+			if r.Prob < 0 || r.Prob > 1 {
+				t.Errorf("target %v probability %v out of range", r.Category, r.Prob)
+			}
+		case ReasonRunnerUp:
+			runnerUps++
+			if r.Value < 0 {
+				t.Errorf("runner-up margin %v is negative", r.Value)
+			}
+		}
+	}
+	if targets == 0 || targets > maxTargets {
+		t.Errorf("got %d target reasons, want 1-%d", targets, maxTargets)
+	}
+	if runnerUps != 1 {
+		t.Errorf("got %d runner-up reasons, want 1", runnerUps)
+	}
+}
+
+func TestExplainScore(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	dice := game.Dice{0, 0, 1, 3, 1}
+	cs := game.AllCategories.Remove(game.CatFreeRoll)
+	rec := Solve(dice, 0, cs, table)
+	Explain(&rec, dice, 0, cs, table)
+
+	if len(rec.Reasons) != 2 {
+		t.Fatalf("got %d reasons, want 2: %+v", len(rec.Reasons), rec.Reasons)
+	}
+
+	cost := rec.Reasons[0]
+	if cost.Kind != ReasonOpportunityCost || cost.Category != rec.BestAction.Category {
+		t.Errorf("first reason = %+v, want opportunity cost of %v", cost, rec.BestAction.Category)
+	}
+	want := table.EV(cs) - table.EV(cs.Remove(rec.BestAction.Category))
+	if math.Abs(cost.Value-want) > 1e-9 {
+		t.Errorf("opportunity cost = %v, want %v", cost.Value, want)
+	}
+
+	runnerUp := rec.Reasons[1]
+	if runnerUp.Kind != ReasonRunnerUp || runnerUp.Alternative.Category != rec.CategoryOptions[1].Category {
+		t.Errorf("second reason = %+v, want runner-up %v", runnerUp, rec.CategoryOptions[1].Category)
+	}
+	if !strings.Contains(runnerUp.Text, rec.CategoryOptions[1].Category.String()) {
+		t.Errorf("runner-up text %q does not name the category", runnerUp.Text)
+	}
+}
+
+func TestExplainNoCategories(t *testing.T) {
+	t.Parallel()
+
+	// With no categories left there is nothing to explain, and no panic.
+	table := computedTable()
+	dice := game.Dice{0, 0, 1, 3, 1}
+	for rollsLeft := 0; rollsLeft < game.RollsPerRound; rollsLeft++ {
+		rec := Solve(dice, rollsLeft, 0, table)
+		Explain(&rec, dice, rollsLeft, 0, table)
+		if len(rec.Reasons) != 0 {
+			t.Errorf("%d rolls left: got reasons %+v, want none", rollsLeft, rec.Reasons)
+		}
+	}
+}
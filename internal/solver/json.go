@@ -2,20 +2,36 @@
 // suitable for API responses and structured output.
 package solver
 
+import "github.com/iadams749/JBFieldsSolver/internal/game"
+
 // RecommendationJSON is the JSON-friendly representation of a Recommendation.
 type RecommendationJSON struct {
+	Roll             string            `json:"roll,omitempty"` // dice in table order, e.g. "JJSPM"
 	BestAction       ActionJSON        `json:"best_action"`
+	RoundEV          float64           `json:"round_ev"`
 	TheoreticalMax   float64           `json:"theoretical_max"`
 	CategoryOptions  []CategoryOptJSON `json:"category_options"`
 	TopRerollOptions []RerollOptJSON   `json:"top_reroll_options"`
+	Reasons          []ReasonJSON      `json:"reasons"`
+	Scorecard        *ScorecardJSON    `json:"scorecard,omitempty"`
+}
+
+// ScorecardJSON summarizes the player's scorecard alongside a
+// recommendation.
+type ScorecardJSON struct {
+	Boxes              game.Scorecard `json:"boxes"`    // points per used category
+	Notation           string         `json:"notation"` // e.g. "j=6 s=4 mix=-"
+	Total              int            `json:"total"`
+	ExpectedFinalScore float64        `json:"expected_final_score"` // total + best action EV
 }
 
 // ActionJSON is the JSON-friendly representation of an Action.
 type ActionJSON struct {
-	Type     string  `json:"type"`     // "score" or "reroll"
+	Type     string  `json:"type"`     // "score", "reroll" or "roll"
 	Keep     string  `json:"keep"`     // e.g. "1M" or "" for score
 	Category string  `json:"category"` // e.g. "Jumbleberry" or "" for reroll
 	EV       float64 `json:"ev"`
+	HoldMask []bool  `json:"hold_mask,omitempty"` // per die position, for rerolls when the roll order is known
 }
 
 // CategoryOptJSON is the JSON-friendly representation of a CategoryOption.
@@ -28,25 +44,42 @@ type CategoryOptJSON struct {
 
 // RerollOptJSON is the JSON-friendly representation of a RerollOption.
 type RerollOptJSON struct {
-	Keep        string  `json:"keep"`
-	NumRerolled int     `json:"num_rerolled"`
-	EV          float64 `json:"ev"`
+	Keep        string       `json:"keep"`
+	NumRerolled int          `json:"num_rerolled"`
+	EV          float64      `json:"ev"`
+	HoldMask    []bool       `json:"hold_mask,omitempty"`
+	Outcomes    *OutcomeJSON `json:"outcomes,omitempty"`
 }
 
-// RecommendationToJSON converts a Recommendation to its JSON-friendly form.
-func RecommendationToJSON(rec Recommendation) RecommendationJSON {
-	var actionType string
-	var keep, category string
+// OutcomeJSON is the JSON-friendly representation of an OutcomeProfile.
+// Probability maps are keyed by category name and omit categories with
+// probability 0, whether they are taken or open but out of reach.
+type OutcomeJSON struct {
+	Qualify        map[string]float64 `json:"qualify"`
+	Category       map[string]float64 `json:"category"`
+	ScoreDist      []ScoreProbJSON    `json:"score_distribution"`
+	ExpectedScore  float64            `json:"expected_score"`
+	LikelyCategory string             `json:"likely_category"`
+}
 
-	switch rec.BestAction.Type {
-	case ScoreAction:
-		actionType = "score"
-		category = rec.BestAction.Category.String()
-	case RerollAction:
-		actionType = "reroll"
-		keep = FormatKeep(rec.BestAction.Keep)
-	}
+// ScoreProbJSON is the JSON-friendly representation of a ScoreProb.
+type ScoreProbJSON struct {
+	Score int     `json:"score"`
+	Prob  float64 `json:"prob"`
+}
+
+// ReasonJSON is the JSON-friendly representation of a Reason.
+type ReasonJSON struct {
+	Kind        string      `json:"kind"`                  // "target", "opportunity_cost" or "runner_up"
+	Category    string      `json:"category,omitempty"`    // target or opportunity-cost category
+	Alternative *ActionJSON `json:"alternative,omitempty"` // runner-up action
+	Probability float64     `json:"probability,omitempty"`
+	Value       float64     `json:"value,omitempty"`
+	Text        string      `json:"text"`
+}
 
+// RecommendationToJSON converts a Recommendation to its JSON-friendly form.
+func RecommendationToJSON(rec Recommendation) RecommendationJSON {
 	var catOpts []CategoryOptJSON
 	for _, opt := range rec.CategoryOptions {
 		catOpts = append(catOpts, CategoryOptJSON{
@@ -63,9 +96,28 @@ func RecommendationToJSON(rec Recommendation) RecommendationJSON {
 			Keep:        FormatKeep(opt.Keep),
 			NumRerolled: opt.NumRerolled,
 			EV:          opt.EV,
+			HoldMask:    holdMaskJSON(rec, opt.Keep),
+			Outcomes:    outcomeToJSON(opt.Outcomes),
 		})
 	}
 
+	var reasons []ReasonJSON
+	for _, r := range rec.Reasons {
+		rj := ReasonJSON{
+			Kind:        string(r.Kind),
+			Probability: r.Prob,
+			Value:       r.Value,
+			Text:        r.Text,
+		}
+		if r.Kind == ReasonRunnerUp {
+			alt := ActionToJSON(r.Alternative)
+			rj.Alternative = &alt
+		} else {
+			rj.Category = r.Category.String()
+		}
+		reasons = append(reasons, rj)
+	}
+
 	// Ensure empty slices serialize as [] not null
 	if catOpts == nil {
 		catOpts = []CategoryOptJSON{}
@@ -73,16 +125,103 @@ func RecommendationToJSON(rec Recommendation) RecommendationJSON {
 	if rerollOpts == nil {
 		rerollOpts = []RerollOptJSON{}
 	}
+	if reasons == nil {
+		reasons = []ReasonJSON{}
+	}
+
+	best := ActionToJSON(rec.BestAction)
+	if rec.BestAction.Type == RerollAction {
+		best.HoldMask = holdMaskJSON(rec, rec.BestAction.Keep)
+	}
+
+	var roll string
+	if rec.Roll != nil {
+		roll = rec.Roll.String()
+	}
+
+	var card *ScorecardJSON
+	if sc := rec.Scorecard; sc != nil {
+		card = &ScorecardJSON{
+			Boxes:              *sc,
+			Notation:           FormatScorecard(*sc),
+			Total:              sc.Total(),
+			ExpectedFinalScore: float64(sc.Total()) + rec.BestAction.EV,
+		}
+	}
 
 	return RecommendationJSON{
-		BestAction: ActionJSON{
-			Type:     actionType,
-			Keep:     keep,
-			Category: category,
-			EV:       rec.BestAction.EV,
-		},
+		Roll:             roll,
+		BestAction:       best,
+		RoundEV:          rec.RoundEV,
 		TheoreticalMax:   rec.TheoreticalMax,
 		CategoryOptions:  catOpts,
 		TopRerollOptions: rerollOpts,
+		Reasons:          reasons,
+		Scorecard:        card,
+	}
+}
+
+// ActionToJSON converts an Action to its JSON-friendly form.
+func ActionToJSON(a Action) ActionJSON {
+	var actionType string
+	var keep, category string
+
+	switch a.Type {
+	case ScoreAction:
+		actionType = "score"
+		category = a.Category.String()
+	case RerollAction:
+		actionType = "reroll"
+		keep = FormatKeep(a.Keep)
+	case RollAction:
+		actionType = "roll"
+	}
+
+	return ActionJSON{
+		Type:     actionType,
+		Keep:     keep,
+		Category: category,
+		EV:       a.EV,
+	}
+}
+
+// holdMaskJSON returns the held positions for keep as one flag per die,
+// or nil if the roll order is unknown.
+func holdMaskJSON(rec Recommendation, keep game.Dice) []bool {
+	m, ok := rec.Hold(keep)
+	if !ok {
+		return nil
+	}
+	mask := make([]bool, game.NumDice)
+	for i := range mask {
+		mask[i] = m.Held(i)
+	}
+	return mask
+}
+
+// outcomeToJSON converts an OutcomeProfile to its JSON-friendly form,
+// returning nil if o is nil.
+func outcomeToJSON(o *OutcomeProfile) *OutcomeJSON {
+	if o == nil {
+		return nil
+	}
+	out := &OutcomeJSON{
+		Qualify:        make(map[string]float64),
+		Category:       make(map[string]float64),
+		ScoreDist:      []ScoreProbJSON{},
+		ExpectedScore:  o.ExpectedScore,
+		LikelyCategory: o.LikelyCategory.String(),
+	}
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		if o.QualifyProb[c] > 0 {
+			out.Qualify[c.String()] = o.QualifyProb[c]
+		}
+		if o.CategoryProb[c] > 0 {
+			out.Category[c.String()] = o.CategoryProb[c]
+		}
+	}
+	for _, sp := range o.ScoreDist {
+		out.ScoreDist = append(out.ScoreDist, ScoreProbJSON{Score: sp.Score, Prob: sp.Prob})
 	}
+	return out
 }
@@ -0,0 +1,154 @@
+package solver
+
+import (
+	"math"
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// HoldOption is one way to hold dice of a roll and reroll the rest, with
+// a die set whose dice differ.
+type HoldOption struct {
+	Hold game.HoldMask
+	Keep game.Dice // faces of the held dice
+	EV   float64
+}
+
+// MixedRecommendation is the solver output for a die set whose dice
+// differ. A reroll is given as the dice to hold, not only their faces,
+// since holding a golden Moonberry is not the same as holding a plain one.
+type MixedRecommendation struct {
+	BestAction     Action        // Keep holds the faces of Hold for a reroll
+	Hold           game.HoldMask // dice to hold when BestAction is a reroll
+	Roll           game.Roll     // the roll solved; zero before the first roll
+	Dice           *game.DieSet  // the die set, for naming dice
+	RoundEV        float64       // the part of BestAction.EV expected to be banked this round
+	TheoreticalMax float64       // as in Recommendation
+
+	CategoryOptions []CategoryOption // populated when scoring
+	HoldOptions     []HoldOption     // every distinct hold that rerolls a die, best first
+}
+
+// MixedSolver answers queries for a single round with a fixed set of
+// remaining categories and a die set whose dice differ. It is the
+// counterpart of RoundSolver for an ev.MixedTable; its layers are indexed
+// by game.RollIndex.
+type MixedSolver struct {
+	cs     game.CategorySet
+	table  *ev.MixedTable
+	layers [game.RollsPerRound][]float64
+	holds  [game.RollsPerRound + 1]*ev.HoldValues // holds[r]: holding before a roll with r rolls left
+	round  [game.RollsPerRound + 1]*ev.HoldValues // round[r]: as holds, for the score banked this round; built lazily
+}
+
+// NewMixedSolver returns a MixedSolver for the category set cs. Building
+// its layers takes a few milliseconds.
+func NewMixedSolver(cs game.CategorySet, table *ev.MixedTable) *MixedSolver {
+	ms := &MixedSolver{cs: cs, table: table}
+	dice := table.Dice()
+	allRolls := game.AllRolls()
+
+	ms.layers[0] = make([]float64, len(allRolls))
+	for i, r := range allRolls {
+		d := r.Dice()
+		best := math.Inf(-1)
+		cs.ForEach(func(cat game.Category) {
+			if val := float64(game.Score(d, cat)) + table.EV(cs.Remove(cat)); val > best {
+				best = val
+			}
+		})
+		ms.layers[0][i] = best
+	}
+	for r := 1; r <= game.RollsPerRound; r++ {
+		ms.holds[r] = ev.NewHoldValues(dice, ms.layers[r-1])
+		if r < game.RollsPerRound {
+			ms.layers[r] = make([]float64, len(allRolls))
+			ev.ComputeMixedRerollLayer(dice, ms.layers[r-1], ms.layers[r])
+		}
+	}
+	return ms
+}
+
+// HoldEV returns the expected value of holding the dice of roll in m and
+// rerolling the others with rollsLeft rolls left.
+func (ms *MixedSolver) HoldEV(roll game.Roll, m game.HoldMask, rollsLeft int) float64 {
+	return ms.holds[rollsLeft].Value(roll, m)
+}
+
+// HoldOptions returns every distinct hold of roll that rerolls at least
+// one die, sorted by descending EV.
+func (ms *MixedSolver) HoldOptions(roll game.Roll, rollsLeft int) []HoldOption {
+	var options []HoldOption
+	ms.table.Dice().EnumerateHolds(roll, func(m game.HoldMask) {
+		if m.Count() == game.NumDice {
+			return // holding every die is a score decision
+		}
+		options = append(options, HoldOption{Hold: m, Keep: heldDice(roll, m), EV: ms.HoldEV(roll, m, rollsLeft)})
+	})
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].EV > options[j].EV
+	})
+	return options
+}
+
+// Solve computes the optimal action for the roll and rolls left, as
+// RoundSolver.Solve does; with game.RollsPerRound the roll is ignored.
+func (ms *MixedSolver) Solve(roll game.Roll, rollsLeft int) MixedRecommendation {
+	dice := roll.Dice()
+	if rollsLeft == game.RollsPerRound {
+		return MixedRecommendation{
+			BestAction:     Action{Type: RollAction, EV: ms.table.EV(ms.cs)},
+			Dice:           ms.table.Dice(),
+			RoundEV:        ms.roundHold(game.RollsPerRound).Value(game.Roll{}, 0),
+			TheoreticalMax: sumMaxScores(ms.cs),
+		}
+	}
+
+	score := solveScoring(dice, ms.cs, ms.table)
+	rec := MixedRecommendation{
+		BestAction:      score.BestAction,
+		RoundEV:         score.RoundEV,
+		Roll:            roll,
+		Dice:            ms.table.Dice(),
+		TheoreticalMax:  theoreticalMax(dice, rollsLeft, ms.cs),
+		CategoryOptions: score.CategoryOptions,
+	}
+	if rollsLeft == 0 {
+		return rec
+	}
+	rec.HoldOptions = ms.HoldOptions(roll, rollsLeft)
+	if best := rec.HoldOptions[0]; best.EV > score.BestAction.EV {
+		rec.BestAction = Action{Type: RerollAction, Keep: best.Keep, EV: best.EV}
+		rec.Hold = best.Hold
+		rec.RoundEV = ms.roundHold(rollsLeft).Value(roll, best.Hold)
+	}
+	return rec
+}
+
+// roundHold returns the expected score banked this round of holding
+// dice before a roll with rollsLeft rolls left, under optimal play. It is
+// built from the RoundEV of every roll with one roll fewer, on first use.
+func (ms *MixedSolver) roundHold(rollsLeft int) *ev.HoldValues {
+	if ms.round[rollsLeft] == nil {
+		allRolls := game.AllRolls()
+		vals := make([]float64, len(allRolls))
+		for i, r := range allRolls {
+			vals[i] = ms.Solve(r, rollsLeft-1).RoundEV
+		}
+		ms.round[rollsLeft] = ev.NewHoldValues(ms.table.Dice(), vals)
+	}
+	return ms.round[rollsLeft]
+}
+
+// heldDice returns the faces of the dice of roll held in m.
+func heldDice(roll game.Roll, m game.HoldMask) game.Dice {
+	var d game.Dice
+	for i, b := range roll {
+		if m.Held(i) {
+			d[b]++
+		}
+	}
+	return d
+}
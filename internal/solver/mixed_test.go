@@ -0,0 +1,134 @@
+package solver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// goldenTable returns the EV table of four standard dice and a golden die
+// favoring Moonberries at position 5.
+func goldenTable(t *testing.T) *ev.MixedTable {
+	t.Helper()
+	golden, err := game.NewDiceModel([game.NumBerryTypes]float64{0.2, 0.2, 0.2, 0.3, 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dice, err := game.GoldenDieSet(game.DefaultModel, golden, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ev.ComputeMixed(dice, nil)
+}
+
+func TestMixedSolver(t *testing.T) {
+	t.Parallel()
+
+	table := goldenTable(t)
+	ms := NewMixedSolver(game.AllCategories, table)
+	roll, err := ParseRoll("JMJJM")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := ms.Solve(roll, 1)
+	if rec.BestAction.Type != RerollAction || rec.Hold != 0b10010 {
+		t.Errorf("best action = %s holding %05b, want to hold both Moonberries (10010)", FormatAction(rec.BestAction), rec.Hold)
+	}
+	if rec.BestAction.Keep != (game.Dice{game.Moonberry: 2}) || rec.BestAction.EV != rec.HoldOptions[0].EV {
+		t.Errorf("best action %+v does not match best hold %+v", rec.BestAction, rec.HoldOptions[0])
+	}
+
+	// Holding one Moonberry, keep the plain one and reroll the golden die:
+	// it is the one likelier to come up Moonberry again.
+	plain, golden := ms.HoldEV(roll, 0b00010, 1), ms.HoldEV(roll, 0b10000, 1)
+	if plain <= golden {
+		t.Errorf("holding the plain Moonberry = %v, want above holding the golden one = %v", plain, golden)
+	}
+	for i := 1; i < len(rec.HoldOptions); i++ {
+		if rec.HoldOptions[i].EV > rec.HoldOptions[i-1].EV {
+			t.Fatalf("hold options out of order at #%d", i+1)
+		}
+	}
+
+	// Scoring when no rolls are left.
+	if rec := ms.Solve(roll, 0); rec.BestAction.Type != ScoreAction || len(rec.HoldOptions) != 0 {
+		t.Errorf("with no rolls left: %s with %d hold options, want a score", FormatAction(rec.BestAction), len(rec.HoldOptions))
+	}
+}
+
+func TestMixedSolverPreRoll(t *testing.T) {
+	t.Parallel()
+
+	table := goldenTable(t)
+	cs := game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatBasketOfThree)
+	ms := NewMixedSolver(cs, table)
+	pre := ms.Solve(game.Roll{}, game.RollsPerRound)
+	if pre.BestAction.Type != RollAction || pre.BestAction.EV != table.EV(cs) {
+		t.Fatalf("pre-roll = %+v, want a roll worth %v", pre.BestAction, table.EV(cs))
+	}
+
+	// Averaging over the first roll reproduces both EVs.
+	gameEV, roundEV := 0.0, 0.0
+	for _, r := range game.AllRolls() {
+		rec := ms.Solve(r, 2)
+		gameEV += table.Dice().RollProb(r, 0) * rec.BestAction.EV
+		roundEV += table.Dice().RollProb(r, 0) * rec.RoundEV
+	}
+	if math.Abs(gameEV-pre.BestAction.EV) > 1e-9 {
+		t.Errorf("averaged EV = %v, pre-roll EV = %v", gameEV, pre.BestAction.EV)
+	}
+	if math.Abs(roundEV-pre.RoundEV) > 1e-9 {
+		t.Errorf("averaged round EV = %v, pre-roll round EV = %v", roundEV, pre.RoundEV)
+	}
+	if pre.RoundEV <= 0 || pre.RoundEV >= pre.BestAction.EV {
+		t.Errorf("RoundEV = %v, want in (0, %v)", pre.RoundEV, pre.BestAction.EV)
+	}
+}
+
+func TestFormatDieHold(t *testing.T) {
+	t.Parallel()
+
+	dice, err := game.GoldenDieSet(game.DefaultModel, game.DefaultModel, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mask game.HoldMask
+		want string
+	}{
+		{0, "Hold no dice"},
+		{0b00110, "Hold dice 2, 3"},
+		{0b10000, "Hold die 5 (golden)"},
+		{0b10010, "Hold dice 2, 5 (die 5 golden)"},
+	}
+	for _, tt := range tests {
+		if got := FormatDieHold(tt.mask, dice); got != tt.want {
+			t.Errorf("FormatDieHold(%05b) = %q, want %q", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestMixedSolverRoundEV(t *testing.T) {
+	t.Parallel()
+
+	// Five standard dice: the round EV is RoundSolver's.
+	d := game.DefaultModel
+	mixed := ev.ComputeMixed(game.NewDieSet([game.NumDice]*game.DiceModel{d, d, d, d, d}), nil)
+	cs := game.CategorySet(0).Add(game.CatSugarberry).Add(game.CatBasketOfFour).Add(game.CatFreeRoll)
+	ms, rs := NewMixedSolver(cs, mixed), NewRoundSolver(cs, ev.Compute(nil))
+	for _, input := range []string{"SSPMX", "JJJMX", "SSSSM"} {
+		roll, err := ParseRoll(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rollsLeft := 0; rollsLeft < game.RollsPerRound; rollsLeft++ {
+			got, want := ms.Solve(roll, rollsLeft), rs.Solve(roll.Dice(), rollsLeft)
+			if math.Abs(got.RoundEV-want.RoundEV) > 1e-9 {
+				t.Errorf("%s, %d rolls left: RoundEV = %v, want %v", input, rollsLeft, got.RoundEV, want.RoundEV)
+			}
+		}
+	}
+}
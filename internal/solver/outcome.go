@@ -0,0 +1,75 @@
+// This file computes outcome profiles: what a keep is likely to produce
+// by the end of the round when play continues optimally.
+package solver
+
+import (
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// ScoreProb pairs a score banked this round with its probability.
+type ScoreProb struct {
+	Score int
+	Prob  float64
+}
+
+// OutcomeProfile describes the end-of-round result of a keep decision
+// under optimal continuation.
+type OutcomeProfile struct {
+	QualifyProb    [game.NumCategories]float64 // P(final dice qualify for the category)
+	CategoryProb   [game.NumCategories]float64 // P(the category is the one scored)
+	ScoreDist      []ScoreProb                 // distribution of the score banked, ascending by score
+	ExpectedScore  float64                     // mean of ScoreDist
+	LikelyCategory game.Category               // category most likely to be scored
+}
+
+// AddOutcomes attaches an OutcomeProfile to each of rec's top reroll options.
+func AddOutcomes(rec *Recommendation, rollsLeft int, cs game.CategorySet, table *ev.Table) {
+	rs := NewRoundSolver(cs, table)
+	for i := range rec.TopRerollOptions {
+		profile := rs.Outcomes(rec.TopRerollOptions[i].Keep, rollsLeft)
+		rec.TopRerollOptions[i].Outcomes = &profile
+	}
+}
+
+// Outcomes returns the OutcomeProfile for holding keep and rerolling the
+// rest with rollsLeft rolls left. With an empty keep and rollsLeft equal to
+// game.RollsPerRound it describes the whole round from before the first roll.
+func (rs *RoundSolver) Outcomes(keep game.Dice, rollsLeft int) OutcomeProfile {
+	var profile OutcomeProfile
+	scoreProb := make(map[int]float64)
+
+	for i, p := range rs.finalDist(keep, rollsLeft) {
+		if p == 0 {
+			continue
+		}
+		d := game.AllDice()[i]
+		cat := rs.bestCategory(d)
+		score := game.Score(d, cat)
+
+		profile.CategoryProb[cat] += p
+		scoreProb[score] += p
+		profile.ExpectedScore += p * float64(score)
+		rs.cs.ForEach(func(c game.Category) {
+			if game.Qualifies(d, c) {
+				profile.QualifyProb[c] += p
+			}
+		})
+	}
+
+	for score, p := range scoreProb {
+		profile.ScoreDist = append(profile.ScoreDist, ScoreProb{Score: score, Prob: p})
+	}
+	sort.Slice(profile.ScoreDist, func(i, j int) bool {
+		return profile.ScoreDist[i].Score < profile.ScoreDist[j].Score
+	})
+
+	rs.cs.ForEach(func(c game.Category) {
+		if profile.CategoryProb[c] > profile.CategoryProb[profile.LikelyCategory] {
+			profile.LikelyCategory = c
+		}
+	})
+	return profile
+}
@@ -0,0 +1,99 @@
+package solver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestOutcomes(t *testing.T) {
+	t.Parallel()
+
+	rs := NewRoundSolver(game.AllCategories, computedTable())
+	profile := rs.Outcomes(game.Dice{0, 0, 1, 1, 0}, 2)
+
+	catSum, scoreSum, mean := 0.0, 0.0, 0.0
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		catSum += profile.CategoryProb[c]
+		if p := profile.QualifyProb[c]; p < 0 || p > 1+1e-9 {
+			t.Errorf("QualifyProb[%v] = %v out of range", c, p)
+		}
+	}
+	for i, sp := range profile.ScoreDist {
+		scoreSum += sp.Prob
+		mean += sp.Prob * float64(sp.Score)
+		if i > 0 && profile.ScoreDist[i-1].Score >= sp.Score {
+			t.Errorf("ScoreDist not strictly ascending at index %d", i)
+		}
+	}
+
+	if math.Abs(catSum-1) > 1e-9 {
+		t.Errorf("CategoryProb sums to %v, want 1", catSum)
+	}
+	if math.Abs(scoreSum-1) > 1e-9 {
+		t.Errorf("ScoreDist sums to %v, want 1", scoreSum)
+	}
+	if math.Abs(mean-profile.ExpectedScore) > 1e-9 {
+		t.Errorf("ExpectedScore = %v, ScoreDist mean = %v", profile.ExpectedScore, mean)
+	}
+	if math.Abs(profile.QualifyProb[game.CatFreeRoll]-1) > 1e-9 {
+		t.Errorf("QualifyProb[Free Roll] = %v, want 1", profile.QualifyProb[game.CatFreeRoll])
+	}
+	// Holding a Pickleberry and a Moonberry guarantees both berries.
+	if math.Abs(profile.QualifyProb[game.CatMoonberry]-1) > 1e-9 {
+		t.Errorf("QualifyProb[Moonberry] = %v, want 1", profile.QualifyProb[game.CatMoonberry])
+	}
+	for c := game.Category(0); c < game.NumCategories; c++ {
+		if profile.CategoryProb[c] > profile.CategoryProb[profile.LikelyCategory] {
+			t.Errorf("LikelyCategory = %v, but %v is more likely", profile.LikelyCategory, c)
+		}
+	}
+}
+
+func TestOutcomesSingleCategory(t *testing.T) {
+	t.Parallel()
+
+	// With only Basket of Four left and four Moonberries held, the final
+	// dice always qualify and always score there.
+	cs := game.CategorySet(0).Add(game.CatBasketOfFour)
+	rs := NewRoundSolver(cs, computedTable())
+	profile := rs.Outcomes(game.Dice{0, 0, 0, 4, 0}, 1)
+
+	if profile.LikelyCategory != game.CatBasketOfFour {
+		t.Errorf("LikelyCategory = %v, want Basket of Four", profile.LikelyCategory)
+	}
+	if math.Abs(profile.QualifyProb[game.CatBasketOfFour]-1) > 1e-9 {
+		t.Errorf("QualifyProb[Basket of Four] = %v, want 1", profile.QualifyProb[game.CatBasketOfFour])
+	}
+	if profile.ScoreDist[0].Score < 28 {
+		t.Errorf("lowest score = %d, want at least 28 (4M + Pest)", profile.ScoreDist[0].Score)
+	}
+}
+
+func TestAddOutcomes(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	dice := game.Dice{2, 1, 1, 1, 0}
+	rec := Solve(dice, 2, game.AllCategories, table)
+	AddOutcomes(&rec, 2, game.AllCategories, table)
+
+	for i, opt := range rec.TopRerollOptions {
+		if opt.Outcomes == nil {
+			t.Fatalf("option %d has no outcome profile", i)
+		}
+	}
+
+	rj := RecommendationToJSON(rec)
+	out := rj.TopRerollOptions[0].Outcomes
+	if out == nil {
+		t.Fatal("JSON option has no outcomes")
+	}
+	if _, ok := out.Qualify["Free Roll"]; !ok {
+		t.Errorf("JSON qualify map missing Free Roll: %v", out.Qualify)
+	}
+	if out.LikelyCategory == "" || len(out.ScoreDist) == 0 {
+		t.Errorf("incomplete JSON outcomes: %+v", out)
+	}
+}
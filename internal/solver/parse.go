@@ -1,8 +1,9 @@
-// This file provides parsing utilities for converting user input
-// into game types (Dice and CategorySet).
+// This file provides parsing utilities for converting user input into
+// game types: dice and rolls, category sets and scorecards.
 package solver
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -55,15 +56,35 @@ func isAllLetters(s string) bool {
 
 // parseDiceSequence parses "JJSPM" format.
 func parseDiceSequence(input string) (game.Dice, error) {
-	var d game.Dice
+	r, err := parseRollSequence(input)
+	if err != nil {
+		return game.Dice{}, err
+	}
+	return r.Dice(), nil
+}
+
+// ParseRoll parses an ordered roll in sequence format ("JJSPM", one letter
+// per die in table order). Count format is rejected because it does not
+// say which die shows which face.
+func ParseRoll(input string) (game.Roll, error) {
+	input = strings.TrimSpace(input)
+	if len(input) != game.NumDice || !isAllLetters(input) {
+		return game.Roll{}, fmt.Errorf("roll must be %d letters, one per die (e.g. JJSPM)", game.NumDice)
+	}
+	return parseRollSequence(input)
+}
+
+// parseRollSequence parses a sequence of exactly NumDice face letters.
+func parseRollSequence(input string) (game.Roll, error) {
+	var r game.Roll
 	for i := 0; i < len(input); i++ {
 		b, ok := berryLetters[input[i]]
 		if !ok {
-			return game.Dice{}, fmt.Errorf("unknown die face %q at position %d (use J, S, P, M, or X)", string(input[i]), i+1)
+			return game.Roll{}, fmt.Errorf("unknown die face %q at position %d (use J, S, P, M, or X)", string(input[i]), i+1)
 		}
-		d[b]++
+		r[i] = b
 	}
-	return d, nil
+	return r, nil
 }
 
 // parseDiceCounts parses "2J 1S 1P 1M 0X" format.
@@ -176,6 +197,9 @@ func ParseCategories(input string) (game.CategorySet, error) {
 			}
 			cs = cs.Remove(cat)
 		}
+		if cs == 0 {
+			return 0, fmt.Errorf("every category removed")
+		}
 		return cs, nil
 	}
 
@@ -200,3 +224,60 @@ func ParseCategories(input string) (game.CategorySet, error) {
 
 	return cs, nil
 }
+
+// ParseScorecard parses a scorecard in compact notation: space- or
+// comma-separated entries of the form "category=points", where category
+// is any alias accepted by ParseCategories and points is a number or "-"
+// for a scratch (0 points), e.g. "j=6 s=4 3k=23 mix=-". Categories not
+// listed are open. An empty input or "new" is a fresh scorecard.
+func ParseScorecard(input string) (game.Scorecard, error) {
+	input = strings.TrimSpace(strings.ToLower(input))
+	var sc game.Scorecard
+	if input == "" || input == "new" {
+		return sc, nil
+	}
+
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || name == "" || value == "" {
+			return game.Scorecard{}, fmt.Errorf("invalid entry %q (expected format like 'j=6' or 'mix=-')", field)
+		}
+		cat, ok := categoryAliases[name]
+		if !ok {
+			return game.Scorecard{}, fmt.Errorf("unknown category %q", name)
+		}
+
+		points := 0
+		if value != "-" {
+			var err error
+			points, err = strconv.Atoi(value)
+			if err != nil {
+				return game.Scorecard{}, fmt.Errorf("invalid points in %q: %v", field, err)
+			}
+		}
+
+		var err error
+		if sc, err = sc.Record(cat, points); err != nil {
+			return game.Scorecard{}, err
+		}
+	}
+	return sc, nil
+}
+
+// ParseScorecardJSON decodes a scorecard from a JSON request field that
+// holds either a notation string (see ParseScorecard) or the object form
+// of game.Scorecard, e.g. {"Jumbleberry": 6}.
+func ParseScorecardJSON(data json.RawMessage) (game.Scorecard, error) {
+	var notation string
+	if err := json.Unmarshal(data, &notation); err == nil {
+		return ParseScorecard(notation)
+	}
+	var sc game.Scorecard
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return game.Scorecard{}, err
+	}
+	return sc, nil
+}
@@ -151,6 +151,33 @@ func TestParseDice(t *testing.T) {
 	}
 }
 
+func TestParseRoll(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    game.Roll
+		wantErr bool
+	}{
+		{"MJSJP", game.Roll{game.Moonberry, game.Jumbleberry, game.Sugarberry, game.Jumbleberry, game.Pickleberry}, false},
+		{" xpmsj ", game.Roll{game.Pest, game.Pickleberry, game.Moonberry, game.Sugarberry, game.Jumbleberry}, false},
+		{"2J 1S 1P 1M", game.Roll{}, true},
+		{"JJSPA", game.Roll{}, true},
+		{"JJSP", game.Roll{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRoll(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRoll(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRoll(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestParseCategories(t *testing.T) {
 	t.Parallel()
 
@@ -191,6 +218,12 @@ func TestParseCategories(t *testing.T) {
 			want:    game.AllCategories.Remove(game.CatJumbleberry).Remove(game.CatSugarberry),
 			wantErr: false,
 		},
+		{
+			name:    "all minus every category",
+			input:   "all-j-s-p-m-3k-4k-5k-mix-fr",
+			want:    0,
+			wantErr: true,
+		},
 		{
 			name:    "all minus invalid category",
 			input:   "all-j-invalid",
@@ -370,3 +403,74 @@ func TestCategoryAliases(t *testing.T) {
 		})
 	}
 }
+
+func TestParseScorecard(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		wantTotal int
+		wantLeft  int
+		wantErr   bool
+	}{
+		{name: "empty", input: "", wantTotal: 0, wantLeft: 9},
+		{name: "new", input: "new", wantTotal: 0, wantLeft: 9},
+		{name: "notation", input: "j=6 s=4 3k=23 mix=-", wantTotal: 33, wantLeft: 5},
+		{name: "commas and full names", input: "Jumbleberry=6, fr=35", wantTotal: 41, wantLeft: 7},
+		{name: "scratch as zero", input: "5k=0", wantTotal: 0, wantLeft: 8},
+		{name: "missing value", input: "j=", wantErr: true},
+		{name: "missing equals", input: "j6", wantErr: true},
+		{name: "unknown category", input: "q=4", wantErr: true},
+		{name: "bad number", input: "j=six", wantErr: true},
+		{name: "impossible score", input: "j=7", wantErr: true},
+		{name: "duplicate", input: "j=2 jb=4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sc, err := ParseScorecard(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScorecard(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if sc.Total() != tt.wantTotal || sc.CategoriesLeft().Count() != tt.wantLeft {
+				t.Errorf("ParseScorecard(%q) total = %d, left = %d; want %d, %d",
+					tt.input, sc.Total(), sc.CategoriesLeft().Count(), tt.wantTotal, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestScorecardNotationRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const notation = "j=6 s=4 3k=23 mix=-"
+	sc, err := ParseScorecard(notation)
+	if err != nil {
+		t.Fatalf("ParseScorecard() error = %v", err)
+	}
+	if got := FormatScorecard(sc); got != notation {
+		t.Errorf("FormatScorecard() = %q, want %q", got, notation)
+	}
+	if got := FormatScorecard(game.Scorecard{}); got != "new" {
+		t.Errorf("FormatScorecard(empty) = %q, want %q", got, "new")
+	}
+
+	for _, data := range []string{`"j=6 s=4 3k=23 mix=-"`, `{"Jumbleberry":6,"Sugarberry":4,"Basket of Three":23,"Mixed Basket":0}`} {
+		got, err := ParseScorecardJSON([]byte(data))
+		if err != nil {
+			t.Errorf("ParseScorecardJSON(%s) error = %v", data, err)
+			continue
+		}
+		if got != sc {
+			t.Errorf("ParseScorecardJSON(%s) = %+v, want %+v", data, got, sc)
+		}
+	}
+	if _, err := ParseScorecardJSON([]byte(`42`)); err == nil {
+		t.Error("ParseScorecardJSON(42) succeeded, want error")
+	}
+}
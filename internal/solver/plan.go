@@ -0,0 +1,82 @@
+// This file builds the optimal policy tree for the rest of a round:
+// for each reroll outcome, the next keep, and finally the category scored
+// at each end state, with the probability of reaching every node.
+package solver
+
+import (
+	"sort"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// DefaultPlanThreshold is the collapse threshold callers use for PlanRound
+// when none is given.
+const DefaultPlanThreshold = 0.01
+
+// PlanNode is one decision point in a round plan.
+type PlanNode struct {
+	Dice      game.Dice
+	RollsLeft int
+	Prob      float64      // probability of reaching this node from the root
+	Action    Action       // optimal action at this node
+	Score     int          // points banked, for a ScoreAction
+	Branches  []PlanBranch // reroll outcomes, for a RerollAction, most likely first
+}
+
+// PlanBranch is one reroll outcome below a PlanNode. Outcomes whose reach
+// probability falls below the plan threshold are merged into a single
+// collapsed branch with a nil Node.
+type PlanBranch struct {
+	Rolled    game.Dice // dice produced by the reroll (zero for a collapsed branch)
+	Prob      float64   // probability of this branch given its parent
+	Node      *PlanNode // resulting state, nil if collapsed
+	Collapsed int       // number of outcomes merged into this branch
+}
+
+// PlanRound returns the optimal policy tree for the rest of the round from
+// the given state. Branches reached with probability below threshold are
+// collapsed; pass 0 for the full tree.
+func PlanRound(dice game.Dice, rollsLeft int, cs game.CategorySet, table *ev.Table, threshold float64) *PlanNode {
+	return NewRoundSolver(cs, table).plan(dice, rollsLeft, 1, threshold)
+}
+
+func (rs *RoundSolver) plan(dice game.Dice, rollsLeft int, prob, threshold float64) *PlanNode {
+	rec := rs.Solve(dice, rollsLeft)
+	node := &PlanNode{
+		Dice:      dice,
+		RollsLeft: rollsLeft,
+		Prob:      prob,
+		Action:    rec.BestAction,
+	}
+	if rec.BestAction.Type == ScoreAction {
+		node.Score = game.Score(dice, rec.BestAction.Category)
+		return node
+	}
+
+	// A RollAction keeps nothing and rolls all dice.
+	keep := rec.BestAction.Keep
+	outcomes := append([]game.RerollOutcome(nil), rs.table.Model().Rerolls(game.NumDice-keep.Total())...)
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return outcomes[i].Prob > outcomes[j].Prob
+	})
+
+	var other PlanBranch
+	for _, ro := range outcomes {
+		if prob*ro.Prob < threshold {
+			other.Prob += ro.Prob
+			other.Collapsed++
+			continue
+		}
+		child := rs.plan(game.AddDice(keep, ro.Dice), rollsLeft-1, prob*ro.Prob, threshold)
+		node.Branches = append(node.Branches, PlanBranch{
+			Rolled: ro.Dice,
+			Prob:   ro.Prob,
+			Node:   child,
+		})
+	}
+	if other.Collapsed > 0 {
+		node.Branches = append(node.Branches, other)
+	}
+	return node
+}
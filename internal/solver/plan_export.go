@@ -0,0 +1,133 @@
+// This file provides text, JSON and Graphviz DOT exports for round plans.
+package solver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// FormatPlan writes the plan as an indented text tree.
+func FormatPlan(w io.Writer, root *PlanNode) {
+	formatPlanNode(w, root, "", "")
+}
+
+func formatPlanNode(w io.Writer, n *PlanNode, indent, edge string) {
+	fmt.Fprintf(w, "%s%s%s  (rolls left: %d)  %s\n", indent, edge, planDiceText(n), n.RollsLeft, planActionText(n))
+	for _, b := range n.Branches {
+		if b.Node == nil {
+			fmt.Fprintf(w, "%s  [%5.1f%%] %d other outcomes\n", indent, b.Prob*100, b.Collapsed)
+			continue
+		}
+		formatPlanNode(w, b.Node, indent+"  ",
+			fmt.Sprintf("[%5.1f%%] rolled %-12s -> ", b.Prob*100, FormatKeep(b.Rolled)))
+	}
+}
+
+// planDiceText describes the dice at a plan node.
+func planDiceText(n *PlanNode) string {
+	if n.RollsLeft == game.RollsPerRound {
+		return "not rolled"
+	}
+	return FormatKeep(n.Dice)
+}
+
+// planActionText describes the action at a plan node.
+func planActionText(n *PlanNode) string {
+	switch n.Action.Type {
+	case ScoreAction:
+		return fmt.Sprintf("score %d in %s  (EV %.2f)", n.Score, n.Action.Category, n.Action.EV)
+	case RollAction:
+		return fmt.Sprintf("roll all dice  (EV %.2f)", n.Action.EV)
+	}
+	return fmt.Sprintf("keep %s, reroll %d  (EV %.2f)",
+		FormatKeep(n.Action.Keep), n.Dice.Total()-n.Action.Keep.Total(), n.Action.EV)
+}
+
+// PlanNodeJSON is the JSON-friendly representation of a PlanNode.
+type PlanNodeJSON struct {
+	Dice      string           `json:"dice"`
+	RollsLeft int              `json:"rolls_left"`
+	Prob      float64          `json:"prob"`
+	Action    ActionJSON       `json:"action"`
+	Score     int              `json:"score,omitempty"`
+	Branches  []PlanBranchJSON `json:"branches,omitempty"`
+}
+
+// PlanBranchJSON is the JSON-friendly representation of a PlanBranch.
+type PlanBranchJSON struct {
+	Rolled    string        `json:"rolled,omitempty"`
+	Prob      float64       `json:"prob"`
+	Node      *PlanNodeJSON `json:"node,omitempty"`
+	Collapsed int           `json:"collapsed,omitempty"`
+}
+
+// PlanToJSON converts a plan tree to its JSON-friendly form.
+func PlanToJSON(n *PlanNode) *PlanNodeJSON {
+	out := &PlanNodeJSON{
+		Dice:      planDiceText(n),
+		RollsLeft: n.RollsLeft,
+		Prob:      n.Prob,
+		Action:    ActionToJSON(n.Action),
+		Score:     n.Score,
+	}
+	for _, b := range n.Branches {
+		bj := PlanBranchJSON{Prob: b.Prob, Collapsed: b.Collapsed}
+		if b.Node != nil {
+			bj.Rolled = FormatKeep(b.Rolled)
+			bj.Node = PlanToJSON(b.Node)
+		}
+		out.Branches = append(out.Branches, bj)
+	}
+	return out
+}
+
+// WritePlanDOT writes the plan as a Graphviz DOT digraph. Render it with
+// e.g. `dot -Tsvg plan.dot -o plan.svg`.
+func WritePlanDOT(w io.Writer, root *PlanNode) {
+	fmt.Fprintln(w, "digraph plan {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=9];`)
+
+	next := 0
+	var walk func(n *PlanNode) int
+	walk = func(n *PlanNode) int {
+		id := next
+		next++
+		style := ""
+		if n.Action.Type == ScoreAction {
+			style = ", style=filled, fillcolor=\"#e8f5e9\""
+		}
+		fmt.Fprintf(w, "  n%d [label=%s%s];\n", id,
+			dotQuote(fmt.Sprintf("%s\n%s\np=%.1f%%", planDiceText(n), planActionText(n), n.Prob*100)), style)
+
+		for _, b := range n.Branches {
+			if b.Node == nil {
+				other := next
+				next++
+				fmt.Fprintf(w, "  n%d [label=%s, style=dashed, fontcolor=gray40];\n", other,
+					dotQuote(fmt.Sprintf("%d other outcomes", b.Collapsed)))
+				fmt.Fprintf(w, "  n%d -> n%d [label=%s, style=dashed];\n", id, other,
+					dotQuote(fmt.Sprintf("%.1f%%", b.Prob*100)))
+				continue
+			}
+			child := walk(b.Node)
+			fmt.Fprintf(w, "  n%d -> n%d [label=%s];\n", id, child,
+				dotQuote(fmt.Sprintf("%s\n%.1f%%", FormatKeep(b.Rolled), b.Prob*100)))
+		}
+		return id
+	}
+	walk(root)
+	fmt.Fprintln(w, "}")
+}
+
+// dotQuote returns s as a quoted DOT string, with newlines as line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
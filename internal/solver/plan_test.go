@@ -0,0 +1,184 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+func TestPlanRound(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	dice := game.Dice{2, 1, 1, 1, 0}
+	root := PlanRound(dice, 2, game.AllCategories, table, 0.001)
+
+	rec := Solve(dice, 2, game.AllCategories, table)
+	if root.Action != rec.BestAction {
+		t.Errorf("root action = %+v, want %+v", root.Action, rec.BestAction)
+	}
+
+	// Every reroll node's branches must cover all outcomes, and the reach
+	// probabilities of leaves and collapsed branches must sum to 1.
+	total := 0.0
+	var walk func(n *PlanNode)
+	walk = func(n *PlanNode) {
+		if n.Action.Type == ScoreAction {
+			total += n.Prob
+			if n.Score != game.Score(n.Dice, n.Action.Category) {
+				t.Errorf("node %v: Score = %d, want %d", n.Dice, n.Score, game.Score(n.Dice, n.Action.Category))
+			}
+			return
+		}
+		if n.RollsLeft == 0 {
+			t.Errorf("node %v rerolls with no rolls left", n.Dice)
+		}
+		sum := 0.0
+		for _, b := range n.Branches {
+			sum += b.Prob
+			if b.Node == nil {
+				total += n.Prob * b.Prob
+				continue
+			}
+			if b.Node.RollsLeft != n.RollsLeft-1 {
+				t.Errorf("child rolls left = %d, want %d", b.Node.RollsLeft, n.RollsLeft-1)
+			}
+			if want := game.AddDice(n.Action.Keep, b.Rolled); b.Node.Dice != want {
+				t.Errorf("child dice = %v, want %v", b.Node.Dice, want)
+			}
+			walk(b.Node)
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("node %v: branch probabilities sum to %v, want 1", n.Dice, sum)
+		}
+	}
+	walk(root)
+
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("leaf probabilities sum to %v, want 1", total)
+	}
+}
+
+func TestPlanRoundCollapses(t *testing.T) {
+	t.Parallel()
+
+	root := PlanRound(game.Dice{2, 1, 1, 1, 0}, 2, game.AllCategories, computedTable(), 0.5)
+	if len(root.Branches) != 1 || root.Branches[0].Node != nil {
+		t.Fatalf("expected a single collapsed branch, got %+v", root.Branches)
+	}
+	if root.Branches[0].Collapsed == 0 || math.Abs(root.Branches[0].Prob-1) > 1e-9 {
+		t.Errorf("collapsed branch = %+v, want all outcomes with probability 1", root.Branches[0])
+	}
+}
+
+func TestPlanRoundScoreNow(t *testing.T) {
+	t.Parallel()
+
+	root := PlanRound(game.Dice{0, 0, 0, 5, 0}, 2, game.AllCategories, computedTable(), 0)
+	if root.Action.Type != ScoreAction || len(root.Branches) != 0 {
+		t.Errorf("five Moonberries should be scored immediately, got %+v", root)
+	}
+}
+
+func TestPlanRoundFull(t *testing.T) {
+	t.Parallel()
+
+	// A threshold of 0 keeps every outcome, however unlikely.
+	root := PlanRound(game.Dice{2, 1, 1, 1, 0}, 2, game.AllCategories, computedTable(), 0)
+	var walk func(n *PlanNode)
+	walk = func(n *PlanNode) {
+		for _, b := range n.Branches {
+			if b.Node == nil {
+				t.Fatalf("collapsed branch of %d outcomes below %v", b.Collapsed, FormatKeep(n.Dice))
+			}
+			walk(b.Node)
+		}
+	}
+	walk(root)
+	if want := len(game.Rerolls(game.NumDice - root.Action.Keep.Total())); len(root.Branches) != want {
+		t.Errorf("root has %d branches, want all %d outcomes", len(root.Branches), want)
+	}
+}
+
+func TestPlanExports(t *testing.T) {
+	t.Parallel()
+
+	root := PlanRound(game.Dice{2, 1, 1, 1, 0}, 1, game.AllCategories, computedTable(), 0.05)
+
+	var text bytes.Buffer
+	FormatPlan(&text, root)
+	if !strings.Contains(text.String(), "keep ") || !strings.Contains(text.String(), "score ") {
+		t.Errorf("text plan missing keep or score lines:\n%s", text.String())
+	}
+
+	var dot bytes.Buffer
+	WritePlanDOT(&dot, root)
+	out := dot.String()
+	if !strings.HasPrefix(out, "digraph plan {") || !strings.HasSuffix(strings.TrimSpace(out), "}") {
+		t.Errorf("DOT output is not a digraph:\n%s", out)
+	}
+	if got, want := strings.Count(out, "->"), countBranches(root); got != want {
+		t.Errorf("DOT has %d edges, want %d", got, want)
+	}
+
+	data, err := json.Marshal(PlanToJSON(root))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded PlanNodeJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded.Action.Type != "reroll" || len(decoded.Branches) != len(root.Branches) {
+		t.Errorf("decoded plan = %+v", decoded)
+	}
+}
+
+func TestDotQuote(t *testing.T) {
+	t.Parallel()
+
+	if got, want := dotQuote("a \"b\"\nc"), `"a \"b\"\nc"`; got != want {
+		t.Errorf("dotQuote() = %s, want %s", got, want)
+	}
+}
+
+func countBranches(n *PlanNode) int {
+	count := len(n.Branches)
+	for _, b := range n.Branches {
+		if b.Node != nil {
+			count += countBranches(b.Node)
+		}
+	}
+	return count
+}
+
+func TestPlanRoundPreRoll(t *testing.T) {
+	t.Parallel()
+
+	cs := game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatFreeRoll)
+	root := PlanRound(game.Dice{}, game.RollsPerRound, cs, computedTable(), 0.001)
+	if root.Action.Type != RollAction {
+		t.Fatalf("root action = %v, want RollAction", root.Action.Type)
+	}
+
+	sum := 0.0
+	for _, b := range root.Branches {
+		sum += b.Prob
+		if b.Node != nil && b.Node.RollsLeft != 2 {
+			t.Errorf("first-roll child has %d rolls left, want 2", b.Node.RollsLeft)
+		}
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("first-roll branches sum to %v, want 1", sum)
+	}
+
+	var text bytes.Buffer
+	FormatPlan(&text, root)
+	if !strings.HasPrefix(text.String(), "not rolled") {
+		t.Errorf("text plan should start with the unrolled root:\n%s", text.String())
+	}
+}
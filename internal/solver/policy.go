@@ -0,0 +1,129 @@
+// This file provides the optimal in-round policy as an explicit mapping
+// from dice to decisions, and forward propagation of dice probabilities
+// through it. Explanations and outcome statistics are built on top.
+package solver
+
+import (
+	"math"
+
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
+	"github.com/iadams749/JBFieldsSolver/internal/game"
+)
+
+// bestKeep returns the optimal keep for the dice at index idx with r
+// rerolls left (r >= 1). Keeping all dice means passing on this reroll.
+// Ties go to keeping all dice, then to the first keep in enumeration
+// order, matching ev.ComputeRerollLayer.
+func (rs *RoundSolver) bestKeep(idx, r int) game.Dice {
+	for len(rs.policy) < r {
+		rs.policy = append(rs.policy, rs.buildPolicy(len(rs.policy)+1))
+	}
+	return rs.policy[r-1][idx]
+}
+
+// buildPolicy computes the optimal keep for every dice outcome with r
+// rerolls left.
+func (rs *RoundSolver) buildPolicy(r int) []game.Dice {
+	allDice := game.AllDice()
+	prevLayer := rs.layer(r - 1)
+	keeps := make([]game.Dice, len(allDice))
+	for i, d := range allDice {
+		keeps[i] = d
+		best := prevLayer[i]
+		ev.EnumerateKeeps(d, func(keep game.Dice, numKept int) {
+			if numKept == game.NumDice {
+				return
+			}
+			if v := rs.KeepEV(keep, r); v > best {
+				keeps[i], best = keep, v
+			}
+		})
+	}
+	return keeps
+}
+
+// bestCategory returns the category the optimal policy scores with the
+// given dice once no rerolls remain.
+func (rs *RoundSolver) bestCategory(d game.Dice) game.Category {
+	bestVal := math.Inf(-1)
+	var bestCat game.Category
+	rs.cs.ForEach(func(cat game.Category) {
+		val := float64(game.Score(d, cat)) + rs.table.EV(rs.cs.Remove(cat))
+		if val > bestVal {
+			bestVal = val
+			bestCat = cat
+		}
+	})
+	return bestCat
+}
+
+// roundScore returns the expected score banked this round for every dice
+// outcome with r rerolls left under the optimal policy, building it and
+// every layer below it on first use. It is the mean of the outcome
+// profile's ScoreDist without the forward pass, so Solve can afford it.
+func (rs *RoundSolver) roundScore(r int) []float64 {
+	allDice := game.AllDice()
+	if len(rs.round) == 0 {
+		r0 := make([]float64, len(allDice))
+		for i, d := range allDice {
+			r0[i] = float64(game.Score(d, rs.bestCategory(d)))
+		}
+		rs.round = append(rs.round, r0)
+	}
+	for len(rs.round) <= r {
+		n := len(rs.round)
+		prev := rs.round[n-1]
+		cur := make([]float64, len(allDice))
+		for i, d := range allDice {
+			if k := rs.bestKeep(i, n); k == d {
+				cur[i] = prev[i] // passing on this reroll
+			} else {
+				cur[i] = rs.expectOver(k, prev)
+			}
+		}
+		rs.round = append(rs.round, cur)
+	}
+	return rs.round[r]
+}
+
+// keepRoundEV returns the expected score banked this round when keep is
+// held now with rollsLeft rolls left and play continues optimally.
+func (rs *RoundSolver) keepRoundEV(keep game.Dice, rollsLeft int) float64 {
+	return rs.expectOver(keep, rs.roundScore(rollsLeft-1))
+}
+
+// expectOver returns the mean of layer over the dice reached by holding
+// keep and rerolling the rest.
+func (rs *RoundSolver) expectOver(keep game.Dice, layer []float64) float64 {
+	val := 0.0
+	for _, ro := range rs.table.Model().Rerolls(game.NumDice - keep.Total()) {
+		val += ro.Prob * layer[game.DiceIndex(game.AddDice(keep, ro.Dice))]
+	}
+	return val
+}
+
+// finalDist returns the probability of each dice outcome (indexed like
+// game.AllDice) being the dice scored at the end of the round, when keep
+// is held now with rollsLeft rolls left and play continues optimally.
+func (rs *RoundSolver) finalDist(keep game.Dice, rollsLeft int) []float64 {
+	numDice := game.NumAllDice()
+	dist := make([]float64, numDice)
+	for _, ro := range rs.table.Model().Rerolls(game.NumDice - keep.Total()) {
+		dist[game.DiceIndex(game.AddDice(keep, ro.Dice))] += ro.Prob
+	}
+
+	for r := rollsLeft - 1; r >= 1; r-- {
+		next := make([]float64, numDice)
+		for i, p := range dist {
+			if p == 0 {
+				continue
+			}
+			k := rs.bestKeep(i, r)
+			for _, ro := range rs.table.Model().Rerolls(game.NumDice - k.Total()) {
+				next[game.DiceIndex(game.AddDice(k, ro.Dice))] += p * ro.Prob
+			}
+		}
+		dist = next
+	}
+	return dist
+}
@@ -17,6 +17,7 @@ type ActionType int
 const (
 	ScoreAction ActionType = iota
 	RerollAction
+	RollAction // roll all dice to start the round (dice not yet rolled)
 )
 
 // Action represents the solver's recommended move.
@@ -27,6 +28,22 @@ type Action struct {
 	EV       float64       // expected value of taking this action
 }
 
+// SameMove reports whether a and b make the same move, whatever their
+// EVs: the same category scored, the same dice kept, or both rolling.
+func (a Action) SameMove(b Action) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case ScoreAction:
+		return a.Category == b.Category
+	case RerollAction:
+		return a.Keep == b.Keep
+	default:
+		return true
+	}
+}
+
 // CategoryOption is one possible scoring choice when rollsLeft == 0.
 type CategoryOption struct {
 	Category       game.Category
@@ -40,27 +57,197 @@ type RerollOption struct {
 	Keep        game.Dice
 	NumRerolled int
 	EV          float64
+	Outcomes    *OutcomeProfile // populated by AddOutcomes
 }
 
 // Recommendation is the full solver output for a given game state.
+// BestAction.EV is the expected score over the rest of the game, including
+// this round; RoundEV is the part of it expected to be banked this round.
 type Recommendation struct {
 	BestAction       Action
+	RoundEV          float64
 	TheoreticalMax   float64
 	CategoryOptions  []CategoryOption // populated when rollsLeft == 0
 	TopRerollOptions []RerollOption   // populated when rollsLeft > 0 (top 10)
+	Reasons          []Reason         // populated by Explain
+
+	// Roll is the physical order of the dice, if known. Callers set it
+	// so keeps can be shown as die positions (see Hold).
+	Roll *game.Roll
+
+	// Scorecard is the player's scorecard, if known. Callers set it so
+	// output can show the current and expected final score.
+	Scorecard *game.Scorecard
+}
+
+// Hold returns the die positions to hold for keep in rec.Roll. It reports
+// false if the roll order is unknown or keep is not part of the roll.
+func (rec Recommendation) Hold(keep game.Dice) (game.HoldMask, bool) {
+	if rec.Roll == nil {
+		return 0, false
+	}
+	return rec.Roll.Hold(keep)
 }
 
 // Solve computes the optimal action for the given game state.
-// rollsLeft: 0 = must score, 1 = one reroll left, 2 = two rerolls left.
+// rollsLeft: 0 = must score, 1 = one reroll left, 2 = two rerolls left,
+// 3 = dice not yet rolled this round (dice is ignored).
 func Solve(dice game.Dice, rollsLeft int, cs game.CategorySet, table *ev.Table) Recommendation {
-	if rollsLeft == 0 {
-		return solveScoring(dice, cs, table)
+	return NewRoundSolver(cs, table).Solve(dice, rollsLeft)
+}
+
+// RoundSolver answers queries for a single round with a fixed set of
+// remaining categories. The value layers depend only on the category set,
+// so reusing one RoundSolver across many dice outcomes avoids rebuilding
+// them for every query.
+type RoundSolver struct {
+	cs     game.CategorySet
+	table  *ev.Table
+	layers [][]float64          // layers[r][diceIdx] = value with r rerolls left, built lazily
+	policy [][]game.Dice        // policy[r-1][diceIdx] = optimal keep with r rerolls left, built lazily
+	round  [][]float64          // round[r][diceIdx] = expected score banked this round with r rerolls left, built lazily
+	keeps  []*[numKeeps]float64 // keeps[r-1][keepIndex(keep)] = KeepEV(keep, r), NaN until computed
+}
+
+// numKeeps is the size of the keep index space: each face is held 0-5
+// times.
+const numKeeps = 7776
+
+// keepIndex returns the index of keep among all holds, reading its face
+// counts in base 6.
+func keepIndex(keep game.Dice) int {
+	idx, place := 0, 1
+	for _, n := range keep {
+		idx += int(n) * place
+		place *= game.NumDice + 1
 	}
-	return solveReroll(dice, rollsLeft, cs, table)
+	return idx
+}
+
+// NewRoundSolver returns a RoundSolver for the category set cs.
+func NewRoundSolver(cs game.CategorySet, table *ev.Table) *RoundSolver {
+	return &RoundSolver{cs: cs, table: table}
+}
+
+// Solve computes the optimal action for the given dice and rolls left.
+func (rs *RoundSolver) Solve(dice game.Dice, rollsLeft int) Recommendation {
+	switch rollsLeft {
+	case 0:
+		return solveScoring(dice, rs.cs, rs.table)
+	case game.RollsPerRound:
+		return rs.solvePreRoll()
+	default:
+		return rs.solveReroll(dice, rollsLeft)
+	}
+}
+
+// layer returns the value layer for r rerolls left, building it and every
+// layer below it on first use.
+func (rs *RoundSolver) layer(r int) []float64 {
+	allDice := game.AllDice()
+	numDice := len(allDice)
+
+	if len(rs.layers) == 0 {
+		// v0: the scoring layer (rollsLeft == 0) for all 126 dice outcomes.
+		v0 := make([]float64, numDice)
+		for i, d := range allDice {
+			bestVal := math.Inf(-1)
+			rs.cs.ForEach(func(cat game.Category) {
+				val := float64(game.Score(d, cat)) + rs.table.EV(rs.cs.Remove(cat))
+				if val > bestVal {
+					bestVal = val
+				}
+			})
+			v0[i] = bestVal
+		}
+		rs.layers = append(rs.layers, v0)
+	}
+
+	// As ev.ComputeRerollLayerWith, but through KeepEV, so each keep is
+	// evaluated once for all the dice that contain it.
+	for len(rs.layers) <= r {
+		n := len(rs.layers)
+		prev := rs.layers[n-1]
+		cur := make([]float64, numDice)
+		for i, d := range allDice {
+			cur[i] = prev[i] // keep all dice
+			ev.EnumerateKeeps(d, func(keep game.Dice, numKept int) {
+				if numKept < game.NumDice {
+					cur[i] = max(cur[i], rs.KeepEV(keep, n))
+				}
+			})
+		}
+		rs.layers = append(rs.layers, cur)
+	}
+	return rs.layers[r]
+}
+
+// KeepEV returns the expected value of holding keep and rerolling the
+// remaining dice with rollsLeft rolls left (so rollsLeft-1 after this one).
+// Values are cached per keep.
+func (rs *RoundSolver) KeepEV(keep game.Dice, rollsLeft int) float64 {
+	for len(rs.keeps) < rollsLeft {
+		c := new([numKeeps]float64)
+		for i := range c {
+			c[i] = math.NaN()
+		}
+		rs.keeps = append(rs.keeps, c)
+	}
+	c, idx := rs.keeps[rollsLeft-1], keepIndex(keep)
+	if !math.IsNaN(c[idx]) {
+		return c[idx]
+	}
+
+	prevLayer := rs.layer(rollsLeft - 1)
+	var keepEV float64
+	for _, ro := range rs.table.Model().Rerolls(game.NumDice - keep.Total()) {
+		resultDice := game.AddDice(keep, ro.Dice)
+		keepEV += ro.Prob * prevLayer[game.DiceIndex(resultDice)]
+	}
+	c[idx] = keepEV
+	return keepEV
+}
+
+// ActionEV returns the expected value of taking action a with the given
+// dice and rolls left, whether or not a is the optimal action.
+func (rs *RoundSolver) ActionEV(dice game.Dice, rollsLeft int, a Action) float64 {
+	if a.Type == ScoreAction {
+		return float64(game.Score(dice, a.Category)) + rs.table.EV(rs.cs.Remove(a.Category))
+	}
+	return rs.KeepEV(a.Keep, rollsLeft)
+}
+
+// RerollOptions returns every keep decision that rerolls at least one die,
+// sorted by descending EV.
+func (rs *RoundSolver) RerollOptions(dice game.Dice, rollsLeft int) []RerollOption {
+	var options []RerollOption
+	ev.EnumerateKeeps(dice, func(keep game.Dice, numKept int) {
+		numRerolled := game.NumDice - numKept
+		if numRerolled == 0 {
+			return // skip "keep all" — that's a score decision, not a reroll
+		}
+		options = append(options, RerollOption{
+			Keep:        keep,
+			NumRerolled: numRerolled,
+			EV:          rs.KeepEV(keep, rollsLeft),
+		})
+	})
+
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].EV > options[j].EV
+	})
+	return options
+}
+
+// evTable is the part of an EV table that scoring needs: the expected
+// value of the categories left after this round. *ev.Table and
+// *ev.MixedTable implement it.
+type evTable interface {
+	EV(cs game.CategorySet) float64
 }
 
 // solveScoring handles the case where the player must score (rollsLeft == 0).
-func solveScoring(dice game.Dice, cs game.CategorySet, table *ev.Table) Recommendation {
+func solveScoring(dice game.Dice, cs game.CategorySet, table evTable) Recommendation {
 	var options []CategoryOption
 	bestVal := math.Inf(-1)
 	var bestCat game.Category
@@ -91,81 +278,26 @@ func solveScoring(dice game.Dice, cs game.CategorySet, table *ev.Table) Recommen
 			Category: bestCat,
 			EV:       bestVal,
 		},
+		RoundEV:         float64(game.Score(dice, bestCat)),
 		TheoreticalMax:  theoreticalMax(dice, 0, cs),
 		CategoryOptions: options,
 	}
 }
 
 // solveReroll handles the case where the player can reroll (rollsLeft > 0).
-func solveReroll(dice game.Dice, rollsLeft int, cs game.CategorySet, table *ev.Table) Recommendation {
-	allDice := game.AllDice()
-	numDice := len(allDice)
-
-	// Build v0: the scoring layer (rollsLeft == 0) for all 126 dice outcomes.
-	v0 := make([]float64, numDice)
-	for i, d := range allDice {
-		bestVal := math.Inf(-1)
-		cs.ForEach(func(cat game.Category) {
-			val := float64(game.Score(d, cat)) + table.EV(cs.Remove(cat))
-			if val > bestVal {
-				bestVal = val
-			}
-		})
-		v0[i] = bestVal
-	}
-
-	// Build successive reroll layers v1..v_{rollsLeft-1}.
-	layers := make([][]float64, rollsLeft)
-	layers[0] = v0
-	for r := 1; r < rollsLeft; r++ {
-		layers[r] = make([]float64, numDice)
-		ev.ComputeRerollLayer(allDice, layers[r-1], layers[r])
-	}
-
+func (rs *RoundSolver) solveReroll(dice game.Dice, rollsLeft int) Recommendation {
 	// For the user's specific dice, enumerate all keep decisions
 	// and evaluate each against the appropriate layer.
-	prevLayer := layers[rollsLeft-1]
-	var allOptions []RerollOption
-	bestEV := math.Inf(-1)
-	var bestKeep game.Dice
-
-	ev.EnumerateKeeps(dice, func(keep game.Dice, numKept int) {
-		numRerolled := game.NumDice - numKept
-		if numRerolled == 0 {
-			return // skip "keep all" — that's a score decision, not a reroll
-		}
-
-		var keepEV float64
-		rerolls := game.Rerolls(numRerolled)
-		for _, ro := range rerolls {
-			resultDice := game.AddDice(keep, ro.Dice)
-			resultIdx := game.DiceIndex(resultDice)
-			keepEV += ro.Prob * prevLayer[resultIdx]
-		}
-
-		allOptions = append(allOptions, RerollOption{
-			Keep:        keep,
-			NumRerolled: numRerolled,
-			EV:          keepEV,
-		})
-
-		if keepEV > bestEV {
-			bestEV = keepEV
-			bestKeep = keep
-		}
-	})
+	allOptions := rs.RerollOptions(dice, rollsLeft)
+	best := allOptions[0]
 
 	// Check if scoring now (keeping all dice) beats every reroll option.
 	// If so, return a score recommendation instead.
-	scoreRec := solveScoring(dice, cs, table)
-	if scoreRec.BestAction.EV >= bestEV {
+	scoreRec := solveScoring(dice, rs.cs, rs.table)
+	if scoreRec.BestAction.EV >= best.EV {
 		return scoreRec
 	}
 
-	sort.Slice(allOptions, func(i, j int) bool {
-		return allOptions[i].EV > allOptions[j].EV
-	})
-
 	topN := 10
 	if len(allOptions) < topN {
 		topN = len(allOptions)
@@ -174,10 +306,24 @@ func solveReroll(dice game.Dice, rollsLeft int, cs game.CategorySet, table *ev.T
 	return Recommendation{
 		BestAction: Action{
 			Type: RerollAction,
-			Keep: bestKeep,
-			EV:   bestEV,
+			Keep: best.Keep,
+			EV:   best.EV,
 		},
-		TheoreticalMax:   theoreticalMax(dice, rollsLeft, cs),
+		RoundEV:          rs.keepRoundEV(best.Keep, rollsLeft),
+		TheoreticalMax:   theoreticalMax(dice, rollsLeft, rs.cs),
 		TopRerollOptions: allOptions[:topN],
 	}
 }
+
+// solvePreRoll handles the start of a round, before any dice are rolled.
+// The only action is to roll all dice; its value is the table's EV.
+func (rs *RoundSolver) solvePreRoll() Recommendation {
+	return Recommendation{
+		BestAction: Action{
+			Type: RollAction,
+			EV:   rs.table.EV(rs.cs),
+		},
+		RoundEV:        rs.Outcomes(game.Dice{}, game.RollsPerRound).ExpectedScore,
+		TheoreticalMax: sumMaxScores(rs.cs),
+	}
+}
@@ -1,8 +1,10 @@
 package solver
 
 import (
+	"math"
 	"testing"
 
+	"github.com/iadams749/JBFieldsSolver/internal/ev"
 	"github.com/iadams749/JBFieldsSolver/internal/game"
 )
 
@@ -56,6 +58,47 @@ func TestFormatKeep(t *testing.T) {
 	}
 }
 
+func TestFormatAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action Action
+		want   string
+	}{
+		{Action{Type: ScoreAction, Category: game.CatMoonberry}, "score Moonberry"},
+		{Action{Type: RerollAction, Keep: game.Dice{0, 0, 1, 2, 0}}, "keep 1P 2M"},
+		{Action{Type: RerollAction}, "keep nothing"},
+		{Action{Type: RollAction}, "roll all dice"},
+	}
+	for _, tt := range tests {
+		if got := FormatAction(tt.action); got != tt.want {
+			t.Errorf("FormatAction(%+v) = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestActionSameMove(t *testing.T) {
+	t.Parallel()
+
+	keep := game.Dice{0, 0, 1, 2, 0}
+	tests := []struct {
+		a, b Action
+		want bool
+	}{
+		{Action{Type: ScoreAction, Category: game.CatMoonberry, EV: 1}, Action{Type: ScoreAction, Category: game.CatMoonberry, EV: 2}, true},
+		{Action{Type: ScoreAction, Category: game.CatMoonberry}, Action{Type: ScoreAction, Category: game.CatPickleberry}, false},
+		{Action{Type: RerollAction, Keep: keep}, Action{Type: RerollAction, Keep: keep, EV: 3}, true},
+		{Action{Type: RerollAction, Keep: keep}, Action{Type: RerollAction}, false},
+		{Action{Type: RollAction, Keep: keep}, Action{Type: RollAction}, true},
+		{Action{Type: ScoreAction}, Action{Type: RerollAction}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.SameMove(tt.b); got != tt.want {
+			t.Errorf("%+v.SameMove(%+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func TestTheoreticalMax(t *testing.T) {
 	t.Parallel()
 
@@ -182,3 +225,200 @@ func TestSumMaxScores(t *testing.T) {
 		})
 	}
 }
+
+func TestSolvePreRoll(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	for _, cs := range []game.CategorySet{
+		game.AllCategories,
+		game.AllCategories.Remove(game.CatFreeRoll).Remove(game.CatMixedBasket),
+		game.CategorySet(0).Add(game.CatBasketOfFive),
+	} {
+		rs := NewRoundSolver(cs, table)
+		pre := rs.Solve(game.Dice{}, game.RollsPerRound)
+		if pre.BestAction.Type != RollAction {
+			t.Fatalf("cs=%v: pre-roll action = %v, want RollAction", cs, pre.BestAction.Type)
+		}
+		if pre.BestAction.EV != table.EV(cs) {
+			t.Errorf("cs=%v: pre-roll EV = %v, want table EV %v", cs, pre.BestAction.EV, table.EV(cs))
+		}
+
+		// Averaging the post-roll recommendations over the first roll must
+		// reproduce both the game EV and the round EV.
+		var gameEV, roundEV float64
+		for i, d := range game.AllDice() {
+			rec := rs.Solve(d, 2)
+			gameEV += game.FirstRollProb(i) * rec.BestAction.EV
+			roundEV += game.FirstRollProb(i) * rec.RoundEV
+		}
+		if math.Abs(gameEV-pre.BestAction.EV) > 1e-9 {
+			t.Errorf("cs=%v: averaged game EV = %v, pre-roll EV = %v", cs, gameEV, pre.BestAction.EV)
+		}
+		if math.Abs(roundEV-pre.RoundEV) > 1e-9 {
+			t.Errorf("cs=%v: averaged round EV = %v, pre-roll round EV = %v", cs, roundEV, pre.RoundEV)
+		}
+	}
+}
+
+func TestSolveRoundEV(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	dice := game.Dice{0, 0, 1, 3, 1}
+	rec := Solve(dice, 0, game.AllCategories, table)
+	if want := float64(game.Score(dice, rec.BestAction.Category)); rec.RoundEV != want {
+		t.Errorf("RoundEV = %v, want immediate score %v", rec.RoundEV, want)
+	}
+
+	// The round EV is part of the game EV.
+	rec = Solve(game.Dice{2, 1, 1, 1, 0}, 2, game.AllCategories, table)
+	if rec.RoundEV <= 0 || rec.RoundEV >= rec.BestAction.EV {
+		t.Errorf("RoundEV = %v, want in (0, %v)", rec.RoundEV, rec.BestAction.EV)
+	}
+
+	// Solve fills it in for rerolls as the outcome profile's mean.
+	rs := NewRoundSolver(game.AllCategories, table)
+	for _, rollsLeft := range []int{1, 2} {
+		rec := rs.Solve(game.Dice{2, 1, 1, 1, 0}, rollsLeft)
+		if rec.BestAction.Type != RerollAction {
+			t.Fatalf("%d rolls left: action = %v, want RerollAction", rollsLeft, rec.BestAction.Type)
+		}
+		if want := rs.Outcomes(rec.BestAction.Keep, rollsLeft).ExpectedScore; math.Abs(rec.RoundEV-want) > 1e-9 {
+			t.Errorf("%d rolls left: RoundEV = %v, want %v", rollsLeft, rec.RoundEV, want)
+		}
+	}
+}
+
+func TestFormatHold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mask game.HoldMask
+		want string
+	}{
+		{0, "Hold no dice"},
+		{1 << 2, "Hold die 3"},
+		{1<<1 | 1<<3 | 1<<4, "Hold dice 2, 4, 5"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatHold(tt.mask); got != tt.want {
+			t.Errorf("FormatHold(%05b) = %q, want %q", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendationHoldMask(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	roll, err := ParseRoll("MJSJP")
+	if err != nil {
+		t.Fatalf("ParseRoll() error = %v", err)
+	}
+	rec := Solve(roll.Dice(), 2, game.AllCategories, table)
+
+	if _, ok := rec.Hold(rec.BestAction.Keep); ok {
+		t.Error("Hold() reported ok without a roll order")
+	}
+	if rj := RecommendationToJSON(rec); rj.BestAction.HoldMask != nil || rj.Roll != "" {
+		t.Errorf("JSON without roll order has roll %q, hold mask %v", rj.Roll, rj.BestAction.HoldMask)
+	}
+
+	rec.Roll = &roll
+	rj := RecommendationToJSON(rec)
+	if rj.Roll != "MJSJP" {
+		t.Errorf("JSON roll = %q, want %q", rj.Roll, "MJSJP")
+	}
+	if len(rj.BestAction.HoldMask) != game.NumDice {
+		t.Fatalf("best action hold mask = %v, want %d flags", rj.BestAction.HoldMask, game.NumDice)
+	}
+
+	// The held positions must show exactly the recommended keep.
+	var held game.Dice
+	for i, h := range rj.BestAction.HoldMask {
+		if h {
+			held[roll[i]]++
+		}
+	}
+	if held != rec.BestAction.Keep {
+		t.Errorf("held dice = %v, want keep %v", held, rec.BestAction.Keep)
+	}
+	for i, opt := range rj.TopRerollOptions {
+		if len(opt.HoldMask) != game.NumDice {
+			t.Errorf("option %d hold mask = %v", i, opt.HoldMask)
+		}
+	}
+}
+
+func TestFinalScoreDist(t *testing.T) {
+	t.Parallel()
+
+	table := computedTable()
+	starts := []game.GameState{
+		game.NewGame(),
+		{RollsLeft: game.RollsPerRound, CategoriesLeft: game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatBasketOfThree), Score: 50},
+		{CurrentDice: game.Dice{0, 0, 1, 4, 0}, RollsLeft: 1, CategoriesLeft: game.CategorySet(0).Add(game.CatBasketOfFive), Score: 7},
+	}
+	for _, start := range starts {
+		dist := FinalScoreDist(start, table)
+		var total, mean float64
+		for i, sp := range dist {
+			if i > 0 && sp.Score <= dist[i-1].Score {
+				t.Fatalf("%v: scores not ascending at %d", start, i)
+			}
+			total += sp.Prob
+			mean += sp.Prob * float64(sp.Score)
+		}
+		if math.Abs(total-1) > 1e-9 {
+			t.Errorf("%v: probabilities sum to %v, want 1", start, total)
+		}
+
+		want := float64(start.Score) + table.EV(start.CategoriesLeft)
+		if start.RollsLeft < game.RollsPerRound {
+			want = float64(start.Score) + Solve(start.CurrentDice, int(start.RollsLeft), start.CategoriesLeft, table).BestAction.EV
+		}
+		if math.Abs(mean-want) > 1e-6 {
+			t.Errorf("%v: mean = %v, want EV %v", start, mean, want)
+		}
+	}
+
+	// Holding 4M with one reroll for Basket of Five: 5M (35) when the
+	// last die shows a Moonberry, otherwise a scratch.
+	pm := game.FaceProb[game.Moonberry]
+	dist := FinalScoreDist(starts[2], table)
+	if len(dist) != 2 || dist[0].Score != 7 || dist[1].Score != 42 || math.Abs(dist[1].Prob-pm) > 1e-12 {
+		t.Errorf("FinalScoreDist(4M, 5k) = %+v, want 42 with probability %v, else 7", dist, pm)
+	}
+}
+
+func TestSolveWithDiceModel(t *testing.T) {
+	t.Parallel()
+
+	// Dice that roll twice as many Moonberries and no Pests.
+	model, err := game.NewDiceModel([game.NumBerryTypes]float64{0.3, 0.3, 0.2, 0.2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := ev.ComputeWith(model, nil)
+
+	// The exact distribution, propagated with the model's reroll odds,
+	// must average to the model's EV.
+	start := game.GameState{RollsLeft: game.RollsPerRound, CategoriesLeft: game.CategorySet(0).Add(game.CatMoonberry).Add(game.CatBasketOfFive)}
+	var mean float64
+	for _, sp := range FinalScoreDist(start, table) {
+		mean += sp.Prob * float64(sp.Score)
+	}
+	if want := table.EV(start.CategoriesLeft); math.Abs(mean-want) > 1e-6 {
+		t.Errorf("mean final score = %v, want EV %v", mean, want)
+	}
+
+	// Holding 4M with one reroll left and only Basket of Five open: the
+	// fifth Moonberry comes with the model's probability.
+	cs := game.CategorySet(0).Add(game.CatBasketOfFive)
+	four := game.Dice{game.Moonberry: 4}
+	if got, want := NewRoundSolver(cs, table).KeepEV(four, 1), 0.2*35; math.Abs(got-want) > 1e-9 {
+		t.Errorf("KeepEV(4M) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// minExpected is the smallest expected count a chi-square bin may have;
+// smaller bins are pooled with their neighbours.
+const minExpected = 5
+
+// GOFResult is the outcome of a goodness-of-fit test.
+type GOFResult struct {
+	Statistic float64 // chi-square statistic, or KS distance D
+	DF        int     // degrees of freedom (chi-square only)
+	P         float64 // p-value: small values reject the distribution
+}
+
+// ChiSquare tests whether the sample follows a discrete distribution,
+// given as the probability of each value. Values are taken in increasing
+// order and pooled into bins with an expected count of at least 5; the
+// last bin takes any remainder. Observations at values with no
+// probability put the statistic at +Inf.
+func (s *IntSample) ChiSquare(probs map[int]float64) GOFResult {
+	n := float64(s.n)
+	sorted := s.support(probs)
+	for _, x := range sorted {
+		if s.counts[x] > 0 && probs[x] == 0 {
+			return GOFResult{Statistic: math.Inf(1), P: 0}
+		}
+	}
+
+	type bin struct {
+		obs int
+		exp float64
+	}
+	var bins []bin
+	var cur bin
+	for _, x := range sorted {
+		cur.obs += s.counts[x]
+		cur.exp += probs[x] * n
+		if cur.exp >= minExpected {
+			bins = append(bins, cur)
+			cur = bin{}
+		}
+	}
+	if cur.obs > 0 || cur.exp > 0 {
+		if len(bins) == 0 {
+			bins = append(bins, cur)
+		} else {
+			bins[len(bins)-1].obs += cur.obs
+			bins[len(bins)-1].exp += cur.exp
+		}
+	}
+
+	var stat float64
+	for _, b := range bins {
+		d := float64(b.obs) - b.exp
+		stat += d * d / b.exp
+	}
+	df := len(bins) - 1
+	if df < 1 {
+		return GOFResult{Statistic: stat, DF: df, P: 1}
+	}
+	return GOFResult{Statistic: stat, DF: df, P: ChiSquareSurvival(stat, df)}
+}
+
+// KS runs a Kolmogorov-Smirnov test of the sample against a discrete
+// distribution: D is the largest gap between the two cumulative
+// distributions. The p-value comes from the asymptotic Kolmogorov
+// distribution, which is conservative for discrete distributions.
+func (s *IntSample) KS(probs map[int]float64) GOFResult {
+	sorted := s.support(probs)
+
+	n := float64(s.n)
+	var d, empirical, theoretical float64
+	for _, x := range sorted {
+		empirical += float64(s.counts[x]) / n
+		theoretical += probs[x]
+		d = max(d, math.Abs(empirical-theoretical))
+	}
+	return GOFResult{Statistic: d, P: KolmogorovSurvival(math.Sqrt(n) * d)}
+}
+
+// ChiSquareSurvival returns P(X > x) for X chi-square distributed with df
+// degrees of freedom.
+func ChiSquareSurvival(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return upperGammaQ(float64(df)/2, x/2)
+}
+
+// KolmogorovSurvival returns P(K > x) for the Kolmogorov distribution,
+// the limit of sqrt(n) times the KS distance.
+func KolmogorovSurvival(x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	if x < 0.3 {
+		return 1 // the series below converges slowly; the tail is ~1 here
+	}
+	var sum float64
+	for k := 1; k <= 100; k++ {
+		term := math.Exp(-2 * float64(k*k) * x * x)
+		if k%2 == 0 {
+			sum -= term
+		} else {
+			sum += term
+		}
+		if term < 1e-16 {
+			break
+		}
+	}
+	return min(max(2*sum, 0), 1)
+}
+
+// upperGammaQ returns the regularized upper incomplete gamma function
+// Q(a, x), by its series for x < a+1 and its continued fraction otherwise.
+func upperGammaQ(a, x float64) float64 {
+	lga, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lga)
+	if x < a+1 {
+		// P(a, x) = prefix * sum x^n / (a (a+1) ... (a+n))
+		term := 1 / a
+		sum := term
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+		return max(1-prefix*sum, 0)
+	}
+	// Lentz's method for the continued fraction of Q(a, x).
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return prefix * h
+}
+
+// support returns, in increasing order, every value observed in s or
+// given a probability in probs.
+func (s *IntSample) support(probs map[int]float64) []int {
+	vals := make([]int, 0, len(probs)+len(s.counts))
+	for x := range probs {
+		vals = append(vals, x)
+	}
+	for x := range s.counts {
+		if _, ok := probs[x]; !ok {
+			vals = append(vals, x)
+		}
+	}
+	slices.Sort(vals)
+	return vals
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestChiSquareSurvival(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		x    float64
+		df   int
+		want float64
+	}{
+		{3.841459, 1, 0.05},
+		{18.307038, 10, 0.05},
+		{10, 10, 0.440493},
+		{2, 4, 0.735759},
+		{124.342113, 100, 0.05},
+		{0, 3, 1},
+	}
+	for _, tt := range tests {
+		if got := ChiSquareSurvival(tt.x, tt.df); math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("ChiSquareSurvival(%v, %d) = %v, want %v", tt.x, tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestKolmogorovSurvival(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		x, want float64
+	}{
+		{1.358099, 0.05},
+		{1.627624, 0.01},
+		{1.223848, 0.1},
+		{0.1, 1},
+	}
+	for _, tt := range tests {
+		if got := KolmogorovSurvival(tt.x); math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("KolmogorovSurvival(%v) = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestGoodnessOfFit(t *testing.T) {
+	t.Parallel()
+
+	// A fair die against itself and against a loaded one.
+	fair := map[int]float64{1: 1.0 / 6, 2: 1.0 / 6, 3: 1.0 / 6, 4: 1.0 / 6, 5: 1.0 / 6, 6: 1.0 / 6}
+	loaded := map[int]float64{1: 0.1, 2: 0.1, 3: 0.1, 4: 0.1, 5: 0.1, 6: 0.5}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	s := NewIntSample()
+	for range 6000 {
+		s.Add(1 + rng.IntN(6))
+	}
+
+	if chi := s.ChiSquare(fair); chi.DF != 5 || chi.P < 0.01 {
+		t.Errorf("ChiSquare(fair) = %+v, want df 5 and no rejection", chi)
+	}
+	if chi := s.ChiSquare(loaded); chi.P > 1e-6 {
+		t.Errorf("ChiSquare(loaded) = %+v, want rejection", chi)
+	}
+	if ks := s.KS(fair); ks.P < 0.01 {
+		t.Errorf("KS(fair) = %+v, want no rejection", ks)
+	}
+	if ks := s.KS(loaded); ks.P > 1e-6 {
+		t.Errorf("KS(loaded) = %+v, want rejection", ks)
+	}
+
+	// An observation where the distribution has no mass.
+	if chi := sampleOf(1, 7).ChiSquare(map[int]float64{1: 1}); !math.IsInf(chi.Statistic, 1) || chi.P != 0 {
+		t.Errorf("ChiSquare with impossible value = %+v", chi)
+	}
+}
+
+func TestChiSquarePooling(t *testing.T) {
+	t.Parallel()
+
+	// With 20 observations, values 1..5 (1 expected each) pool into one
+	// bin and 6 (15 expected) is its own: one degree of freedom.
+	probs := map[int]float64{1: 0.05, 2: 0.05, 3: 0.05, 4: 0.05, 5: 0.05, 6: 0.75}
+	s := NewIntSample()
+	for x := 1; x <= 5; x++ {
+		s.Add(x)
+	}
+	for range 15 {
+		s.Add(6)
+	}
+	chi := s.ChiSquare(probs)
+	if chi.DF != 1 || chi.Statistic != 0 || chi.P != 1 {
+		t.Errorf("ChiSquare() = %+v, want a perfect fit with df 1", chi)
+	}
+}
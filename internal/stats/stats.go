@@ -0,0 +1,205 @@
+// Package stats provides the summary statistics used to analyze simulated
+// games: exact integer samples with quantiles and their confidence
+// intervals.
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// IntSample accumulates integer observations, such as game scores, as a
+// frequency table. Memory grows with the number of distinct values, not
+// the number of observations, and merging samples is exact in any order.
+type IntSample struct {
+	counts     map[int]int
+	n          int
+	sum, sumSq int64
+	min, max   int
+}
+
+// NewIntSample returns an empty sample.
+func NewIntSample() *IntSample {
+	return &IntSample{counts: make(map[int]int)}
+}
+
+// Add adds one observation.
+func (s *IntSample) Add(x int) {
+	if s.n == 0 || x < s.min {
+		s.min = x
+	}
+	if s.n == 0 || x > s.max {
+		s.max = x
+	}
+	s.counts[x]++
+	s.n++
+	s.sum += int64(x)
+	s.sumSq += int64(x) * int64(x)
+}
+
+// Merge adds every observation in o to s.
+func (s *IntSample) Merge(o *IntSample) {
+	if o.n == 0 {
+		return
+	}
+	if s.n == 0 || o.min < s.min {
+		s.min = o.min
+	}
+	if s.n == 0 || o.max > s.max {
+		s.max = o.max
+	}
+	for x, c := range o.counts {
+		s.counts[x] += c
+	}
+	s.n += o.n
+	s.sum += o.sum
+	s.sumSq += o.sumSq
+}
+
+// N returns the number of observations.
+func (s *IntSample) N() int {
+	return s.n
+}
+
+// Count returns the number of observations equal to x.
+func (s *IntSample) Count(x int) int {
+	return s.counts[x]
+}
+
+// CountAtLeast returns the number of observations greater than or equal
+// to x.
+func (s *IntSample) CountAtLeast(x int) int {
+	n := 0
+	for v, c := range s.counts {
+		if v >= x {
+			n += c
+		}
+	}
+	return n
+}
+
+// Min returns the smallest observation, or 0 if the sample is empty.
+func (s *IntSample) Min() int {
+	return s.min
+}
+
+// Max returns the largest observation, or 0 if the sample is empty.
+func (s *IntSample) Max() int {
+	return s.max
+}
+
+// Mean returns the sample mean, or 0 if the sample is empty.
+func (s *IntSample) Mean() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	return float64(s.sum) / float64(s.n)
+}
+
+// StdDev returns the population standard deviation.
+func (s *IntSample) StdDev() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	mean := s.Mean()
+	return math.Sqrt(max(float64(s.sumSq)/float64(s.n)-mean*mean, 0))
+}
+
+// StdErr returns the standard error of the mean.
+func (s *IntSample) StdErr() float64 {
+	if s.n == 0 {
+		return math.Inf(1)
+	}
+	return s.StdDev() / math.Sqrt(float64(s.n))
+}
+
+// MeanCI returns a confidence interval for the mean at the given level,
+// from the normal approximation to the sampling distribution.
+func (s *IntSample) MeanCI(level float64) (lo, hi float64) {
+	half := NormalQuantile(0.5+level/2) * s.StdErr()
+	return s.Mean() - half, s.Mean() + half
+}
+
+// ZTest tests whether the mean differs from mu0, returning the z
+// statistic and its two-sided p-value under the normal approximation,
+// which suits the large samples of simulations. Applied to paired
+// differences it is a paired test.
+func (s *IntSample) ZTest(mu0 float64) (z, p float64) {
+	diff := s.Mean() - mu0
+	se := s.StdErr()
+	if se == 0 || math.IsInf(se, 1) {
+		if diff == 0 || s.n == 0 {
+			return 0, 1
+		}
+		return math.Copysign(math.Inf(1), diff), 0
+	}
+	z = diff / se
+	return z, math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// Values returns the distinct observed values in increasing order.
+func (s *IntSample) Values() []int {
+	vals := make([]int, 0, len(s.counts))
+	for x := range s.counts {
+		vals = append(vals, x)
+	}
+	slices.Sort(vals)
+	return vals
+}
+
+// Rank returns the k-th smallest observation (1-based), clamping k to
+// [1, N]. It returns 0 if the sample is empty.
+func (s *IntSample) Rank(k int) int {
+	if s.n == 0 {
+		return 0
+	}
+	k = min(max(k, 1), s.n)
+	seen := 0
+	for _, x := range s.Values() {
+		seen += s.counts[x]
+		if seen >= k {
+			return x
+		}
+	}
+	return s.max
+}
+
+// Quantile returns the p-quantile (0 <= p <= 1) by the nearest-rank
+// method: the smallest observation with at least a fraction p of the
+// sample at or below it.
+func (s *IntSample) Quantile(p float64) int {
+	return s.Rank(int(math.Ceil(p * float64(s.n))))
+}
+
+// QuantileCI returns a distribution-free confidence interval for the
+// p-quantile at the given level (e.g. 0.95). The bounds are order
+// statistics whose ranks come from the normal approximation to the
+// binomial count of observations below the quantile.
+func (s *IntSample) QuantileCI(p, level float64) (lo, hi int) {
+	n := float64(s.n)
+	half := NormalQuantile(0.5+level/2) * math.Sqrt(n*p*(1-p))
+	return s.Rank(int(math.Floor(n*p - half))), s.Rank(int(math.Ceil(n*p+half)) + 1)
+}
+
+// NormalQuantile returns the p-quantile of the standard normal
+// distribution, e.g. 1.96 for p = 0.975.
+func NormalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// ProportionCI returns the Wilson score interval for a proportion at the
+// given level, after k successes in n trials. Unlike the normal
+// approximation it stays inside [0, 1] and is usable for proportions
+// near 0 or 1. It returns [0, 1] if n is 0.
+func ProportionCI(k, n int, level float64) (lo, hi float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	z := NormalQuantile(0.5 + level/2)
+	nf := float64(n)
+	p := float64(k) / nf
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	half := z / denom * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	return max(center-half, 0), min(center+half, 1)
+}
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func sampleOf(xs ...int) *IntSample {
+	s := NewIntSample()
+	for _, x := range xs {
+		s.Add(x)
+	}
+	return s
+}
+
+func TestIntSampleMoments(t *testing.T) {
+	t.Parallel()
+
+	s := sampleOf(2, 4, 4, 4, 5, 5, 7, 9)
+	if s.N() != 8 || s.Min() != 2 || s.Max() != 9 || s.Count(4) != 3 {
+		t.Errorf("N, Min, Max, Count(4) = %d, %d, %d, %d", s.N(), s.Min(), s.Max(), s.Count(4))
+	}
+	if s.CountAtLeast(5) != 4 || s.CountAtLeast(10) != 0 || s.CountAtLeast(2) != 8 {
+		t.Errorf("CountAtLeast(5, 10, 2) = %d, %d, %d; want 4, 0, 8", s.CountAtLeast(5), s.CountAtLeast(10), s.CountAtLeast(2))
+	}
+	if s.Mean() != 5 {
+		t.Errorf("Mean() = %v, want 5", s.Mean())
+	}
+	if s.StdDev() != 2 {
+		t.Errorf("StdDev() = %v, want 2", s.StdDev())
+	}
+	if want := 2 / math.Sqrt(8); math.Abs(s.StdErr()-want) > 1e-12 {
+		t.Errorf("StdErr() = %v, want %v", s.StdErr(), want)
+	}
+
+	empty := NewIntSample()
+	if empty.Mean() != 0 || empty.StdDev() != 0 || !math.IsInf(empty.StdErr(), 1) || empty.Quantile(0.5) != 0 {
+		t.Error("empty sample statistics are not zero")
+	}
+}
+
+func TestIntSampleQuantile(t *testing.T) {
+	t.Parallel()
+
+	// 1..100: the nearest-rank p-quantile is ceil(100p).
+	s := NewIntSample()
+	for x := 100; x >= 1; x-- {
+		s.Add(x)
+	}
+
+	tests := []struct {
+		p    float64
+		want int
+	}{
+		{0, 1},
+		{0.01, 1},
+		{0.05, 5},
+		{0.25, 25},
+		{0.5, 50},
+		{0.505, 51},
+		{0.99, 99},
+		{1, 100},
+	}
+	for _, tt := range tests {
+		if got := s.Quantile(tt.p); got != tt.want {
+			t.Errorf("Quantile(%v) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+
+	if got := sampleOf(3, 3, 3, 8).Quantile(0.75); got != 3 {
+		t.Errorf("Quantile(0.75) with ties = %d, want 3", got)
+	}
+}
+
+func TestIntSampleQuantileCI(t *testing.T) {
+	t.Parallel()
+
+	s := NewIntSample()
+	for x := 1; x <= 1000; x++ {
+		s.Add(x)
+	}
+
+	// For the median of 1000 observations the 95% ranks are about
+	// 500 -/+ 1.96*sqrt(250) = 469 and 532.
+	lo, hi := s.QuantileCI(0.5, 0.95)
+	if lo != 469 || hi != 532 {
+		t.Errorf("QuantileCI(0.5) = [%d, %d], want [469, 532]", lo, hi)
+	}
+	for _, p := range []float64{0.01, 0.05, 0.25, 0.75, 0.95, 0.99} {
+		lo, hi := s.QuantileCI(p, 0.95)
+		if q := s.Quantile(p); lo > q || hi < q {
+			t.Errorf("QuantileCI(%v) = [%d, %d] does not contain the quantile %d", p, lo, hi, q)
+		}
+	}
+}
+
+func TestIntSampleMerge(t *testing.T) {
+	t.Parallel()
+
+	a := sampleOf(5, 1, 9)
+	b := sampleOf(7, 7, 0)
+	all := sampleOf(5, 1, 9, 7, 7, 0)
+
+	a.Merge(b)
+	a.Merge(NewIntSample())
+	if a.N() != all.N() || a.Mean() != all.Mean() || a.StdDev() != all.StdDev() ||
+		a.Min() != all.Min() || a.Max() != all.Max() || a.Quantile(0.5) != all.Quantile(0.5) {
+		t.Errorf("merged sample differs from combined sample")
+	}
+
+	empty := NewIntSample()
+	empty.Merge(sampleOf(4))
+	if empty.Min() != 4 || empty.Max() != 4 {
+		t.Errorf("merge into empty: Min, Max = %d, %d; want 4, 4", empty.Min(), empty.Max())
+	}
+}
+
+func TestIntSampleMeanTests(t *testing.T) {
+	t.Parallel()
+
+	s := sampleOf(2, 4, 4, 4, 5, 5, 7, 9) // mean 5, std error 2/sqrt(8)
+	se := 2 / math.Sqrt(8)
+	lo, hi := s.MeanCI(0.95)
+	if math.Abs(lo-(5-1.959964*se)) > 1e-6 || math.Abs(hi-(5+1.959964*se)) > 1e-6 {
+		t.Errorf("MeanCI(0.95) = [%v, %v]", lo, hi)
+	}
+
+	tests := []struct {
+		s     *IntSample
+		mu0   float64
+		wantZ float64
+		wantP float64
+	}{
+		{s, 5, 0, 1},
+		{s, 5 - 1.959964*se, 1.959964, 0.05},
+		{s, 5 + 1.959964*se, -1.959964, 0.05},
+		{sampleOf(3, 3, 3), 3, 0, 1},
+		{sampleOf(3, 3, 3), 0, math.Inf(1), 0},
+		{NewIntSample(), 1, 0, 1},
+	}
+	for _, tt := range tests {
+		z, p := tt.s.ZTest(tt.mu0)
+		if !(z == tt.wantZ || math.Abs(z-tt.wantZ) < 1e-5) || math.Abs(p-tt.wantP) > 1e-5 {
+			t.Errorf("ZTest(%v) = %v, %v; want %v, %v", tt.mu0, z, p, tt.wantZ, tt.wantP)
+		}
+	}
+}
+
+func TestNormalQuantile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		p, want float64
+	}{
+		{0.5, 0},
+		{0.975, 1.959964},
+		{0.995, 2.575829},
+		{0.025, -1.959964},
+	}
+	for _, tt := range tests {
+		if got := NormalQuantile(tt.p); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("NormalQuantile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestProportionCI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		k, n   int
+		lo, hi float64
+	}{
+		{50, 100, 0.403831, 0.596169},
+		{0, 20, 0, 0.161125},
+		{20, 20, 0.838875, 1},
+		{3, 10, 0.107789, 0.603221},
+		{0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		lo, hi := ProportionCI(tt.k, tt.n, 0.95)
+		if math.Abs(lo-tt.lo) > 1e-5 || math.Abs(hi-tt.hi) > 1e-5 {
+			t.Errorf("ProportionCI(%d, %d) = [%v, %v], want [%v, %v]", tt.k, tt.n, lo, hi, tt.lo, tt.hi)
+		}
+	}
+}